@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"neuroscan/internal/cache"
+	"neuroscan/internal/database"
+	"neuroscan/internal/domain"
+	"neuroscan/internal/repository"
+	"neuroscan/internal/service"
+	"neuroscan/pkg/cluster"
+	"neuroscan/pkg/logging"
+
+	"github.com/joho/godotenv"
+)
+
+type ClusterCmd struct {
+	Timepoint     int     `required:"" help:"Timepoint whose contacts are clustered"`
+	Linkage       string  `default:"average" enum:"average,single,complete" help:"How the similarity of two clusters is derived from their contact areas"`
+	MinSimilarity float64 `default:"0" help:"Clusters linked by less contact area than this are kept apart"`
+	MaxIterations int     `default:"0" help:"Stop after this many iterations, 0 runs until nothing merges"`
+	Output        string  `short:"o" help:"File the CPHATE structure is written to, stdout when empty"`
+	Save          bool    `help:"Store the result as the CPHATE of the timepoint, replacing the ingested one"`
+}
+
+func (cmd *ClusterCmd) Run(ctx *context.Context) error {
+	logger := logging.NewLoggerFromEnv()
+
+	err := godotenv.Load()
+	if err != nil {
+		logger.Info().Err(err).Msg("🤯 failed to load environment variables")
+	}
+
+	cntx := logging.WithLogger(*ctx, logger)
+
+	db, err := database.NewFromEnv(cntx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("🤯 failed to connect to database")
+		return err
+	}
+	defer db.Close(cntx)
+
+	cache, err := cache.NewCache(cntx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("🤯 failed to connect to cache")
+		return fmt.Errorf("failed to connect to cache: %w", err)
+	}
+
+	neuronService := service.NewNeuronService(repository.NewPostgresNeuronRepository(db.Pool, cache))
+	contactService := service.NewContactService(repository.NewPostgresContactRepository(db.Pool, cache))
+	cphateService := service.NewCphateService(repository.NewPostgresCphateRepository(db.Pool, cache))
+
+	graph, err := cmd.contactGraph(cntx, neuronService, contactService)
+	if err != nil {
+		return err
+	}
+
+	levels, err := cluster.Agglomerate(graph, cluster.Options{
+		Linkage:       cluster.Linkage(cmd.Linkage),
+		MinSimilarity: cmd.MinSimilarity,
+		MaxIterations: cmd.MaxIterations,
+	})
+	if err != nil {
+		return err
+	}
+
+	cphate := domain.NewClusteredCphate(cmd.Timepoint, levels)
+	logger.Info().Int("neurons", graph.Len()).Int("iterations", len(levels)).Int("clusters", len(cphate.Structure)).Msg("Clustered contacts")
+
+	if err := cmd.write(cphate); err != nil {
+		return fmt.Errorf("failed to write cphate: %w", err)
+	}
+
+	if cmd.Save {
+		if _, err := cphateService.IngestCphate(cntx, cphate, false, true); err != nil {
+			return fmt.Errorf("failed to save cphate: %w", err)
+		}
+		logger.Info().Int("timepoint", cmd.Timepoint).Msg("Saved cphate")
+	}
+
+	return nil
+}
+
+// contactGraph connects the neurons of the timepoint by the surface area of their contacts.
+// A contact is listed once from each side, both add to the same edge.
+func (cmd *ClusterCmd) contactGraph(ctx context.Context, neurons service.NeuronService, contacts service.ContactService) (*cluster.Graph, error) {
+	logger := logging.FromContext(ctx)
+
+	uids, err := neurons.NeuronUIDs(ctx, cmd.Timepoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get neurons: %w", err)
+	}
+
+	areas, err := contacts.ContactAreas(ctx, cmd.Timepoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact areas: %w", err)
+	}
+
+	if len(uids) == 0 && len(areas) == 0 {
+		return nil, fmt.Errorf("no neurons or contacts for timepoint %d", cmd.Timepoint)
+	}
+
+	graph := cluster.NewGraph()
+	for _, uid := range uids {
+		graph.AddNode(uid)
+	}
+
+	for _, area := range areas {
+		cell, partner, ok := area.Cells()
+		if !ok {
+			logger.Warn().Str("uid", area.UID).Msg("Skipping contact without two cells")
+			continue
+		}
+		graph.AddEdge(cell, partner, area.SurfaceArea)
+	}
+
+	return graph, nil
+}
+
+func (cmd *ClusterCmd) write(cphate domain.Cphate) error {
+	var w io.Writer = os.Stdout
+	if cmd.Output != "" {
+		file, err := os.Create(cmd.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(cphate)
+}
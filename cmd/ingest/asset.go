@@ -0,0 +1,99 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/toolshed"
+)
+
+// recordAsset stores the hash and size of a file served from /files so it gets a strong ETag,
+// and with --precompress-dir writes its brotli and gzip variants. The asset is keyed by
+// servedPath, the file's path under APP_GLTF_DIR, and the variants are written at the same
+// path under the precompress directory. A failure is reported, the entity is still ingested
+// and the file is served without them.
+func (n *Ingestor) recordAsset(ctx context.Context, entity string, fsys fs.FS, filePath string, servedPath string) {
+	info, err := fs.Stat(fsys, filePath)
+	if err != nil {
+		n.fail(ctx, entity, filePath, err, "Error reading asset")
+		return
+	}
+
+	hash, err := toolshed.HashFile(fsys, filePath)
+	if err != nil {
+		n.fail(ctx, entity, filePath, err, "Error hashing asset")
+		return
+	}
+
+	asset := domain.Asset{
+		Path: servedPath,
+		Hash: hash,
+		Size: info.Size(),
+	}
+
+	if n.precompressDir != "" {
+		asset.Encodings, err = toolshed.PrecompressFile(fsys, filePath, n.precompressDir, servedPath)
+		if err != nil {
+			n.fail(ctx, entity, filePath, err, "Error precompressing asset")
+			return
+		}
+	}
+
+	if err := n.services.assets.SaveAsset(ctx, asset); err != nil {
+		n.fail(ctx, entity, filePath, err, "Error saving asset")
+	}
+}
+
+// gltfPrefix returns where the source sits under APP_GLTF_DIR, the directory /files serves, so
+// that the paths stored on ingest can be read back by the web server. It is the --gltf-prefix
+// flag when given, else the path of a local source directory inside gltfDir, else the root.
+func gltfPrefix(source string, prefix string, gltfDir string) (string, error) {
+	if prefix != "" {
+		prefix = path.Clean(strings.Trim(filepath.ToSlash(prefix), "/"))
+		if !fs.ValidPath(prefix) {
+			return "", fmt.Errorf("--gltf-prefix %q must be a relative path inside APP_GLTF_DIR", prefix)
+		}
+		// only the cleaned root is dropped, dot directories like .cache are kept
+		if prefix == "." {
+			prefix = ""
+		}
+		return prefix, nil
+	}
+
+	if gltfDir == "" || strings.HasPrefix(source, "s3://") {
+		return "", nil
+	}
+
+	info, err := os.Stat(source)
+	if err != nil || !info.IsDir() {
+		return "", nil
+	}
+
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return "", err
+	}
+	absGltfDir, err := filepath.Abs(gltfDir)
+	if err != nil {
+		return "", err
+	}
+
+	// a source outside of APP_GLTF_DIR is taken to be a copy of it
+	rel, err := filepath.Rel(absGltfDir, absSource)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", nil
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// servedPath returns the path of a source file under APP_GLTF_DIR
+func (n *Ingestor) servedPath(filePath string) string {
+	return path.Join(n.gltfPrefix, filePath)
+}
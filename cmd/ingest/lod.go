@@ -0,0 +1,80 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"neuroscan/pkg/gltf"
+	"neuroscan/pkg/mesh"
+)
+
+// lodLevel is a simplified version of a mesh, named after the percentage of triangles it keeps.
+type lodLevel struct {
+	name  string
+	ratio float64
+}
+
+var lodLevels = []lodLevel{
+	{name: "50", ratio: 0.5},
+	{name: "10", ratio: 0.1},
+}
+
+// writeLODs simplifies the glTF file at filePath and writes a GLB per level into the LOD
+// directory, at the path the file is served from under APP_GLTF_DIR so that /files serves
+// them next to it when the LOD directory is APP_GLTF_DIR. Each LOD is recorded as an asset
+// like its source. It returns the written filenames keyed by level.
+func (n *Ingestor) writeLODs(ctx context.Context, entity string, filePath string) (map[string]string, error) {
+	doc, err := gltf.OpenFS(n.fsys, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	servedDir := path.Dir(n.servedPath(filePath))
+	dir := filepath.Join(n.lodDir, filepath.FromSlash(servedDir))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	filenames := make(map[string]string, len(lodLevels))
+
+	for _, level := range lodLevels {
+		lod, err := mesh.LOD(doc, level.ratio)
+		if err != nil {
+			return nil, err
+		}
+
+		filename := base + ".lod" + level.name + ".glb"
+		if err := n.saveLOD(lod, filepath.Join(dir, filename)); err != nil {
+			return nil, err
+		}
+
+		filenames[level.name] = filename
+		lodPath := path.Join(servedDir, filename)
+		n.recordAsset(ctx, entity, os.DirFS(n.lodDir), lodPath, lodPath)
+	}
+
+	return filenames, nil
+}
+
+// saveLOD writes a LOD as a GLB, compressed with EXT_meshopt_compression when --lod-meshopt is set.
+func (n *Ingestor) saveLOD(doc *gltf.Document, name string) error {
+	if !n.lodMeshopt {
+		return gltf.SaveBinary(doc, name)
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := gltf.NewEncoder(f)
+	enc.Meshopt = true
+	if err := enc.Encode(doc); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
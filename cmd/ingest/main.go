@@ -2,12 +2,18 @@ package ingest
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
 
 	"neuroscan/internal/cache"
 	"neuroscan/internal/database"
@@ -16,86 +22,114 @@ import (
 	"neuroscan/internal/service"
 	"neuroscan/internal/toolshed"
 	"neuroscan/pkg/logging"
+	"neuroscan/pkg/storage"
 )
 
 type IngestCmd struct {
-	DirPath      string   `required:"" help:"Path to the directory" short:"d"`
-	Verbose      bool     `optional:"" help:"Enable verbose logging" short:"v"`
-	SkipExisting bool     `optional:"" help:"Skip existing files" short:"s"`
-	ThreadCount  int      `optional:"" help:"Number of threads to use" short:"t"`
-	ProcessTypes []string `optional:"" help:"Types of entities to process" short:"p"`
-	Clean        bool     `optional:"" help:"Clean the database before ingesting" short:"c"`
+	DirPath              string   `required:"" help:"Directory, s3://bucket/prefix or .tar/.tar.gz archive to ingest" short:"d"`
+	ScaleMicrometers     float64  `optional:"" name:"scale-micrometers" help:"Physical length in micrometers of the scale bars whose glTF has no micrometers extra, used to export meshes in micrometers"`
+	GltfPrefix           string   `optional:"" name:"gltf-prefix" help:"Path of the source under APP_GLTF_DIR, where /files serves it from. Defaults to the path of a source directory inside APP_GLTF_DIR, otherwise the source is taken to be APP_GLTF_DIR itself"`
+	TempDir              string   `optional:"" name:"temp-dir" help:"Directory a .tar.gz source is decompressed into, it needs as much free space as the decompressed archive. Defaults to the system temporary directory" type:"existingdir"`
+	Verbose              bool     `optional:"" help:"Enable verbose logging" short:"v"`
+	SkipExisting         bool     `optional:"" help:"Skip existing files" short:"s"`
+	ThreadCount          int      `optional:"" help:"Number of workers per entity type, defaults to the number of CPUs" short:"t"`
+	ProcessTypes         []string `optional:"" help:"Types of entities to process" short:"p"`
+	Clean                bool     `optional:"" help:"Clean the database before ingesting" short:"c"`
+	Layout               string   `optional:"" help:"YAML file describing the directory layout of the source, defaults to <STAGE>/<TIMEPOINT>/<ENTITY>/<FILE>" short:"l"`
+	MaxErrors            int      `optional:"" help:"Number of failed files or rows tolerated before ingest exits with an error" default:"0"`
+	Report               string   `optional:"" help:"Write a JSON summary of the run, including every failure, to this path"`
+	NoProgress           bool     `optional:"" help:"Disable the progress bars"`
+	DiscrepancyThreshold float64  `optional:"" help:"Relative difference between CSV and mesh volume or surface area above which a neuron is reported" default:"0.05"`
+	LODDir               string   `optional:"" name:"lod-dir" help:"Write simplified level of detail GLBs of neurons, contacts and synapses to this directory, mirroring the source layout"`
+	LODMeshopt           bool     `optional:"" name:"lod-meshopt" help:"Compress the level of detail GLBs with EXT_meshopt_compression, viewers need a meshopt decoder to load them"`
+	PrecompressDir       string   `optional:"" name:"precompress-dir" help:"Write brotli and gzip variants of every served glTF file and LOD to this directory, mirroring the source layout"`
+	Wormbase             string   `optional:"" name:"wormbase" help:"Tab separated WormBase gene file to check promoter WormBase IDs against and fill their gene names and descriptions from" type:"existingfile"`
 }
 
 type Ingestor struct {
-	neurons      int64
-	synapses     int64
-	contacts     int64
-	cphates      int64
-	nerveRings   int64
-	scales       int64
-	promoters    int64
-	devStages    int64
-	meta         int64
-	skipExisting bool
-	debug        bool
-	clean        bool
-	processTypes []string
-	DevStages    []domain.DevelopmentalStage
-	threadCount  int
+	neurons          int64
+	synapses         int64
+	contacts         int64
+	cphates          int64
+	nerveRings       int64
+	scales           int64
+	promoters        int64
+	devStages        int64
+	timepoints       int64
+	meta             int64
+	skipExisting     bool
+	debug            bool
+	clean            bool
+	processTypes     []string
+	DevStages        []domain.DevelopmentalStage
+	threadCount      int
+	fsys             fs.FS
+	layout           *toolshed.Layout
+	report           *IngestReport
+	progress         *ingestProgress
+	services         ingestServices
+	lodDir           string
+	lodMeshopt       bool
+	precompressDir   string
+	gltfPrefix       string
+	scaleMicrometers float64
+	wormbase         *domain.WormbaseGenes
 }
 
-type ingestChannels struct {
-	neurons    chan string
-	contacts   chan string
-	synapses   chan string
-	cphates    chan string
-	nerveRings chan string
-	scales     chan string
-	promoters  chan string
-	devStages  chan string
-	meta       chan string
+type ingestServices struct {
+	neurons    service.NeuronService
+	contacts   service.ContactService
+	synapses   service.SynapseService
+	cphates    service.CphateService
+	nerveRings service.NerveRingService
+	scales     service.ScaleService
+	promoters  service.PromoterService
+	devStages  service.DevelopmentalStageService
+	assets     service.AssetService
+	timepoints service.TimepointService
 }
 
-type ingestWaitGroups struct {
-	neurons    sync.WaitGroup
-	contacts   sync.WaitGroup
-	synapses   sync.WaitGroup
-	cphates    sync.WaitGroup
-	nerveRings sync.WaitGroup
-	scales     sync.WaitGroup
-	promoters  sync.WaitGroup
-	devStages  sync.WaitGroup
-	meta       sync.WaitGroup
+// ingestTask is a group of queued paths processed by its own pool of workers
+type ingestTask struct {
+	// entity is the entity type the paths belong to, used for progress and the report
+	entity string
+	// queue is the name the paths are indexed under while walking the source
+	queue  string
+	ingest func(ctx context.Context, path string)
 }
 
-// createIngestChannels creates the ingest channels
-func createIngestChannels() *ingestChannels {
-	return &ingestChannels{
-		neurons:    make(chan string, 10_000),
-		contacts:   make(chan string, 100_000),
-		synapses:   make(chan string, 100_000),
-		cphates:    make(chan string, 20),
-		nerveRings: make(chan string, 20),
-		scales:     make(chan string, 20),
-		promoters:  make(chan string, 500),
-		devStages:  make(chan string, 100),
-		meta:       make(chan string, 100_000),
-	}
-}
+// Meta files are indexed under the entity they annotate, as they can only be
+// applied once that entity has been ingested.
+const (
+	queueNeuronMeta  = "meta:neurons"
+	queueContactMeta = "meta:contacts"
+)
 
-// createIngestWaitGroups creates the ingest wait groups
-func createIngestWaitGroups() *ingestWaitGroups {
-	return &ingestWaitGroups{
-		neurons:    sync.WaitGroup{},
-		contacts:   sync.WaitGroup{},
-		synapses:   sync.WaitGroup{},
-		cphates:    sync.WaitGroup{},
-		nerveRings: sync.WaitGroup{},
-		scales:     sync.WaitGroup{},
-		promoters:  sync.WaitGroup{},
-		devStages:  sync.WaitGroup{},
-		meta:       sync.WaitGroup{},
+// ingestStages returns the tasks of each stage. Tasks within a stage run
+// concurrently, a stage only starts once every task of the previous one is done.
+func (n *Ingestor) ingestStages() [][]ingestTask {
+	return [][]ingestTask{
+		{
+			{entity: "neurons", queue: "neurons", ingest: n.ingestNeuron},
+			{entity: "scene", queue: "scene", ingest: n.ingestScene},
+			{entity: "cphate", queue: "cphate", ingest: n.ingestCphate},
+			{entity: "nerveRing", queue: "nerveRing", ingest: n.ingestNerveRing},
+			{entity: "scale", queue: "scale", ingest: n.ingestScale},
+			{entity: "promoters", queue: "promoters", ingest: n.ingestPromoters},
+			{entity: "timepoints", queue: "timepoints", ingest: n.ingestTimepoints},
+		},
+		{
+			{entity: "meta", queue: queueNeuronMeta, ingest: n.ingestMeta},
+			// developmental stages list timepoints, which the timepoints CSV may add
+			{entity: "dev_stages", queue: "dev_stages", ingest: n.ingestDevStages},
+		},
+		{
+			{entity: "contacts", queue: "contacts", ingest: n.ingestContact},
+			{entity: "synapses", queue: "synapses", ingest: n.ingestSynapse},
+		},
+		{
+			{entity: "meta", queue: queueContactMeta, ingest: n.ingestMeta},
+		},
 	}
 }
 
@@ -122,490 +156,730 @@ func (cmd *IngestCmd) Run(ctx *context.Context) error {
 
 	defer db.Close(cntx)
 
+	source, err := storage.OpenSource(cntx, cmd.DirPath, cmd.TempDir)
+	if err != nil {
+		logger.Error().Err(err).Str("path", cmd.DirPath).Msg("🤯 failed to open ingest source")
+		return err
+	}
+
+	defer source.Close()
+
+	layout := toolshed.DefaultLayout()
+	if cmd.Layout != "" {
+		layout, err = toolshed.LoadLayout(cmd.Layout)
+		if err != nil {
+			logger.Error().Err(err).Str("path", cmd.Layout).Msg("🤯 failed to load layout")
+			return err
+		}
+	}
+
+	prefix, err := gltfPrefix(cmd.DirPath, cmd.GltfPrefix, os.Getenv("APP_GLTF_DIR"))
+	if err != nil {
+		logger.Error().Err(err).Msg("🤯 failed to place the source under APP_GLTF_DIR")
+		return err
+	}
+
 	n := &Ingestor{
-		neurons:      0,
-		synapses:     0,
-		contacts:     0,
-		cphates:      0,
-		nerveRings:   0,
-		scales:       0,
-		promoters:    0,
-		devStages:    0,
-		meta:         0,
-		skipExisting: cmd.SkipExisting,
-		debug:        cmd.Verbose,
-		clean:        cmd.Clean,
-		processTypes: cmd.ProcessTypes,
-		threadCount:  cmd.ThreadCount,
+		neurons:          0,
+		synapses:         0,
+		contacts:         0,
+		cphates:          0,
+		nerveRings:       0,
+		scales:           0,
+		promoters:        0,
+		devStages:        0,
+		timepoints:       0,
+		meta:             0,
+		skipExisting:     cmd.SkipExisting,
+		debug:            cmd.Verbose,
+		clean:            cmd.Clean,
+		processTypes:     cmd.ProcessTypes,
+		threadCount:      cmd.ThreadCount,
+		fsys:             source,
+		layout:           layout,
+		report:           newIngestReport(cmd.DirPath),
+		progress:         newIngestProgress(!cmd.NoProgress),
+		lodDir:           cmd.LODDir,
+		lodMeshopt:       cmd.LODMeshopt,
+		precompressDir:   cmd.PrecompressDir,
+		gltfPrefix:       prefix,
+		scaleMicrometers: cmd.ScaleMicrometers,
 	}
 
 	// if processTypes is empty, set it to all valid process types
 	if len(n.processTypes) == 0 {
-		n.processTypes = []string{"neurons", "contacts", "synapses", "cphate", "nerveRing", "scale", "promoters", "dev_stages"}
+		n.processTypes = toolshed.EntityTypes
 	}
 
-	// We use channels and wait groups to handle the ingestion of entities concurrently
-	channels := createIngestChannels()
-	waitGroups := createIngestWaitGroups()
+	// get the max number of routines to use per entity type
+	if n.threadCount <= 0 {
+		n.threadCount = toolshed.MaxParallelism()
+	}
 
-	// get the max number of routines to use
-	maxRoutines := toolshed.MaxParallelism()
+	// stop handing out work on Ctrl-C, files already being ingested are allowed to finish
+	cntx, stop := signal.NotifyContext(cntx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	neuronRepo := repository.NewPostgresNeuronRepository(db.Pool, cache)
-	neuronService := service.NewNeuronService(neuronRepo)
+	devStageRepo := repository.NewPostgresDevelopmentalStageRepository(db.Pool, cache)
 
-	contactRepo := repository.NewPostgresContactRepository(db.Pool, cache)
-	contactService := service.NewContactService(contactRepo)
+	n.services = ingestServices{
+		neurons:    service.NewNeuronService(repository.NewPostgresNeuronRepository(db.Pool, cache)),
+		contacts:   service.NewContactService(repository.NewPostgresContactRepository(db.Pool, cache)),
+		synapses:   service.NewSynapseService(repository.NewPostgresSynapseRepository(db.Pool, cache)),
+		cphates:    service.NewCphateService(repository.NewPostgresCphateRepository(db.Pool, cache)),
+		nerveRings: service.NewNerveRingService(repository.NewPostgresNerveRingRepository(db.Pool, cache)),
+		scales:     service.NewScaleService(repository.NewPostgresScaleRepository(db.Pool, cache)),
+		promoters:  service.NewPromoterService(repository.NewPostgresPromoterRepository(db.Pool, cache)),
+		devStages:  service.NewDevelopmentalStageService(devStageRepo),
+		assets:     service.NewAssetService(repository.NewPostgresAssetRepository(db.Pool, cache)),
+		timepoints: service.NewTimepointService(repository.NewPostgresTimepointRepository(db.Pool, cache), devStageRepo),
+	}
 
-	synapseRepo := repository.NewPostgresSynapseRepository(db.Pool, cache)
-	synapseService := service.NewSynapseService(synapseRepo)
+	if n.clean {
+		n.truncate(cntx)
+	}
 
-	cphateRepo := repository.NewPostgresCphateRepository(db.Pool, cache)
-	cphateService := service.NewCphateService(cphateRepo)
+	if cmd.Wormbase != "" {
+		n.wormbase = n.loadWormbase(cntx, cmd.Wormbase)
+	}
 
-	nerveRingRepo := repository.NewPostgresNerveRingRepository(db.Pool, cache)
-	nerveRingService := service.NewNerveRingService(nerveRingRepo)
+	queues, err := n.walkDirFolder(cntx)
+	if err != nil {
+		n.fail(cntx, "", cmd.DirPath, err, "Error walking source")
+	}
 
-	scaleRepo := repository.NewPostgresScaleRepository(db.Pool, cache)
-	scaleService := service.NewScaleService(scaleRepo)
+	n.progress.WalkDone()
 
-	promoterRepo := repository.NewPostgresPromoterRepository(db.Pool, cache)
-	promoterService := service.NewPromoterService(promoterRepo)
+	// entities reference their timepoint, so every timepoint in the source is created first
+	n.saveTimepoints(cntx, queues)
 
-	devStageRepo := repository.NewPostgresDevelopmentalStageRepository(db.Pool, cache)
-	devStageService := service.NewDevelopmentalStageService(devStageRepo)
+	for i, stage := range n.ingestStages() {
+		logger.Debug().Int("stage", i+1).Msg("Starting ingest stage")
 
-	if n.clean {
-		for _, processType := range n.processTypes {
-			switch processType {
-			case "neurons":
-				err := neuronService.TruncateNeurons(cntx)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error truncating neurons")
-				}
-			case "contacts":
-				err := contactService.TruncateContacts(cntx)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error truncating contacts")
-				}
-			case "synapses":
-				err := synapseService.TruncateSynapses(cntx)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error truncating synapses")
-				}
-			case "cphate":
-				err := cphateService.TruncateCphates(cntx)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error truncating cphates")
-				}
-			case "nerveRing":
-				err := nerveRingService.TruncateNerveRings(cntx)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error truncating nerveRings")
-				}
-			case "scale":
-				err := scaleService.TruncateScales(cntx)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error truncating scales")
-				}
-			case "promoters":
-				err := promoterService.TruncatePromoters(cntx)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error truncating promoters")
-				}
-			case "dev_stages":
-				err := devStageService.TruncateDevelopmentalStages(cntx)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error truncating developmental stages")
-				}
-			}
+		var stageGroup sync.WaitGroup
+		for _, task := range stage {
+			stageGroup.Add(1)
+			go func() {
+				defer stageGroup.Done()
+				n.runTask(cntx, task, queues[task.queue])
+			}()
+		}
+
+		stageGroup.Wait()
+
+		if cntx.Err() != nil {
+			break
 		}
 	}
 
-	for w := 1; w <= maxRoutines; w++ {
+	if cntx.Err() == nil && (slices.Contains(n.processTypes, "neurons") || slices.Contains(n.processTypes, "meta")) {
+		n.reportMeshDiscrepancies(cntx, cmd.DiscrepancyThreshold)
+	}
+
+	logger.Info().Msg("Done processing entities")
+	logger.Info().Int64("count", n.neurons).Msg("Neurons ingested")
+	logger.Info().Int64("count", n.contacts).Msg("Contacts ingested")
+	logger.Info().Int64("count", n.synapses).Msg("Synapses ingested")
+	logger.Info().Int64("count", n.cphates).Msg("Cphates ingested")
+	logger.Info().Int64("count", n.nerveRings).Msg("NerveRings ingested")
+	logger.Info().Int64("count", n.scales).Msg("Scales ingested")
+	logger.Info().Int64("count", n.promoters).Msg("Promoters ingested")
+	logger.Info().Int64("count", n.devStages).Msg("DevelopmentalStages ingested")
+	logger.Info().Int64("count", n.timepoints).Msg("Timepoints ingested")
+	logger.Info().Int64("count", n.meta).Msg("Meta files ingested")
+
+	failures := n.report.ErrorTotal()
+	if failures > 0 {
+		logger.Warn().Int("count", failures).Msg("Files or rows failed to ingest")
+	}
+
+	if cmd.Report != "" {
+		n.report.FinishedAt = time.Now()
+		n.report.Ingested = map[string]int64{
+			"neurons":    n.neurons,
+			"contacts":   n.contacts,
+			"synapses":   n.synapses,
+			"cphate":     n.cphates,
+			"nerveRing":  n.nerveRings,
+			"scale":      n.scales,
+			"promoters":  n.promoters,
+			"dev_stages": n.devStages,
+			"timepoints": n.timepoints,
+			"meta":       n.meta,
+		}
+
+		if err := n.report.Write(cmd.Report); err != nil {
+			logger.Error().Err(err).Str("path", cmd.Report).Msg("Error writing ingest report")
+			return err
+		}
+
+		logger.Info().Str("path", cmd.Report).Msg("Ingest report written")
+	}
+
+	if err := cntx.Err(); err != nil {
+		logger.Warn().Msg("Ingest interrupted before all files were processed")
+		return err
+	}
+
+	if failures > cmd.MaxErrors {
+		return fmt.Errorf("ingest failed for %d files or rows, more than the %d allowed by --max-errors", failures, cmd.MaxErrors)
+	}
+
+	return nil
+}
+
+// runTask feeds the paths to a pool of workers. The channel is kept small so
+// memory stays bounded no matter how many files are queued.
+func (n *Ingestor) runTask(ctx context.Context, task ingestTask, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	jobs := make(chan string, n.threadCount)
+
+	// a file that was handed out is ingested to the end, Ctrl-C only stops feeding new ones
+	work := context.WithoutCancel(ctx)
+
+	var workers sync.WaitGroup
+	for range min(n.threadCount, len(paths)) {
+		workers.Add(1)
 		go func() {
-			for neuronPath := range channels.neurons {
-				neuron := domain.Neuron{}
-				err := neuron.Parse(neuronPath)
-				if err != nil {
-					logger.Error().Err(err).Str("path", neuronPath).Msg("Error parsing neuron")
-					waitGroups.neurons.Done()
+			defer workers.Done()
+			for path := range jobs {
+				// files still queued when Ctrl-C came aren't started
+				if ctx.Err() != nil {
 					continue
 				}
+				task.ingest(work, path)
+				n.progress.Processed(task.entity)
+			}
+		}()
+	}
 
-				success, err := neuronService.IngestNeuron(cntx, neuron, n.skipExisting, n.debug)
-				if err != nil {
-					logger.Error().Err(err).Str("path", neuronPath).Msg("Error ingesting neuron")
-					waitGroups.neurons.Done()
-					continue
-				}
+feed:
+	for _, path := range paths {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- path:
+		}
+	}
 
-				if success {
-					atomic.AddInt64(&n.neurons, 1)
-				}
+	close(jobs)
+	workers.Wait()
+}
 
-				waitGroups.neurons.Done()
-			}
+// truncate removes the existing rows of every entity type being processed
+func (n *Ingestor) truncate(ctx context.Context) {
+	for _, processType := range n.processTypes {
+		var err error
 
-			for contactPath := range channels.contacts {
-				contact := domain.Contact{}
-				err := contact.Parse(contactPath)
-				if err != nil {
-					logger.Error().Err(err).Str("path", contactPath).Msg("Error parsing contact")
-					waitGroups.contacts.Done()
-					continue
-				}
+		switch processType {
+		case "neurons":
+			err = n.services.neurons.TruncateNeurons(ctx)
+		case "contacts":
+			err = n.services.contacts.TruncateContacts(ctx)
+		case "synapses":
+			err = n.services.synapses.TruncateSynapses(ctx)
+		case "cphate":
+			err = n.services.cphates.TruncateCphates(ctx)
+		case "nerveRing":
+			err = n.services.nerveRings.TruncateNerveRings(ctx)
+		case "scale":
+			err = n.services.scales.TruncateScales(ctx)
+		case "promoters":
+			err = n.services.promoters.TruncatePromoters(ctx)
+		case "dev_stages":
+			err = n.services.devStages.TruncateDevelopmentalStages(ctx)
+		case "timepoints":
+			// every entity references its timepoint, so only what the timepoints CSV stored is cleared
+			err = n.services.timepoints.ResetTimepoints(ctx)
+		}
 
-				success, err := contactService.IngestContact(cntx, contact, n.skipExisting, n.debug)
-				if err != nil {
-					logger.Error().Err(err).Str("path", contactPath).Msg("Error ingesting contact")
-					waitGroups.contacts.Done()
-					continue
-				}
+		if err != nil {
+			n.fail(ctx, processType, "", err, "Error truncating "+processType)
+		}
+	}
+}
 
-				if success {
-					atomic.AddInt64(&n.contacts, 1)
-				}
+func (n *Ingestor) ingestNeuron(ctx context.Context, neuronPath string) {
+	neuron := domain.Neuron{}
+	err := neuron.Parse(n.fsys, n.layout, neuronPath)
+	if err != nil {
+		n.fail(ctx, "neurons", neuronPath, err, "Error parsing neuron")
+		return
+	}
 
-				waitGroups.contacts.Done()
-			}
+	neuron.FilePath = n.servedPath(neuronPath)
 
-			for synapsePath := range channels.synapses {
-				synapse := domain.Synapse{}
-				err := synapse.Parse(synapsePath)
-				if err != nil {
-					logger.Error().Err(err).Str("path", synapsePath).Msg("Error parsing synapse")
-					waitGroups.synapses.Done()
-					continue
-				}
+	if n.lodDir != "" {
+		// a failed LOD is reported, the neuron is still ingested without it
+		neuron.FilenameLOD, err = n.writeLODs(ctx, "neurons", neuronPath)
+		if err != nil {
+			n.fail(ctx, "neurons", neuronPath, err, "Error generating neuron LOD")
+		}
+	}
 
-				success, err := synapseService.IngestSynapse(cntx, synapse, n.skipExisting, n.debug)
-				if err != nil {
-					logger.Error().Err(err).Str("path", synapsePath).Msg("Error ingesting synapse")
-					waitGroups.synapses.Done()
-					continue
-				}
+	success, err := n.services.neurons.IngestNeuron(ctx, neuron, n.skipExisting, n.debug)
+	if err != nil {
+		n.fail(ctx, "neurons", neuronPath, err, "Error ingesting neuron")
+		return
+	}
 
-				if success {
-					atomic.AddInt64(&n.synapses, 1)
-				}
+	n.recordAsset(ctx, "neurons", n.fsys, neuronPath, n.servedPath(neuronPath))
 
-				waitGroups.synapses.Done()
-			}
+	if success {
+		atomic.AddInt64(&n.neurons, 1)
+	}
+}
 
-			for cphateDir := range channels.cphates {
-				cphate := domain.Cphate{}
-				err := cphate.Parse(cphateDir)
-				if err != nil {
-					logger.Error().Err(err).Str("path", cphateDir).Msg("Error parsing cphate")
-					waitGroups.cphates.Done()
-					continue
-				}
+func (n *Ingestor) ingestContact(ctx context.Context, contactPath string) {
+	contact := domain.Contact{}
+	err := contact.Parse(n.fsys, n.layout, contactPath)
+	if err != nil {
+		n.fail(ctx, "contacts", contactPath, err, "Error parsing contact")
+		return
+	}
 
-				success, err := cphateService.IngestCphate(cntx, cphate, n.skipExisting, n.debug)
-				if err != nil {
-					logger.Error().Err(err).Str("path", cphateDir).Msg("Error ingesting cphate")
-					waitGroups.cphates.Done()
-					continue
-				}
+	contact.FilePath = n.servedPath(contactPath)
 
-				if success {
-					atomic.AddInt64(&n.cphates, 1)
-				}
+	if n.lodDir != "" {
+		// a failed LOD is reported, the contact is still ingested without it
+		contact.FilenameLOD, err = n.writeLODs(ctx, "contacts", contactPath)
+		if err != nil {
+			n.fail(ctx, "contacts", contactPath, err, "Error generating contact LOD")
+		}
+	}
 
-				waitGroups.cphates.Done()
-			}
+	success, err := n.services.contacts.IngestContact(ctx, contact, n.skipExisting, n.debug)
+	if err != nil {
+		n.fail(ctx, "contacts", contactPath, err, "Error ingesting contact")
+		return
+	}
 
-			for nerveRingPath := range channels.nerveRings {
-				nerveRing := domain.NerveRing{}
-				err := nerveRing.Parse(nerveRingPath)
-				if err != nil {
-					logger.Error().Err(err).Str("path", nerveRingPath).Msg("Error parsing nerveRing")
-					waitGroups.nerveRings.Done()
-					continue
-				}
+	n.recordAsset(ctx, "contacts", n.fsys, contactPath, n.servedPath(contactPath))
 
-				success, err := nerveRingService.IngestNerveRing(cntx, nerveRing, n.skipExisting, n.debug)
-				if err != nil {
-					logger.Error().Err(err).Str("path", nerveRingPath).Msg("Error ingesting nerveRing")
-					waitGroups.nerveRings.Done()
-					continue
-				}
+	if success {
+		atomic.AddInt64(&n.contacts, 1)
+	}
+}
 
-				if success {
-					atomic.AddInt64(&n.nerveRings, 1)
-				}
+func (n *Ingestor) ingestSynapse(ctx context.Context, synapsePath string) {
+	synapse := domain.Synapse{}
+	err := synapse.Parse(n.fsys, n.layout, synapsePath)
+	if err != nil {
+		n.fail(ctx, "synapses", synapsePath, err, "Error parsing synapse")
+		return
+	}
 
-				waitGroups.nerveRings.Done()
-			}
+	synapse.FilePath = n.servedPath(synapsePath)
 
-			for scalePath := range channels.scales {
-				scale := domain.Scale{}
-				err := scale.Parse(scalePath)
-				if err != nil {
-					logger.Error().Err(err).Str("path", scalePath).Msg("Error parsing scale")
-					waitGroups.scales.Done()
-					continue
-				}
+	if n.lodDir != "" {
+		// a failed LOD is reported, the synapse is still ingested without it
+		synapse.FilenameLOD, err = n.writeLODs(ctx, "synapses", synapsePath)
+		if err != nil {
+			n.fail(ctx, "synapses", synapsePath, err, "Error generating synapse LOD")
+		}
+	}
 
-				success, err := scaleService.IngestScale(cntx, scale, n.skipExisting, n.debug)
-				if err != nil {
-					logger.Error().Err(err).Str("path", scalePath).Msg("Error ingesting scale")
-					waitGroups.scales.Done()
-					continue
-				}
+	success, err := n.services.synapses.IngestSynapse(ctx, synapse, n.skipExisting, n.debug)
+	if err != nil {
+		n.fail(ctx, "synapses", synapsePath, err, "Error ingesting synapse")
+		return
+	}
 
-				if success {
-					atomic.AddInt64(&n.scales, 1)
-				}
+	n.recordAsset(ctx, "synapses", n.fsys, synapsePath, n.servedPath(synapsePath))
 
-				waitGroups.scales.Done()
-			}
+	if success {
+		atomic.AddInt64(&n.synapses, 1)
+	}
+}
 
-			for promoterPath := range channels.promoters {
-				csvRows, err := toolshed.GetCSVRows(promoterPath)
-				if err != nil {
-					logger.Error().Err(err).Str("path", promoterPath).Msg("Error getting CSV rows")
-					waitGroups.promoters.Done()
-					continue
-				}
+func (n *Ingestor) ingestCphate(ctx context.Context, cphateDir string) {
+	cphate := domain.Cphate{}
+	err := cphate.Parse(n.fsys, n.layout, cphateDir)
+	if err != nil {
+		n.fail(ctx, "cphate", cphateDir, err, "Error parsing cphate")
+		return
+	}
 
-				for i, row := range csvRows {
-					if i == 0 {
-						continue
-					}
-
-					promoter := domain.Promoter{}
-					err := promoter.ParseCSV(row)
-					if err != nil {
-						logger.Error().Err(err).Str("path", promoterPath).Msg("Error parsing promoter")
-						continue
-					}
-
-					success, err := promoterService.IngestPromoter(cntx, promoter, n.skipExisting, n.debug)
-					if err != nil {
-						logger.Error().Err(err).Str("path", promoterPath).Msg("Error ingesting promoter")
-						continue
-					}
-
-					if success {
-						atomic.AddInt64(&n.promoters, 1)
-					}
-				}
+	success, err := n.services.cphates.IngestCphate(ctx, cphate, n.skipExisting, n.debug)
+	if err != nil {
+		n.fail(ctx, "cphate", cphateDir, err, "Error ingesting cphate")
+		return
+	}
 
-				waitGroups.promoters.Done()
-			}
+	if success {
+		atomic.AddInt64(&n.cphates, 1)
+	}
+}
 
-			for devStagePath := range channels.devStages {
-				csvRows, err := toolshed.GetCSVRows(devStagePath)
-				if err != nil {
-					logger.Error().Err(err).Str("path", devStagePath).Msg("Error getting CSV rows")
-					waitGroups.devStages.Done()
-					continue
-				}
+func (n *Ingestor) ingestNerveRing(ctx context.Context, nerveRingPath string) {
+	nerveRing := domain.NerveRing{}
+	err := nerveRing.Parse(n.fsys, n.layout, nerveRingPath)
+	if err != nil {
+		n.fail(ctx, "nerveRing", nerveRingPath, err, "Error parsing nerveRing")
+		return
+	}
 
-				for i, row := range csvRows {
-					if i == 0 {
-						continue
-					}
-
-					devStage := domain.DevelopmentalStage{}
-					err := devStage.ParseCSV(row)
-					if err != nil {
-						logger.Error().Err(err).Str("path", devStagePath).Msg("Error parsing devStage")
-						continue
-					}
-
-					success, err := devStageService.IngestDevelopmentalStage(cntx, devStage, n.skipExisting, n.debug)
-					if err != nil {
-						logger.Error().Err(err).Str("path", devStagePath).Msg("Error ingesting devStage")
-						continue
-					}
-
-					if success {
-						atomic.AddInt64(&n.devStages, 1)
-					}
-				}
+	success, err := n.services.nerveRings.IngestNerveRing(ctx, nerveRing, n.skipExisting, n.debug)
+	if err != nil {
+		n.fail(ctx, "nerveRing", nerveRingPath, err, "Error ingesting nerveRing")
+		return
+	}
 
-				waitGroups.devStages.Done()
-			}
+	n.recordAsset(ctx, "nerveRing", n.fsys, nerveRingPath, n.servedPath(nerveRingPath))
 
-			for metaPath := range channels.meta {
-				csvRows, err := toolshed.GetCSVRows(metaPath)
-				if err != nil {
-					logger.Error().Err(err).Str("path", metaPath).Msg("Error getting CSV rows")
-					waitGroups.meta.Done()
-					continue
-				}
+	if success {
+		atomic.AddInt64(&n.nerveRings, 1)
+	}
+}
 
-				timepoint, err := toolshed.GetTimepoint(metaPath)
-				if err != nil {
-					logger.Error().Err(err).Msg("Error getting meta timepoint")
-					waitGroups.meta.Done()
-					continue
-				}
+func (n *Ingestor) ingestScale(ctx context.Context, scalePath string) {
+	scale := domain.Scale{}
+	err := scale.Parse(n.fsys, n.layout, scalePath)
+	if err != nil {
+		n.fail(ctx, "scale", scalePath, err, "Error parsing scale")
+		return
+	}
 
-				// we have 3 different files, cell_sa, cell_vol, and patch_sa. We need to parse them seperately based on the filename
-				filename := filepath.Base(metaPath)
+	if scale.Micrometers == nil && n.scaleMicrometers > 0 {
+		micrometers := n.scaleMicrometers
+		scale.Micrometers = &micrometers
+	}
 
-				for i, row := range csvRows {
-					if i == 0 {
-						continue
-					}
+	if scale.Micrometers == nil {
+		logging.FromContext(ctx).Warn().Str("path", scalePath).Msg("Scale bar has no length in micrometers, meshes of its timepoint are exported in glTF units")
+	}
 
-					// if the row length is less than 2, continue
-					if len(row) < 2 {
-						logger.Error().Msg("Malformed meta data row")
-						continue
-					}
+	success, err := n.services.scales.IngestScale(ctx, scale, n.skipExisting, n.debug)
+	if err != nil {
+		n.fail(ctx, "scale", scalePath, err, "Error ingesting scale")
+		return
+	}
 
-					if strings.Contains(filename, "cell_sa") {
-						err = neuronService.ParseMeta(cntx, row, timepoint, "surface_area")
-					}
+	n.recordAsset(ctx, "scale", n.fsys, scalePath, n.servedPath(scalePath))
 
-					if strings.Contains(filename, "cell_vol") {
-						err = neuronService.ParseMeta(cntx, row, timepoint, "volume")
-					}
+	if success {
+		atomic.AddInt64(&n.scales, 1)
+	}
+}
 
-					if strings.Contains(filename, "patch_sa") {
-						err = contactService.ParseMeta(cntx, row, timepoint, "surface_area")
-					}
+// ingestScene splits a combined scene file into neurons, contacts and synapses
+func (n *Ingestor) ingestScene(ctx context.Context, scenePath string) {
+	sceneNodes, err := toolshed.ParseScene(n.fsys, n.layout, scenePath)
+	if err != nil {
+		n.fail(ctx, "scene", scenePath, err, "Error parsing scene")
+		return
+	}
 
-					if err != nil {
-						logger.Error().Err(err).Msg("Error parsing meta data")
-						continue
-					}
-				}
+	for _, node := range sceneNodes {
+		var success bool
 
-				waitGroups.meta.Done()
+		switch node.Entity {
+		case "neurons":
+			neuron := domain.Neuron{}
+			neuron.ParseSceneNode(node)
+			neuron.FilePath = n.servedPath(scenePath)
+			success, err = n.services.neurons.IngestNeuron(ctx, neuron, n.skipExisting, n.debug)
+			if success {
+				atomic.AddInt64(&n.neurons, 1)
 			}
-		}()
+		case "contacts":
+			contact := domain.Contact{}
+			contact.ParseSceneNode(node)
+			contact.FilePath = n.servedPath(scenePath)
+			success, err = n.services.contacts.IngestContact(ctx, contact, n.skipExisting, n.debug)
+			if success {
+				atomic.AddInt64(&n.contacts, 1)
+			}
+		case "synapses":
+			synapse := domain.Synapse{}
+			synapse.ParseSceneNode(node)
+			synapse.FilePath = n.servedPath(scenePath)
+			success, err = n.services.synapses.IngestSynapse(ctx, synapse, n.skipExisting, n.debug)
+			if success {
+				atomic.AddInt64(&n.synapses, 1)
+			}
+		}
+
+		if err != nil {
+			n.fail(ctx, node.Entity, scenePath, err, fmt.Sprintf("Error ingesting scene node %s", node.UID))
+		}
 	}
 
-	err = n.walkDirFolder(cntx, cmd.DirPath, channels, waitGroups)
+	n.recordAsset(ctx, "scene", n.fsys, scenePath, n.servedPath(scenePath))
+}
+
+func (n *Ingestor) ingestPromoters(ctx context.Context, promoterPath string) {
+	csvRows, err := toolshed.GetCSVRows(n.fsys, promoterPath)
 	if err != nil {
-		logger.Error().Err(err).Msg("Error processing entities")
+		n.fail(ctx, "promoters", promoterPath, err, "Error getting CSV rows")
+		return
 	}
 
-	waitGroups.neurons.Wait()
-	close(channels.neurons)
+	if len(csvRows) == 0 {
+		n.fail(ctx, "promoters", promoterPath, errors.New("file is empty"), "Error reading promoter header")
+		return
+	}
 
-	waitGroups.contacts.Wait()
-	close(channels.contacts)
+	header, err := domain.PromoterCSVSchema.ParseHeader(csvRows[0])
+	if err != nil {
+		n.failRow(ctx, "promoters", promoterPath, 1, err, "Error reading promoter header")
+		return
+	}
 
-	waitGroups.synapses.Wait()
-	close(channels.synapses)
+	if len(header.Missing) > 0 {
+		logging.FromContext(ctx).Warn().Str("path", promoterPath).Strs("columns", header.Missing).Msg("Promoter file is missing optional columns")
+	}
 
-	waitGroups.cphates.Wait()
-	close(channels.cphates)
+	for i, row := range csvRows[1:] {
+		line := i + 2
 
-	waitGroups.nerveRings.Wait()
-	close(channels.nerveRings)
+		record, err := header.Record(row)
+		if err != nil {
+			n.failRow(ctx, "promoters", promoterPath, line, err, "Invalid promoter row")
+			continue
+		}
 
-	waitGroups.scales.Wait()
-	close(channels.scales)
+		promoter := domain.Promoter{}
+		err = promoter.ParseCSV(record)
+		if err != nil {
+			n.failRow(ctx, "promoters", promoterPath, line, err, "Error parsing promoter")
+			continue
+		}
 
-	waitGroups.promoters.Wait()
-	close(channels.promoters)
+		n.enrichWormbase(ctx, &promoter)
 
-	waitGroups.devStages.Wait()
-	close(channels.devStages)
+		success, err := n.services.promoters.IngestPromoter(ctx, promoter, n.skipExisting, n.debug)
+		if err != nil {
+			n.failRow(ctx, "promoters", promoterPath, line, err, "Error ingesting promoter")
+			continue
+		}
 
-	waitGroups.meta.Wait()
-	close(channels.meta)
+		if success {
+			atomic.AddInt64(&n.promoters, 1)
+		}
+	}
+}
 
-	logger.Info().Msg("Done processing entities")
-	logger.Info().Int64("count", n.neurons).Msg("Neurons ingested")
-	logger.Info().Int64("count", n.contacts).Msg("Contacts ingested")
-	logger.Info().Int64("count", n.synapses).Msg("Synapses ingested")
-	logger.Info().Int64("count", n.cphates).Msg("Cphates ingested")
-	logger.Info().Int64("count", n.nerveRings).Msg("NerveRings ingested")
-	logger.Info().Int64("count", n.scales).Msg("Scales ingested")
-	logger.Info().Int64("count", n.promoters).Msg("Promoters ingested")
-	logger.Info().Int64("count", n.devStages).Msg("DevelopmentalStages ingested")
-	logger.Info().Int64("count", n.meta).Msg("Meta files ingested")
+func (n *Ingestor) ingestDevStages(ctx context.Context, devStagePath string) {
+	csvRows, err := toolshed.GetCSVRows(n.fsys, devStagePath)
+	if err != nil {
+		n.fail(ctx, "dev_stages", devStagePath, err, "Error getting CSV rows")
+		return
+	}
 
-	return nil
+	for i, row := range csvRows {
+		if i == 0 {
+			continue
+		}
+		line := i + 1
+
+		devStage := domain.DevelopmentalStage{}
+		err := devStage.ParseCSV(row)
+		if err != nil {
+			n.failRow(ctx, "dev_stages", devStagePath, line, err, "Error parsing devStage")
+			continue
+		}
+
+		// the timepoints of a stage are the dataset timepoints, they can't be checked by a foreign key
+		// as they are stored in an array
+		missing, err := n.services.timepoints.MissingTimepoints(ctx, devStage.Timepoints)
+		if err != nil {
+			n.failRow(ctx, "dev_stages", devStagePath, line, err, "Error checking devStage timepoints")
+			continue
+		}
+
+		if len(missing) > 0 {
+			n.failRow(ctx, "dev_stages", devStagePath, line, fmt.Errorf("timepoints %v don't exist", missing), "Developmental stage lists unknown timepoints")
+			continue
+		}
+
+		success, err := n.services.devStages.IngestDevelopmentalStage(ctx, devStage, n.skipExisting, n.debug)
+		if err != nil {
+			n.failRow(ctx, "dev_stages", devStagePath, line, err, "Error ingesting devStage")
+			continue
+		}
+
+		if success {
+			atomic.AddInt64(&n.devStages, 1)
+		}
+	}
 }
 
-func (n *Ingestor) walkDirFolder(ctx context.Context, path string, channels *ingestChannels, waitGroups *ingestWaitGroups) error {
+func (n *Ingestor) ingestMeta(ctx context.Context, metaPath string) {
+	csvRows, err := toolshed.GetCSVRows(n.fsys, metaPath)
+	if err != nil {
+		n.fail(ctx, "meta", metaPath, err, "Error getting CSV rows")
+		return
+	}
+
+	timepoint, err := n.layout.Timepoint(metaPath)
+	if err != nil {
+		n.fail(ctx, "meta", metaPath, err, "Error getting meta timepoint")
+		return
+	}
+
+	// we have 3 different files, cell_sa, cell_vol, and patch_sa. We need to parse them seperately based on the filename
+	filename := filepath.Base(metaPath)
+
+	for i, row := range csvRows {
+		if i == 0 {
+			continue
+		}
+
+		// if the row length is less than 2, continue
+		if len(row) < 2 {
+			n.failRow(ctx, "meta", metaPath, i+1, errors.New("expected at least 2 columns"), "Malformed meta data")
+			continue
+		}
+
+		if strings.Contains(filename, "cell_sa") {
+			err = n.services.neurons.ParseMeta(ctx, row, timepoint, "surface_area")
+		}
+
+		if strings.Contains(filename, "cell_vol") {
+			err = n.services.neurons.ParseMeta(ctx, row, timepoint, "volume")
+		}
+
+		if strings.Contains(filename, "patch_sa") {
+			err = n.services.contacts.ParseMeta(ctx, row, timepoint, "surface_area")
+		}
+
+		if err != nil {
+			n.failRow(ctx, "meta", metaPath, i+1, err, "Error parsing meta data")
+			continue
+		}
+	}
+
+	atomic.AddInt64(&n.meta, 1)
+}
+
+// reportMeshDiscrepancies compares the CSV cell stats with the stats measured from each neuron's mesh
+func (n *Ingestor) reportMeshDiscrepancies(ctx context.Context, threshold float64) {
 	logger := logging.FromContext(ctx)
 
-	logger.Info().Str("path", path).Msg("Walking directory")
-	return filepath.WalkDir(path, func(path string, d os.DirEntry, err error) error {
+	discrepancies, err := n.services.neurons.MeshDiscrepancies(ctx, threshold)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error comparing CSV and mesh stats")
+		return
+	}
+
+	for _, d := range discrepancies {
+		logger.Debug().Str("uid", d.UID).Int("timepoint", d.Timepoint).Str("stat", d.Stat).Float64("csv", d.CSV).Float64("mesh", d.Mesh).Msg("CSV and mesh stats disagree")
+	}
+
+	if len(discrepancies) > 0 {
+		logger.Warn().Int("count", len(discrepancies)).Float64("threshold", threshold).Msg("Neuron CSV stats disagree with their meshes")
+	}
+
+	n.report.Discrepancies = discrepancies
+}
+
+// metaQueue returns the queue a meta file belongs to based on the entity it annotates
+func metaQueue(path string) (string, bool) {
+	filename := filepath.Base(path)
+
+	switch {
+	case strings.Contains(filename, "cell_sa"), strings.Contains(filename, "cell_vol"):
+		return queueNeuronMeta, true
+	case strings.Contains(filename, "patch_sa"):
+		return queueContactMeta, true
+	}
+
+	return "", false
+}
+
+// fail logs an ingest failure and records it in the report
+func (n *Ingestor) fail(ctx context.Context, entity string, path string, err error, msg string) {
+	n.failRow(ctx, entity, path, 0, err, msg)
+}
+
+// failRow records a failure on a row of a CSV file
+func (n *Ingestor) failRow(ctx context.Context, entity string, path string, row int, err error, msg string) {
+	logger := logging.FromContext(ctx).Error().Err(err).Str("path", path)
+	if row > 0 {
+		logger = logger.Int("row", row)
+	}
+	logger.Msg(msg)
+
+	stage, _ := n.layout.DevStage(path)
+
+	n.report.AddError(IngestError{
+		Path:   path,
+		Entity: entity,
+		Stage:  stage,
+		Row:    row,
+		Cause:  msg + ": " + err.Error(),
+	})
+}
+
+// walkDirFolder walks the source once and indexes the paths to ingest by queue
+func (n *Ingestor) walkDirFolder(ctx context.Context) (map[string][]string, error) {
+	logger := logging.FromContext(ctx)
+	queues := map[string][]string{}
+
+	logger.Info().Msg("Walking source")
+	err := fs.WalkDir(n.fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			logger.Error().Err(err).Msg("Error walking directory")
 			return err
 		}
 
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// depending on the type of file, we want to process it differently
-		currentEntity, err := toolshed.GetEntityType(path)
+		info, err := n.layout.Resolve(path)
 		if err != nil {
-			logger.Error().Err(err).Str("path", path).Msg("Error getting entity type, skipping")
+			logger.Debug().Err(err).Str("path", path).Msg("Path does not match the layout, skipping")
+			return nil
 		}
 
-		// we want to skip directories
-		if d.IsDir() && currentEntity != "cphate" {
+		currentEntity := info.Entity
+
+		if !slices.Contains(n.processTypes, currentEntity) {
 			return nil
 		}
 
-		if d.IsDir() && currentEntity == "cphate" {
-			logger.Debug().Str("path", path).Msg("Adding cphate dir to channel")
-			waitGroups.cphates.Add(1)
-			channels.cphates <- path
-		}
+		// we want to skip directories, except the cphate folder itself which is parsed as a whole
+		if d.IsDir() {
+			if currentEntity == "cphate" && path == info.EntityDir {
+				logger.Debug().Str("path", path).Msg("Queueing cphate dir")
+				queues[currentEntity] = append(queues[currentEntity], path)
+				n.progress.Queued(currentEntity)
+			}
 
-		// if it's not a valid extension, skip it
-		if !toolshed.ValidExtension(path, []string{".gltf", ".csv"}) {
 			return nil
 		}
 
-		if !slices.Contains(n.processTypes, currentEntity) {
+		// if it's not a valid extension, skip it
+		if !toolshed.ValidExtension(path, []string{".gltf", ".glb", ".csv"}) {
 			return nil
 		}
 
-		// switch case to handle different entity types
+		queue := currentEntity
+
 		switch currentEntity {
-		case "neurons":
-			logger.Debug().Str("path", path).Msg("Adding neuron to channel")
-			waitGroups.neurons.Add(1)
-			channels.neurons <- path
-		case "contacts":
-			logger.Debug().Str("path", path).Msg("Adding contact to channel")
-			waitGroups.contacts.Add(1)
-			channels.contacts <- path
-		case "synapses":
-			logger.Debug().Str("path", path).Msg("Adding synapse to channel")
-			waitGroups.synapses.Add(1)
-			channels.synapses <- path
-		case "nerveRing":
-			logger.Debug().Str("path", path).Msg("Adding nerveRing to channel")
-			waitGroups.nerveRings.Add(1)
-			channels.nerveRings <- path
 		case "cphate":
-			logger.Debug().Str("path", path).Msg("Skipping cphate")
-		case "scale":
-			logger.Debug().Str("path", path).Msg("Adding scale to channel")
-			waitGroups.scales.Add(1)
-			channels.scales <- path
-		case "promoters":
-			logger.Debug().Str("path", path).Msg("Adding promoter to channel")
-			waitGroups.promoters.Add(1)
-			channels.promoters <- path
-		case "dev_stages":
-			logger.Debug().Str("path", path).Msg("Adding devStage to channel")
-			waitGroups.devStages.Add(1)
-			channels.devStages <- path
+			// cphate files are parsed along with their folder
+			return nil
 		case "meta":
-			logger.Debug().Str("path", path).Msg("Adding meta to channel")
-			waitGroups.meta.Add(1)
-			channels.meta <- path
-		default:
-			logger.Error().Str("type", currentEntity).Msg("Unknown entity type")
+			var ok bool
+			if queue, ok = metaQueue(path); !ok {
+				logger.Debug().Str("path", path).Msg("Unknown meta file, skipping")
+				return nil
+			}
 		}
 
-		logger.Debug().Str("path", path).Msg("Processing file")
+		logger.Debug().Str("path", path).Str("queue", queue).Msg("Queueing file")
+		queues[queue] = append(queues[queue], path)
+		n.progress.Queued(currentEntity)
 
 		return nil
 	})
+
+	return queues, err
 }
@@ -0,0 +1,118 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/toolshed"
+)
+
+func TestWalkDirFolderQueues(t *testing.T) {
+	t.Parallel()
+
+	n := &Ingestor{
+		fsys: fstest.MapFS{
+			"L1/0/neurons/ADAL.gltf":        {},
+			"L1/0/contacts/ADALbyAIYL.gltf": {},
+			"L1/0/cphate/cphate.gltf":       {},
+			"L1/0/meta/cell_sa.csv":         {},
+			"L1/0/meta/cell_vol.csv":        {},
+			"L1/0/meta/patch_sa.csv":        {},
+			"L1/0/neurons/notes.txt":        {},
+			"promoters/promoters.csv":       {},
+		},
+		layout:       toolshed.DefaultLayout(),
+		processTypes: toolshed.EntityTypes,
+		progress:     newIngestProgress(false),
+		report:       newIngestReport("test"),
+	}
+
+	queues, err := n.walkDirFolder(context.Background())
+	if err != nil {
+		t.Fatalf("Expected walk to succeed, got %v", err)
+	}
+
+	expected := map[string][]string{
+		"neurons":        {"L1/0/neurons/ADAL.gltf"},
+		"contacts":       {"L1/0/contacts/ADALbyAIYL.gltf"},
+		"cphate":         {"L1/0/cphate"},
+		"promoters":      {"promoters/promoters.csv"},
+		queueNeuronMeta:  {"L1/0/meta/cell_sa.csv", "L1/0/meta/cell_vol.csv"},
+		queueContactMeta: {"L1/0/meta/patch_sa.csv"},
+	}
+
+	if len(queues) != len(expected) {
+		t.Errorf("Expected %d queues, got %d: %v", len(expected), len(queues), queues)
+	}
+
+	for queue, paths := range expected {
+		if !slices.Equal(queues[queue], paths) {
+			t.Errorf("Expected %s queue to be %v, got %v", queue, paths, queues[queue])
+		}
+	}
+}
+
+func TestSourceTimepoints(t *testing.T) {
+	t.Parallel()
+
+	n := &Ingestor{layout: toolshed.DefaultLayout()}
+
+	timepoints := n.sourceTimepoints(map[string][]string{
+		"neurons":   {"L1/0/neurons/ADAL.gltf", "L1/0/neurons/ADAR.gltf"},
+		"cphate":    {"L2/36/cphate"},
+		"promoters": {"promoters/promoters.csv"},
+	})
+
+	slices.SortFunc(timepoints, func(a, b domain.Timepoint) int { return a.Timepoint - b.Timepoint })
+
+	expected := []domain.Timepoint{domain.NewTimepoint(0, "L1"), domain.NewTimepoint(36, "L2")}
+	if !slices.Equal(timepoints, expected) {
+		t.Errorf("Expected %v, got %v", expected, timepoints)
+	}
+}
+
+func TestGltfPrefix(t *testing.T) {
+	t.Parallel()
+
+	gltfDir := t.TempDir()
+	source := filepath.Join(gltfDir, "neuroscan", "release")
+	if err := os.MkdirAll(source, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		prefix   string
+		gltfDir  string
+		expected string
+	}{
+		{name: "source inside APP_GLTF_DIR", source: source, gltfDir: gltfDir, expected: "neuroscan/release"},
+		{name: "source is APP_GLTF_DIR", source: gltfDir, gltfDir: gltfDir, expected: ""},
+		{name: "source outside APP_GLTF_DIR", source: t.TempDir(), gltfDir: gltfDir, expected: ""},
+		{name: "archive", source: "release.tar.gz", prefix: "/neuroscan/", gltfDir: gltfDir, expected: "neuroscan"},
+		{name: "s3", source: "s3://bucket/release", prefix: "neuroscan/release", expected: "neuroscan/release"},
+		{name: "dot directory", source: "release.tar.gz", prefix: ".cache/gltf", gltfDir: gltfDir, expected: ".cache/gltf"},
+		{name: "root", source: "release.tar.gz", prefix: "./", gltfDir: gltfDir, expected: ""},
+	}
+
+	for _, tt := range tests {
+		prefix, err := gltfPrefix(tt.source, tt.prefix, tt.gltfDir)
+		if err != nil {
+			t.Errorf("%s: expected a prefix, got %v", tt.name, err)
+			continue
+		}
+		if prefix != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, prefix)
+		}
+	}
+
+	if _, err := gltfPrefix(source, "../outside", gltfDir); err == nil {
+		t.Error("Expected a prefix outside APP_GLTF_DIR to fail")
+	}
+}
@@ -0,0 +1,75 @@
+package ingest
+
+import (
+	"os"
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+// ingestProgress keeps a progress bar per entity type. Bars show a spinner
+// until the walk finishes and the total for each entity type is known.
+type ingestProgress struct {
+	mu      sync.Mutex
+	visible bool
+	bars    map[string]*progressbar.ProgressBar
+	queued  map[string]int64
+}
+
+// newIngestProgress creates the progress tracker, bars are only drawn when stderr is a terminal
+func newIngestProgress(enabled bool) *ingestProgress {
+	return &ingestProgress{
+		visible: enabled && term.IsTerminal(int(os.Stderr.Fd())),
+		bars:    map[string]*progressbar.ProgressBar{},
+		queued:  map[string]int64{},
+	}
+}
+
+// bar returns the bar for the entity type, creating it on first use. Callers must hold the lock.
+func (p *ingestProgress) bar(entity string) *progressbar.ProgressBar {
+	bar, ok := p.bars[entity]
+	if !ok {
+		bar = progressbar.NewOptions64(-1,
+			progressbar.OptionSetDescription(entity),
+			progressbar.OptionSetWriter(os.Stderr),
+			progressbar.OptionSetVisibility(p.visible),
+			progressbar.OptionShowCount(),
+			progressbar.OptionSetWidth(30),
+			progressbar.OptionOnCompletion(func() {
+				os.Stderr.WriteString("\n")
+			}),
+		)
+		p.bars[entity] = bar
+	}
+
+	return bar
+}
+
+// Queued records a file of the entity type being queued for processing
+func (p *ingestProgress) Queued(entity string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.bar(entity)
+	p.queued[entity]++
+}
+
+// Processed advances the bar of the entity type, whether the file succeeded or not
+func (p *ingestProgress) Processed(entity string) {
+	p.mu.Lock()
+	bar := p.bar(entity)
+	p.mu.Unlock()
+
+	bar.Add(1)
+}
+
+// WalkDone sets the total of every bar now that all files have been queued
+func (p *ingestProgress) WalkDone() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for entity, bar := range p.bars {
+		bar.ChangeMax64(p.queued[entity])
+	}
+}
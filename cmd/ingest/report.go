@@ -0,0 +1,97 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"neuroscan/internal/domain"
+)
+
+// IngestError is a single file or row that failed to ingest.
+type IngestError struct {
+	Path   string `json:"path"`
+	Entity string `json:"entity"`
+	Stage  string `json:"stage,omitempty"`
+	// Row is the line of a CSV file that failed, counting the header as 1
+	Row   int    `json:"row,omitempty"`
+	Cause string `json:"cause"`
+}
+
+// WormbaseFlag is a promoter whose WormBase ID is unknown or belongs to a dead gene
+type WormbaseFlag struct {
+	Promoter string `json:"promoter"`
+	Wormbase string `json:"wormbase"`
+	Status   string `json:"status"`
+}
+
+// IngestReport is the machine readable summary of an ingest run, written with --report.
+type IngestReport struct {
+	Source     string           `json:"source"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Ingested   map[string]int64 `json:"ingested"`
+	ErrorCount int              `json:"error_count"`
+	Errors     []IngestError    `json:"errors"`
+	// Discrepancies are neurons whose CSV volume or surface area disagrees with their mesh
+	Discrepancies []domain.MeshDiscrepancy `json:"mesh_discrepancies"`
+	// WormbaseFlags are promoters whose WormBase ID isn't a live gene of the --wormbase file
+	WormbaseFlags []WormbaseFlag `json:"wormbase_flags"`
+
+	mu sync.Mutex
+}
+
+func newIngestReport(source string) *IngestReport {
+	return &IngestReport{
+		Source:        source,
+		StartedAt:     time.Now(),
+		Ingested:      map[string]int64{},
+		Errors:        []IngestError{},
+		Discrepancies: []domain.MeshDiscrepancy{},
+		WormbaseFlags: []WormbaseFlag{},
+	}
+}
+
+// AddError records a failure, it is safe to call from multiple workers
+func (r *IngestReport) AddError(ingestErr IngestError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Errors = append(r.Errors, ingestErr)
+	r.ErrorCount = len(r.Errors)
+}
+
+// AddWormbaseFlag records a promoter with an unknown WormBase ID, it is safe to call from multiple workers
+func (r *IngestReport) AddWormbaseFlag(flag WormbaseFlag) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.WormbaseFlags = append(r.WormbaseFlags, flag)
+}
+
+// ErrorTotal returns the number of failures recorded so far
+func (r *IngestReport) ErrorTotal() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.Errors)
+}
+
+// Write saves the report as JSON to path
+func (r *IngestReport) Write(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode ingest report: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("unable to write ingest report: %w", err)
+	}
+
+	return nil
+}
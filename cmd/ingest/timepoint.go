@@ -0,0 +1,90 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/toolshed"
+)
+
+// sourceTimepoints returns the timepoints the queued paths belong to, with the stage of their folder
+func (n *Ingestor) sourceTimepoints(queues map[string][]string) []domain.Timepoint {
+	seen := map[int]bool{}
+	timepoints := []domain.Timepoint{}
+
+	for _, paths := range queues {
+		for _, path := range paths {
+			info, err := n.layout.Resolve(path)
+			if err != nil || info.Timepoint == nil || seen[*info.Timepoint] {
+				continue
+			}
+
+			seen[*info.Timepoint] = true
+			timepoints = append(timepoints, domain.NewTimepoint(*info.Timepoint, info.Stage))
+		}
+	}
+
+	return timepoints
+}
+
+// saveTimepoints creates the timepoints of the source, a failure is reported and the entities
+// of a missing timepoint then fail on their own
+func (n *Ingestor) saveTimepoints(ctx context.Context, queues map[string][]string) {
+	timepoints := n.sourceTimepoints(queues)
+	if len(timepoints) == 0 {
+		return
+	}
+
+	if err := n.services.timepoints.SaveTimepoints(ctx, timepoints); err != nil {
+		n.fail(ctx, "timepoints", "", err, "Error saving timepoints")
+	}
+}
+
+// ingestTimepoints reads the hours post-hatch, dataset source and specimen of each timepoint
+func (n *Ingestor) ingestTimepoints(ctx context.Context, timepointPath string) {
+	csvRows, err := toolshed.GetCSVRows(n.fsys, timepointPath)
+	if err != nil {
+		n.fail(ctx, "timepoints", timepointPath, err, "Error getting CSV rows")
+		return
+	}
+
+	if len(csvRows) == 0 {
+		n.fail(ctx, "timepoints", timepointPath, errors.New("file is empty"), "Error reading timepoint header")
+		return
+	}
+
+	header, err := domain.TimepointCSVSchema.ParseHeader(csvRows[0])
+	if err != nil {
+		n.failRow(ctx, "timepoints", timepointPath, 1, err, "Error reading timepoint header")
+		return
+	}
+
+	for i, row := range csvRows[1:] {
+		line := i + 2
+
+		record, err := header.Record(row)
+		if err != nil {
+			n.failRow(ctx, "timepoints", timepointPath, line, err, "Invalid timepoint row")
+			continue
+		}
+
+		timepoint := domain.Timepoint{}
+		err = timepoint.ParseCSV(record)
+		if err != nil {
+			n.failRow(ctx, "timepoints", timepointPath, line, err, "Error parsing timepoint")
+			continue
+		}
+
+		success, err := n.services.timepoints.IngestTimepoint(ctx, timepoint, n.skipExisting, n.debug)
+		if err != nil {
+			n.failRow(ctx, "timepoints", timepointPath, line, err, "Error ingesting timepoint")
+			continue
+		}
+
+		if success {
+			atomic.AddInt64(&n.timepoints, 1)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/toolshed"
+	"neuroscan/pkg/logging"
+)
+
+// loadWormbase reads the WormBase reference file given with --wormbase. Rows that don't fit
+// the schema are reported and skipped, it returns nil when the file can't be read at all.
+func (n *Ingestor) loadWormbase(ctx context.Context, path string) *domain.WormbaseGenes {
+	rows, err := toolshed.GetTSVRows(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+	if err != nil {
+		n.fail(ctx, "wormbase", path, err, "Error reading WormBase file")
+		return nil
+	}
+
+	if len(rows) == 0 {
+		n.fail(ctx, "wormbase", path, errors.New("file is empty"), "Error reading WormBase header")
+		return nil
+	}
+
+	header, err := domain.WormbaseTSVSchema.ParseHeader(rows[0])
+	if err != nil {
+		n.failRow(ctx, "wormbase", path, 1, err, "Error reading WormBase header")
+		return nil
+	}
+
+	genes := domain.NewWormbaseGenes()
+	for i, row := range rows[1:] {
+		record, err := header.Record(row)
+		if err != nil {
+			n.failRow(ctx, "wormbase", path, i+2, err, "Invalid WormBase row")
+			continue
+		}
+		genes.Add(record)
+	}
+
+	logging.FromContext(ctx).Info().Int("genes", genes.Len()).Str("path", path).Msg("Loaded WormBase genes")
+
+	return genes
+}
+
+// enrichWormbase fills the promoter's gene from the reference file, IDs that aren't in it or
+// belong to a dead gene are flagged in the report
+func (n *Ingestor) enrichWormbase(ctx context.Context, promoter *domain.Promoter) {
+	if n.wormbase == nil {
+		return
+	}
+
+	wormbase := promoter.Wormbase
+	promoter.EnrichWormbase(n.wormbase)
+
+	if promoter.WormbaseStatus == domain.WormbaseUnknown || promoter.WormbaseStatus == domain.WormbaseDead {
+		logging.FromContext(ctx).Warn().Str("promoter", promoter.UID).Str("wormbase", wormbase).Str("status", promoter.WormbaseStatus).Msg("Promoter WormBase ID is not a live gene")
+		n.report.AddWormbaseFlag(WormbaseFlag{
+			Promoter: promoter.UID,
+			Wormbase: wormbase,
+			Status:   promoter.WormbaseStatus,
+		})
+	}
+}
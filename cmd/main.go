@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"neuroscan/cmd/cleanup"
+	"neuroscan/cmd/cluster"
 	"neuroscan/cmd/ingest"
 	"neuroscan/cmd/transcode"
 	"neuroscan/cmd/web"
@@ -18,6 +19,7 @@ type Cli struct {
 	Ingest    ingest.IngestCmd       `cmd:"" help:"Ingest files into the database."`
 	Transcode transcode.TranscodeCmd `cmd:"" help:"Listen for videos and transcode."`
 	Cleanup   cleanup.CleanupCmd     `cmd:"" help:"Clean up old videos from storage and database."`
+	Cluster   cluster.ClusterCmd     `cmd:"" help:"Cluster the neurons of a timepoint by their contacts into a CPHATE structure."`
 }
 
 func main() {
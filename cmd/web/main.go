@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"neuroscan/internal/cache"
@@ -96,6 +98,10 @@ func (cmd *WebCmd) Run(ctx *context.Context) error {
 
 		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 			Level: 5,
+			// files are served precompressed, compressing them again would also break Range requests
+			Skipper: func(c echo.Context) bool {
+				return strings.HasPrefix(c.Request().URL.Path, "/files/")
+			},
 		}))
 
 		// e.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(rate.Limit(100))))
@@ -140,7 +146,6 @@ func (cmd *WebCmd) Run(ctx *context.Context) error {
 		Timeout: 30 * time.Second,
 	}))
 
-	e.Static("/files", os.Getenv("APP_GLTF_DIR"))
 	e.Static("/", os.Getenv("APP_FRONTEND_DIR"))
 
 	neuronRepo := repository.NewPostgresNeuronRepository(db.Pool, cache)
@@ -179,7 +184,40 @@ func (cmd *WebCmd) Run(ctx *context.Context) error {
 	videoService := service.NewVideoService(videoRepo, *store, bucket)
 	videoHandler := handler.NewVideoHandler(videoService)
 
-	e = router.NewRouter(e, neuronHandler, contactHandler, synapseHandler, cphateHandler, nerveringHandler, scaleHandler, promoterHandler, devStageHandler, videoHandler)
+	sceneCacheDir := os.Getenv("APP_SCENE_CACHE_DIR")
+	if sceneCacheDir == "" {
+		sceneCacheDir = filepath.Join(os.TempDir(), "neuroscan-scenes")
+	}
+
+	sceneCacheSize := service.DefaultSceneCacheSize
+	if size, err := strconv.ParseInt(os.Getenv("APP_SCENE_CACHE_SIZE_MB"), 10, 64); err == nil && size > 0 {
+		sceneCacheSize = size << 20
+	}
+
+	sceneService := service.NewSceneService(neuronRepo, contactRepo, synapseRepo, os.Getenv("APP_GLTF_DIR"), sceneCacheDir, sceneCacheSize)
+	sceneHandler := handler.NewSceneHandler(sceneService)
+
+	meshService := service.NewMeshService(neuronRepo, contactRepo, synapseRepo, scaleRepo, os.Getenv("APP_GLTF_DIR"))
+	meshHandler := handler.NewMeshHandler(meshService)
+
+	spatialRepo := repository.NewPostgresSpatialRepository(db.Pool, cache)
+	spatialService := service.NewSpatialService(spatialRepo)
+	spatialHandler := handler.NewSpatialHandler(spatialService)
+
+	assetRepo := repository.NewPostgresAssetRepository(db.Pool, cache)
+	assetService := service.NewAssetService(assetRepo)
+	precompressDir := os.Getenv("APP_PRECOMPRESS_DIR")
+	if precompressDir == "" {
+		precompressDir = os.Getenv("APP_GLTF_DIR")
+	}
+
+	assetHandler := handler.NewAssetHandler(assetService, os.Getenv("APP_GLTF_DIR"), precompressDir)
+
+	timepointRepo := repository.NewPostgresTimepointRepository(db.Pool, cache)
+	timepointService := service.NewTimepointService(timepointRepo, devStageRepo)
+	timepointHandler := handler.NewTimepointHandler(timepointService)
+
+	e = router.NewRouter(e, neuronHandler, contactHandler, synapseHandler, cphateHandler, nerveringHandler, scaleHandler, promoterHandler, devStageHandler, videoHandler, sceneHandler, meshHandler, spatialHandler, assetHandler, timepointHandler)
 
 	e.Logger.Fatal(e.Start(fmt.Sprintf(":%s", port)))
 
@@ -12,4 +12,10 @@ type APIV1Request struct {
 	Offset     int      `query:"start"`
 	PostNeuron string   `query:"post_neuron"`
 	PreNeuron  string   `query:"pre_neuron"`
+	// Cells, Wormbase and the timepoint range filter promoters, a promoter matches a range
+	// when its own range overlaps it
+	Cells         []string `query:"cell"`
+	Wormbase      []string `query:"wormbase"`
+	TimepointFrom *int     `query:"timepoint_from"`
+	TimepointTo   *int     `query:"timepoint_to"`
 }
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"net/url"
+	"slices"
+)
+
+// Asset is a file served from /files, recorded on ingest so it can be served with a strong
+// ETag and its precompressed variants.
+type Asset struct {
+	// Path is relative to APP_GLTF_DIR with forward slashes, e.g. L1/23/neurons/ADAL.gltf
+	Path string `json:"path"`
+	// Hash is the SHA256 of the uncompressed file
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+	// Encodings lists the precompressed variants written to the precompress directory, "br" and "gzip"
+	Encodings []string `json:"encodings"`
+}
+
+// HasEncoding reports whether a variant was written for the content encoding.
+func (a Asset) HasEncoding(encoding string) bool {
+	return slices.Contains(a.Encodings, encoding)
+}
+
+// AssetURL returns the /files URL of a path under APP_GLTF_DIR, versioned with the file's hash
+// so it can be cached for good. It is empty for entities ingested before paths were recorded.
+func AssetURL(filePath string, hash string) string {
+	if filePath == "" {
+		return ""
+	}
+
+	u := url.URL{Path: "/files/" + filePath}
+	if hash != "" {
+		u.RawQuery = url.Values{"v": {hash}}.Encode()
+	}
+
+	return u.String()
+}
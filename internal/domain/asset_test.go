@@ -0,0 +1,19 @@
+package domain
+
+import "testing"
+
+func TestAssetURL(t *testing.T) {
+	t.Parallel()
+
+	if got := AssetURL("L1/23/neurons/ADAL.gltf", "abc"); got != "/files/L1/23/neurons/ADAL.gltf?v=abc" {
+		t.Errorf("Expected a versioned URL, got %s", got)
+	}
+
+	if got := AssetURL("L1/23/neurons/AD AL.gltf", ""); got != "/files/L1/23/neurons/AD%20AL.gltf" {
+		t.Errorf("Expected an escaped URL without a version, got %s", got)
+	}
+
+	if got := AssetURL("", "abc"); got != "" {
+		t.Errorf("Expected no URL without a path, got %s", got)
+	}
+}
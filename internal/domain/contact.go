@@ -2,6 +2,8 @@ package domain
 
 import (
 	"errors"
+	"io/fs"
+	"strings"
 
 	"neuroscan/internal/toolshed"
 )
@@ -18,6 +20,17 @@ type Contact struct {
 	CellStats  *CellStats     `json:"cell_stats"`
 	PatchStats *PatchStats    `json:"patch_stats"`
 	Ranking    *Ranking       `json:"ranking"`
+	// SceneSource is set when the contact was split out of a combined scene file
+	SceneSource *toolshed.SceneSource `json:"scene_source,omitempty"`
+	// FilePath is the file's path under APP_GLTF_DIR, recorded on ingest so it is never searched for
+	FilePath string `json:"-"`
+	// FileHash is the SHA256 of the file, FileURL serves it from /files versioned by the hash
+	FileHash string `json:"file_hash"`
+	FileURL  string `json:"file_url"`
+	// FilenameLOD holds the simplified versions of the file, keyed by the percentage of triangles kept
+	FilenameLOD map[string]string `json:"filename_lod"`
+	// Bounds is measured from the mesh on ingest, it is used by the spatial queries
+	Bounds *Bounds `json:"bounds"`
 }
 
 type PatchStats struct {
@@ -26,8 +39,8 @@ type PatchStats struct {
 	TotalCellPatchSurfaceArea *float64 `json:"total_cell_patch_surface_area"`
 }
 
-func (c *Contact) Parse(filePath string) error {
-	fileMetas, err := toolshed.FilePathParse(filePath)
+func (c *Contact) Parse(fsys fs.FS, layout *toolshed.Layout, filePath string) error {
+	fileMetas, err := toolshed.FilePathParse(fsys, layout, filePath)
 	if err != nil {
 		return errors.New("error parsing contact file path: " + err.Error())
 	}
@@ -38,12 +51,26 @@ func (c *Contact) Parse(filePath string) error {
 	c.UID = fileMeta.UID
 	c.ULID = ulid
 	c.Filename = fileMeta.Filename
+	c.FileHash = fileMeta.Filehash
 	c.Timepoint = fileMeta.Timepoint
 	c.Color = fileMeta.Color
+	c.Bounds = NewBounds(fileMetas...)
 
 	return nil
 }
 
+// ParseSceneNode fills the contact from a node of a combined scene file
+func (c *Contact) ParseSceneNode(node toolshed.SceneNode) {
+	c.UID = node.UID
+	c.ULID = toolshed.CreateULID(ContactULIDPrefix)
+	c.Filename = node.Filename
+	c.FileHash = node.Filehash
+	c.Timepoint = node.Timepoint
+	c.Color = node.Color
+	c.SceneSource = &node.Source
+	c.Bounds = NewBounds(node.NeuroscanFilepathData)
+}
+
 func (c *Contact) Validate() error {
 	if c.ID == 0 {
 		return errors.New("id is invalid")
@@ -59,3 +86,25 @@ func (c *Contact) Validate() error {
 
 	return nil
 }
+
+// ContactArea is the surface area of one contact of a timepoint
+type ContactArea struct {
+	UID         string  `json:"uid"`
+	SurfaceArea float64 `json:"surface_area"`
+}
+
+// Cells returns the two cells of the contact from its uid, e.g. ADALbyAIYL_3 is between ADAL
+// and AIYL. The patch suffix after the partner is dropped.
+func (c ContactArea) Cells() (string, string, bool) {
+	cell, partner, ok := strings.Cut(c.UID, "by")
+	if !ok {
+		return "", "", false
+	}
+
+	partner, _, _ = strings.Cut(partner, "_")
+	if cell == "" || partner == "" {
+		return "", "", false
+	}
+
+	return cell, partner, true
+}
@@ -1,13 +1,18 @@
 package domain
 
 import (
+	"cmp"
 	"errors"
-	"os"
-	"path/filepath"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 
 	"neuroscan/internal/toolshed"
+	"neuroscan/pkg/cluster"
 )
 
 const (
@@ -15,28 +20,24 @@ const (
 	CphateMetaItemULIDPrefix = "cph_mi"
 )
 
-type CphateNode struct {
-	id             int
-	ulid           string
-	uid            string
-	cphateId       int
-	cluster        int
-	clusterCount   int
-	iteration      int
-	iterationCount int
-	serial         int
-	neurons        []string
-}
-
 type CphateMeta []CphateMetaItem
 
+// CphateMetaItem is a cluster at one iteration of the CPHATE hierarchy, parsed from a node
+// name such as ADAL_ADAR-i3/12-c2/5-s01.
 type CphateMetaItem struct {
-	I       int            `json:"i"`
-	C       int            `json:"c"`
-	Neurons []string       `json:"neurons"`
-	ObjFile string         `json:"objFile"`
-	Color   toolshed.Color `json:"color"`
-	ULID    string         `json:"ulid"`
+	// I is the iteration and C the cluster within it
+	I int `json:"i"`
+	C int `json:"c"`
+	// S is the serial of the node across the whole hierarchy
+	S              int            `json:"s"`
+	IterationCount int            `json:"iterationCount"`
+	ClusterCount   int            `json:"clusterCount"`
+	Neurons        []string       `json:"neurons"`
+	ObjFile        string         `json:"objFile"`
+	Color          toolshed.Color `json:"color"`
+	ULID           string         `json:"ulid"`
+	// Parent is the ULID of the cluster at the next iteration this one merges into
+	Parent string `json:"parent,omitempty"`
 }
 
 type Cphate struct {
@@ -47,8 +48,8 @@ type Cphate struct {
 	Structure CphateMeta `json:"structure"`
 }
 
-func (c *Cphate) Parse(dirPath string) error {
-	timepoint, err := toolshed.GetTimepoint(dirPath)
+func (c *Cphate) Parse(fsys fs.FS, layout *toolshed.Layout, dirPath string) error {
+	timepoint, err := layout.Timepoint(dirPath)
 	if err != nil {
 		return errors.New("error getting timepoint: " + err.Error())
 	}
@@ -63,7 +64,7 @@ func (c *Cphate) Parse(dirPath string) error {
 
 	var cphateMetaItems []CphateMetaItem
 
-	err = filepath.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
+	err = fs.WalkDir(fsys, dirPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return errors.New("error walking directory: " + err.Error())
 		}
@@ -76,22 +77,24 @@ func (c *Cphate) Parse(dirPath string) error {
 			return nil
 		}
 
-		fileMetas, err := toolshed.FilePathParse(path)
+		fileMetas, err := toolshed.FilePathParse(fsys, layout, path)
 		if err != nil {
 			return errors.New("error parsing file path: " + err.Error())
 		}
 
 		// loop over the fileMetas and create a CPHATE node for each
 		for _, fileMeta := range fileMetas {
-			// cphateNode, err := parseCphateNode(n, fileMeta.uid)
-			cphateMetaItem, err := buildCphateMetaItem(fileMeta.UID, fileMeta.Filename, fileMeta.Color)
+			cphateMetaItem, err := buildCphateMetaItem(timepoint, fileMeta.UID, fileMeta.Filename, fileMeta.Color)
 			if err != nil {
 				continue
 			}
 
-			cphateMetaItems = append(cphateMetaItems, cphateMetaItem)
+			// a cluster split over several files is kept once
+			if slices.ContainsFunc(cphateMetaItems, func(item CphateMetaItem) bool { return item.ULID == cphateMetaItem.ULID }) {
+				continue
+			}
 
-			// cphateNodes = append(cphateNodes, cphateNode)
+			cphateMetaItems = append(cphateMetaItems, cphateMetaItem)
 		}
 
 		return nil
@@ -101,11 +104,64 @@ func (c *Cphate) Parse(dirPath string) error {
 	}
 
 	c.Structure = cphateMetaItems
+	c.Structure.Link()
 
 	return nil
 }
 
-func buildCphateMetaItem(node string, filename string, color toolshed.Color) (CphateMetaItem, error) {
+// NewClusteredCphate builds the CPHATE structure of a timepoint from the levels of an
+// agglomerative clustering, the first level being the first iteration. Clusters computed this
+// way have no mesh, so their obj files are left empty.
+func NewClusteredCphate(timepoint int, levels []cluster.Level) Cphate {
+	c := Cphate{
+		ULID:      toolshed.CreateULID(CphateULIDPrefix),
+		UID:       "CPHATE " + strconv.Itoa(timepoint),
+		Timepoint: timepoint,
+		Structure: CphateMeta{},
+	}
+
+	serial := 0
+	for i, level := range levels {
+		for j, neurons := range level {
+			serial++
+			c.Structure = append(c.Structure, CphateMetaItem{
+				I:              i + 1,
+				C:              j + 1,
+				S:              serial,
+				IterationCount: len(levels),
+				ClusterCount:   len(level),
+				Neurons:        slices.Clone(neurons),
+				Color:          toolshed.DefaultColor,
+				ULID:           CphateNodeULID(timepoint, i+1, j+1),
+			})
+		}
+	}
+
+	c.Structure.Link()
+
+	return c
+}
+
+func buildCphateMetaItem(timepoint int, node string, filename string, color toolshed.Color) (CphateMetaItem, error) {
+	cphateMetaItem, err := parseCphateNode(node)
+	if err != nil {
+		return CphateMetaItem{}, err
+	}
+
+	cphateMetaItem.ObjFile = filename
+	cphateMetaItem.Color = color
+	cphateMetaItem.ULID = CphateNodeULID(timepoint, cphateMetaItem.I, cphateMetaItem.C)
+
+	return cphateMetaItem, nil
+}
+
+// CphateNodeULID is the id of a cluster, derived from where it sits so that it stays the same
+// across ingests, e.g. cph_mi_23_3_2
+func CphateNodeULID(timepoint int, iteration int, cluster int) string {
+	return fmt.Sprintf("%s_%d_%d_%d", CphateMetaItemULIDPrefix, timepoint, iteration, cluster)
+}
+
+func parseCphateNode(node string) (CphateMetaItem, error) {
 	// split the node string by the "-" character
 	// the first part is the neuron names
 	// the second part is the cluster, iteration, and serial
@@ -124,94 +180,377 @@ func buildCphateMetaItem(node string, filename string, color toolshed.Color) (Cp
 	// this will give us the cluster, iteration, and serial
 	clusterParts := strings.Split(parts[1], "-")
 
-	// loop over the cluster parts
-	// check if they contain i, c, or s, as it determines if it is an iteration, cluster, or serial
-
-	var cluster, iteration int
+	// each part starts with i, c, or s, as it determines if it is an iteration, cluster, or serial
+	// iterations and clusters may be followed by their count, e.g. i3/12
+	item := CphateMetaItem{Neurons: neurons}
 
 	for _, part := range clusterParts {
-		if strings.Contains(part, "i") {
-			part = strings.Replace(part, "i", "", 1)
-			iterationParts := strings.Split(part, "/")
-			iteration, _ = strconv.Atoi(iterationParts[0])
-		} else if strings.Contains(part, "c") {
-			part = strings.Replace(part, "c", "", 1)
-			clusterParts := strings.Split(part, "/")
-			cluster, _ = strconv.Atoi(clusterParts[0])
+		switch {
+		case strings.HasPrefix(part, "i"):
+			item.I, item.IterationCount = parseCphateCount(part[1:])
+		case strings.HasPrefix(part, "c"):
+			item.C, item.ClusterCount = parseCphateCount(part[1:])
+		case strings.HasPrefix(part, "s"):
+			// serials are written as s01 or s_01
+			item.S, _ = strconv.Atoi(strings.TrimLeft(part[1:], "_#"))
 		}
 	}
 
-	ulid := toolshed.CreateULID(CphateMetaItemULIDPrefix)
+	return item, nil
+}
+
+// parseCphateCount parses a value optionally followed by its total, e.g. 3/12
+func parseCphateCount(part string) (int, int) {
+	value, total, _ := strings.Cut(part, "/")
+
+	v, _ := strconv.Atoi(value)
+	t, _ := strconv.Atoi(total)
+
+	return v, t
+}
+
+// Link sets the parent of every cluster to the cluster at the next iteration that contains
+// all of its neurons. Clusters only merge as the iterations go on, so the last iteration
+// holds the roots. A cluster no later cluster contains is left as a root.
+func (m CphateMeta) Link() {
+	iterations := m.Iterations()
 
-	cphateMetaItem := CphateMetaItem{
-		I:       iteration,
-		C:       cluster,
-		Neurons: neurons,
-		ObjFile: filename,
-		Color:   color,
-		ULID:    ulid,
+	byIteration := make(map[int][]int, len(iterations))
+	for i, item := range m {
+		byIteration[item.I] = append(byIteration[item.I], i)
 	}
 
-	return cphateMetaItem, nil
+	for i := range m {
+		m[i].Parent = ""
+
+		next, _ := slices.BinarySearch(iterations, m[i].I+1)
+		if next == len(iterations) {
+			continue
+		}
+
+		for _, candidate := range byIteration[iterations[next]] {
+			if containsAll(m[candidate].Neurons, m[i].Neurons) {
+				m[i].Parent = m[candidate].ULID
+				break
+			}
+		}
+	}
 }
 
-func parseCphateNode(node string) (CphateNode, error) {
-	// split the node string by the "-" character
-	// the first part is the neuron names
-	// the second part is the cluster, iteration, and serial
-	// if we don't have a "-" character, then return an error
-	parts := strings.SplitN(node, "-", 2)
+// Iterations returns the iterations present in the hierarchy in ascending order
+func (m CphateMeta) Iterations() []int {
+	var iterations []int
+	for _, item := range m {
+		if !slices.Contains(iterations, item.I) {
+			iterations = append(iterations, item.I)
+		}
+	}
+	slices.Sort(iterations)
 
-	if len(parts) != 2 {
-		return CphateNode{}, errors.New("invalid cphate node")
+	return iterations
+}
+
+// CphateTreeNode is a cluster with the clusters of the previous iteration that merged into it
+type CphateTreeNode struct {
+	ULID      string            `json:"id"`
+	Iteration int               `json:"iteration"`
+	Cluster   int               `json:"cluster"`
+	Serial    int               `json:"serial"`
+	Neurons   []string          `json:"neurons"`
+	ObjFile   string            `json:"objFile"`
+	Color     toolshed.Color    `json:"color"`
+	Children  []*CphateTreeNode `json:"children"`
+}
+
+// CphateTree is the CPHATE hierarchy of a timepoint, rooted at the clusters of the last iteration
+type CphateTree struct {
+	Timepoint      int               `json:"timepoint"`
+	IterationCount int               `json:"iterationCount"`
+	Roots          []*CphateTreeNode `json:"roots"`
+}
+
+// Tree nests the clusters by their parent links. Structures ingested before the links were
+// stored are linked first.
+func (c Cphate) Tree() CphateTree {
+	items := slices.Clone(c.Structure)
+	if !slices.ContainsFunc(items, func(item CphateMetaItem) bool { return item.Parent != "" }) {
+		items.Link()
 	}
 
-	// split the first part by the "_" character
-	// this will give us the neuron names
-	neurons := strings.Split(parts[0], "_")
+	// children are listed by cluster within each iteration
+	slices.SortFunc(items, func(a, b CphateMetaItem) int {
+		return cmp.Or(cmp.Compare(a.I, b.I), cmp.Compare(a.C, b.C))
+	})
 
-	// split the second part by the "-" character
-	// this will give us the cluster, iteration, and serial
-	clusterParts := strings.Split(parts[1], "-")
+	nodes := make(map[string]*CphateTreeNode, len(items))
+	for _, item := range items {
+		nodes[item.ULID] = &CphateTreeNode{
+			ULID:      item.ULID,
+			Iteration: item.I,
+			Cluster:   item.C,
+			Serial:    item.S,
+			Neurons:   item.Neurons,
+			ObjFile:   item.ObjFile,
+			Color:     item.Color,
+			Children:  []*CphateTreeNode{},
+		}
+	}
+
+	tree := CphateTree{Timepoint: c.Timepoint, IterationCount: len(items.Iterations()), Roots: []*CphateTreeNode{}}
+	for _, item := range items {
+		if parent, ok := nodes[item.Parent]; ok {
+			parent.Children = append(parent.Children, nodes[item.ULID])
+		} else {
+			tree.Roots = append(tree.Roots, nodes[item.ULID])
+		}
+	}
 
-	// loop over the cluster parts
-	// check if they contain i, c, or s, as it determines if it is an iteration, cluster, or serial
+	return tree
+}
 
-	var cluster, clusterCount, iteration, iterationCount, serial int
+// CphateMembership is the cluster a neuron belongs to at one iteration of the hierarchy
+type CphateMembership struct {
+	Iteration int    `json:"iteration"`
+	Cluster   int    `json:"cluster"`
+	ClusterID string `json:"clusterId"`
+	// Neurons are all the members of the cluster, the neuron included
+	Neurons []string `json:"neurons"`
+	// Merged are the neurons that joined the neuron's cluster at this iteration
+	Merged []string `json:"merged"`
+}
 
-	for _, part := range clusterParts {
-		if strings.Contains(part, "i") {
-			part = strings.Replace(part, "i", "", 1)
-			iterationParts := strings.Split(part, "/")
-			iteration, _ = strconv.Atoi(iterationParts[0])
+// NeuronCphate follows a neuron through the iterations of its timepoint's hierarchy
+type NeuronCphate struct {
+	UID        string             `json:"uid"`
+	Timepoint  int                `json:"timepoint"`
+	Iterations []CphateMembership `json:"iterations"`
+}
+
+// Membership returns the cluster of the neuron at every iteration it appears in, in ascending
+// order. At the first one every other member counts as merged.
+func (c Cphate) Membership(uid string) NeuronCphate {
+	membership := NeuronCphate{UID: uid, Timepoint: c.Timepoint, Iterations: []CphateMembership{}}
 
-			if len(iterationParts) > 1 {
-				iterationCount, _ = strconv.Atoi(iterationParts[1])
+	var previous []string
+	for _, iteration := range c.Structure.Iterations() {
+		for _, item := range c.Structure {
+			if item.I != iteration || !slices.Contains(item.Neurons, uid) {
+				continue
 			}
-		} else if strings.Contains(part, "c") {
-			part = strings.Replace(part, "c", "", 1)
-			clusterParts := strings.Split(part, "/")
-			cluster, _ = strconv.Atoi(clusterParts[0])
 
-			if len(clusterParts) > 1 {
-				clusterCount, _ = strconv.Atoi(clusterParts[1])
+			merged := []string{}
+			for _, neuron := range item.Neurons {
+				if neuron != uid && !slices.Contains(previous, neuron) {
+					merged = append(merged, neuron)
+				}
 			}
-		} else if strings.Contains(part, "s") {
-			part = strings.Replace(part, "s", "", 1)
-			serial, _ = strconv.Atoi(part[1:])
+
+			membership.Iterations = append(membership.Iterations, CphateMembership{
+				Iteration: item.I,
+				Cluster:   item.C,
+				ClusterID: item.ULID,
+				Neurons:   item.Neurons,
+				Merged:    merged,
+			})
+			previous = item.Neurons
+			break
+		}
+	}
+
+	return membership
+}
+
+// CphateCompareRequest holds the query parameters of /cphates/compare
+type CphateCompareRequest struct {
+	From      *int `query:"from"`
+	To        *int `query:"to"`
+	Iteration *int `query:"iteration"`
+}
+
+func (r CphateCompareRequest) Validate() error {
+	if r.From == nil || r.To == nil {
+		return errors.New("from and to timepoints are required")
+	}
+
+	if r.Iteration == nil {
+		return errors.New("iteration is required")
+	}
+
+	return nil
+}
+
+// CphateSwitch is a neuron whose cluster at the later timepoint isn't the one most of its
+// earlier cluster went to
+type CphateSwitch struct {
+	UID         string `json:"uid"`
+	FromCluster int    `json:"fromCluster"`
+	ToCluster   int    `json:"toCluster"`
+	// ExpectedCluster is the cluster most neurons of FromCluster ended up in
+	ExpectedCluster int `json:"expectedCluster"`
+}
+
+// CphateComparison compares the clusterings of two timepoints at one iteration, over the
+// neurons present in both
+type CphateComparison struct {
+	From      int `json:"from"`
+	To        int `json:"to"`
+	Iteration int `json:"iteration"`
+	Neurons   int `json:"neurons"`
+	// AdjustedRandIndex is 1 for identical clusterings and around 0 for unrelated ones
+	AdjustedRandIndex float64 `json:"adjustedRandIndex"`
+	// VariationOfInformation is 0 for identical clusterings and grows as they differ, in nats
+	VariationOfInformation float64        `json:"variationOfInformation"`
+	Switched               []CphateSwitch `json:"switched"`
+	// OnlyFrom and OnlyTo are neurons clustered at one of the timepoints only
+	OnlyFrom []string `json:"onlyFrom"`
+	OnlyTo   []string `json:"onlyTo"`
+}
+
+// Clustering maps each neuron to its cluster at the iteration, it returns false when the
+// iteration isn't part of the hierarchy
+func (m CphateMeta) Clustering(iteration int) (map[string]int, bool) {
+	clusters := map[string]int{}
+	found := false
+
+	for _, item := range m {
+		if item.I != iteration {
+			continue
+		}
+
+		found = true
+		for _, neuron := range item.Neurons {
+			if _, ok := clusters[neuron]; !ok {
+				clusters[neuron] = item.C
+			}
+		}
+	}
+
+	return clusters, found
+}
+
+// CompareCphates compares the clusterings of two timepoints at the iteration, it returns false
+// when either timepoint lacks the iteration
+func CompareCphates(from Cphate, to Cphate, iteration int) (CphateComparison, bool) {
+	a, okFrom := from.Structure.Clustering(iteration)
+	b, okTo := to.Structure.Clustering(iteration)
+	if !okFrom || !okTo {
+		return CphateComparison{}, false
+	}
+
+	comparison := CphateComparison{
+		From:      from.Timepoint,
+		To:        to.Timepoint,
+		Iteration: iteration,
+		Switched:  []CphateSwitch{},
+		OnlyFrom:  []string{},
+		OnlyTo:    []string{},
+	}
+
+	// the contingency table counts the neurons in each pair of clusters
+	var neurons []string
+	contingency := map[[2]int]int{}
+	for _, neuron := range slices.Sorted(maps.Keys(a)) {
+		if cb, ok := b[neuron]; ok {
+			neurons = append(neurons, neuron)
+			contingency[[2]int{a[neuron], cb}]++
+		} else {
+			comparison.OnlyFrom = append(comparison.OnlyFrom, neuron)
+		}
+	}
+	for _, neuron := range slices.Sorted(maps.Keys(b)) {
+		if _, ok := a[neuron]; !ok {
+			comparison.OnlyTo = append(comparison.OnlyTo, neuron)
 		}
 	}
 
-	cphateNode := CphateNode{
-		uid:            node,
-		cluster:        cluster,
-		clusterCount:   clusterCount,
-		iteration:      iteration,
-		iterationCount: iterationCount,
-		serial:         serial,
+	comparison.Neurons = len(neurons)
+	if len(neurons) == 0 {
+		return comparison, true
 	}
 
-	cphateNode.neurons = append(cphateNode.neurons, neurons...)
+	rows, cols := map[int]int{}, map[int]int{}
+	for pair, count := range contingency {
+		rows[pair[0]] += count
+		cols[pair[1]] += count
+	}
+
+	comparison.AdjustedRandIndex = adjustedRandIndex(contingency, rows, cols, len(neurons))
+	comparison.VariationOfInformation = variationOfInformation(contingency, rows, cols, len(neurons))
+
+	// each earlier cluster is expected to go where most of its neurons went, ties go to the lower cluster
+	expected := map[int]int{}
+	for _, pair := range slices.SortedFunc(maps.Keys(contingency), func(x, y [2]int) int {
+		return cmp.Or(cmp.Compare(x[0], y[0]), cmp.Compare(x[1], y[1]))
+	}) {
+		if best, ok := expected[pair[0]]; !ok || contingency[pair] > contingency[[2]int{pair[0], best}] {
+			expected[pair[0]] = pair[1]
+		}
+	}
+
+	for _, neuron := range neurons {
+		if b[neuron] != expected[a[neuron]] {
+			comparison.Switched = append(comparison.Switched, CphateSwitch{
+				UID:             neuron,
+				FromCluster:     a[neuron],
+				ToCluster:       b[neuron],
+				ExpectedCluster: expected[a[neuron]],
+			})
+		}
+	}
+
+	return comparison, true
+}
+
+func adjustedRandIndex(contingency map[[2]int]int, rows, cols map[int]int, n int) float64 {
+	pairs := func(k int) float64 { return float64(k) * float64(k-1) / 2 }
+
+	// a single neuron has no pairs to disagree on
+	if n < 2 {
+		return 1
+	}
+
+	var index, sumRows, sumCols float64
+	for _, count := range contingency {
+		index += pairs(count)
+	}
+	for _, count := range rows {
+		sumRows += pairs(count)
+	}
+	for _, count := range cols {
+		sumCols += pairs(count)
+	}
+
+	expected := sumRows * sumCols / pairs(n)
+	maximum := (sumRows + sumCols) / 2
+	if maximum == expected {
+		// both clusterings are a single cluster or all singletons, they can only agree
+		return 1
+	}
+
+	return (index - expected) / (maximum - expected)
+}
+
+func variationOfInformation(contingency map[[2]int]int, rows, cols map[int]int, n int) float64 {
+	total := float64(n)
+
+	var vi float64
+	for pair, count := range contingency {
+		p := float64(count) / total
+		pa := float64(rows[pair[0]]) / total
+		pb := float64(cols[pair[1]]) / total
+		vi -= p * (math.Log(p/pa) + math.Log(p/pb))
+	}
+
+	// rounding can leave a tiny negative for identical clusterings
+	return math.Max(vi, 0)
+}
+
+func containsAll(set []string, subset []string) bool {
+	for _, neuron := range subset {
+		if !slices.Contains(set, neuron) {
+			return false
+		}
+	}
 
-	return cphateNode, nil
+	return true
 }
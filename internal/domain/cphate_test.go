@@ -0,0 +1,205 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"testing"
+
+	"neuroscan/internal/toolshed"
+	"neuroscan/pkg/cluster"
+)
+
+func TestParseCphateNode(t *testing.T) {
+	t.Parallel()
+
+	item, err := parseCphateNode("ADAL_ADAR-i3/12-c2/5-s14")
+	if err != nil {
+		t.Fatalf("Expected node to be parsed, got %v", err)
+	}
+
+	if item.I != 3 || item.IterationCount != 12 || item.C != 2 || item.ClusterCount != 5 || item.S != 14 {
+		t.Errorf("Expected i3/12 c2/5 s14, got i%d/%d c%d/%d s%d", item.I, item.IterationCount, item.C, item.ClusterCount, item.S)
+	}
+
+	if !slices.Equal(item.Neurons, []string{"ADAL", "ADAR"}) {
+		t.Errorf("Expected ADAL and ADAR, got %v", item.Neurons)
+	}
+
+	built, err := buildCphateMetaItem(23, "ADAL_ADAR-i3/12-c2/5-s14", "cphate.gltf", toolshed.DefaultColor)
+	if err != nil || built.ULID != "cph_mi_23_3_2" {
+		t.Errorf("Expected the id to be derived from timepoint, iteration and cluster, got %q", built.ULID)
+	}
+
+	if _, err := parseCphateNode("ADAL"); err == nil {
+		t.Error("Expected a node without clusters to fail")
+	}
+}
+
+func testCphate() Cphate {
+	return Cphate{Timepoint: 23, Structure: CphateMeta{
+		{I: 1, C: 1, Neurons: []string{"ADAL"}, ULID: "a"},
+		{I: 1, C: 2, Neurons: []string{"ADAR"}, ULID: "b"},
+		{I: 1, C: 3, Neurons: []string{"AVAL"}, ULID: "c"},
+		{I: 2, C: 1, Neurons: []string{"ADAL", "ADAR"}, ULID: "ab"},
+		{I: 2, C: 2, Neurons: []string{"AVAL"}, ULID: "c2"},
+		{I: 3, C: 1, Neurons: []string{"ADAL", "ADAR", "AVAL"}, ULID: "abc"},
+	}}
+}
+
+func TestCphateTree(t *testing.T) {
+	t.Parallel()
+
+	tree := testCphate().Tree()
+	if tree.IterationCount != 3 || len(tree.Roots) != 1 {
+		t.Fatalf("Expected 3 iterations under one root, got %d and %d roots", tree.IterationCount, len(tree.Roots))
+	}
+
+	root := tree.Roots[0]
+	if root.ULID != "abc" || len(root.Children) != 2 {
+		t.Fatalf("Expected abc with 2 children, got %s with %d", root.ULID, len(root.Children))
+	}
+
+	if ab := root.Children[0]; ab.ULID != "ab" || len(ab.Children) != 2 || ab.Children[0].ULID != "a" || ab.Children[1].ULID != "b" {
+		t.Errorf("Expected ab to hold a and b, got %+v", ab)
+	}
+
+	if c2 := root.Children[1]; c2.ULID != "c2" || len(c2.Children) != 1 || c2.Children[0].ULID != "c" {
+		t.Errorf("Expected c2 to hold c, got %+v", c2)
+	}
+}
+
+func TestCphateMembership(t *testing.T) {
+	t.Parallel()
+
+	membership := testCphate().Membership("AVAL")
+	if len(membership.Iterations) != 3 {
+		t.Fatalf("Expected AVAL at 3 iterations, got %d", len(membership.Iterations))
+	}
+
+	if got := membership.Iterations[1]; got.ClusterID != "c2" || len(got.Merged) != 0 {
+		t.Errorf("Expected AVAL alone in c2 at iteration 2, got %+v", got)
+	}
+
+	if got := membership.Iterations[2]; got.ClusterID != "abc" || !slices.Equal(got.Merged, []string{"ADAL", "ADAR"}) {
+		t.Errorf("Expected AVAL to merge with ADAL and ADAR at iteration 3, got %+v", got)
+	}
+
+	if len(testCphate().Membership("RIAL").Iterations) != 0 {
+		t.Error("Expected no iterations for a neuron outside the hierarchy")
+	}
+}
+
+func TestCompareCphates(t *testing.T) {
+	t.Parallel()
+
+	from := Cphate{Timepoint: 1, Structure: CphateMeta{
+		{I: 2, C: 1, Neurons: []string{"ADAL", "ADAR"}},
+		{I: 2, C: 2, Neurons: []string{"AVAL", "AVAR"}},
+		{I: 2, C: 3, Neurons: []string{"RIAL"}},
+	}}
+
+	// the same clustering with other cluster numbers
+	relabeled := Cphate{Timepoint: 2, Structure: CphateMeta{
+		{I: 2, C: 2, Neurons: []string{"ADAL", "ADAR"}},
+		{I: 2, C: 1, Neurons: []string{"AVAL", "AVAR"}},
+		{I: 2, C: 3, Neurons: []string{"RIAR"}},
+	}}
+
+	comparison, ok := CompareCphates(from, relabeled, 2)
+	if !ok {
+		t.Fatal("Expected both timepoints to have iteration 2")
+	}
+	if comparison.AdjustedRandIndex != 1 || comparison.VariationOfInformation != 0 || len(comparison.Switched) != 0 {
+		t.Errorf("Expected identical clusterings, got %+v", comparison)
+	}
+	if !slices.Equal(comparison.OnlyFrom, []string{"RIAL"}) || !slices.Equal(comparison.OnlyTo, []string{"RIAR"}) {
+		t.Errorf("Expected RIAL and RIAR to be compared on one side only, got %v and %v", comparison.OnlyFrom, comparison.OnlyTo)
+	}
+
+	// AVAR leaves AVAL and AVBL for ADAL and ADAR
+	from.Structure[1].Neurons = []string{"AVAL", "AVAR", "AVBL"}
+	switched := Cphate{Timepoint: 3, Structure: CphateMeta{
+		{I: 2, C: 1, Neurons: []string{"ADAL", "ADAR", "AVAR"}},
+		{I: 2, C: 2, Neurons: []string{"AVAL", "AVBL"}},
+	}}
+
+	comparison, _ = CompareCphates(from, switched, 2)
+	if comparison.Neurons != 5 || math.Abs(comparison.AdjustedRandIndex-1.0/6) > 1e-9 {
+		t.Errorf("Expected an adjusted rand index of 1/6 over 5 neurons, got %v over %d", comparison.AdjustedRandIndex, comparison.Neurons)
+	}
+	if math.Abs(comparison.VariationOfInformation-0.7638) > 1e-3 {
+		t.Errorf("Expected a variation of information of 0.764, got %v", comparison.VariationOfInformation)
+	}
+	if len(comparison.Switched) != 1 || comparison.Switched[0].UID != "AVAR" || comparison.Switched[0].ExpectedCluster != 2 {
+		t.Errorf("Expected AVAR to have switched, got %+v", comparison.Switched)
+	}
+
+	if _, ok := CompareCphates(from, switched, 5); ok {
+		t.Error("Expected a missing iteration to be reported")
+	}
+}
+
+func TestCompareCphatesFewNeurons(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		from    []string
+		to      []string
+		neurons int
+	}{
+		{name: "no shared neuron", from: []string{"ADAL"}, to: []string{"ADAR"}, neurons: 0},
+		{name: "one shared neuron", from: []string{"ADAL", "AVAL"}, to: []string{"ADAL", "RIAR"}, neurons: 1},
+		{name: "two shared neurons", from: []string{"ADAL", "ADAR"}, to: []string{"ADAL", "ADAR"}, neurons: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			from := Cphate{Timepoint: 1, Structure: CphateMeta{{I: 1, C: 1, Neurons: tt.from}}}
+			to := Cphate{Timepoint: 2, Structure: CphateMeta{{I: 1, C: 1, Neurons: tt.to}}}
+
+			comparison, _ := CompareCphates(from, to, 1)
+			if comparison.Neurons != tt.neurons {
+				t.Errorf("Expected %d shared neurons, got %d", tt.neurons, comparison.Neurons)
+			}
+			if math.IsNaN(comparison.AdjustedRandIndex) || math.IsNaN(comparison.VariationOfInformation) {
+				t.Errorf("Expected defined indices, got %+v", comparison)
+			}
+			if _, err := json.Marshal(comparison); err != nil {
+				t.Errorf("Expected the comparison to encode, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewClusteredCphate(t *testing.T) {
+	t.Parallel()
+
+	levels := []cluster.Level{
+		{{"ADAL"}, {"ADAR"}, {"AIBL"}},
+		{{"ADAL", "ADAR"}, {"AIBL"}},
+		{{"ADAL", "ADAR", "AIBL"}},
+	}
+
+	cphate := NewClusteredCphate(23, levels)
+
+	if cphate.UID != "CPHATE 23" || len(cphate.Structure) != 6 {
+		t.Fatalf("Expected 6 clusters for CPHATE 23, got %d for %s", len(cphate.Structure), cphate.UID)
+	}
+
+	last := cphate.Structure[5]
+	if last.I != 3 || last.C != 1 || last.S != 6 || last.IterationCount != 3 || last.ClusterCount != 1 {
+		t.Errorf("Expected i3/3 c1/1 s6, got i%d/%d c%d/%d s%d", last.I, last.IterationCount, last.C, last.ClusterCount, last.S)
+	}
+
+	if parent := cphate.Structure[2].Parent; parent != CphateNodeULID(23, 2, 2) {
+		t.Errorf("Expected AIBL to merge into %s, got %s", CphateNodeULID(23, 2, 2), parent)
+	}
+
+	if parent := cphate.Structure[3].Parent; parent != last.ULID {
+		t.Errorf("Expected ADAL_ADAR to merge into %s, got %s", last.ULID, parent)
+	}
+}
@@ -31,7 +31,15 @@ func (ds *DevelopmentalStage) ParseCSV(row []string) error {
 	order, _ := strconv.Atoi(row[3])
 	promoterDB, _ := strconv.ParseBool(row[4])
 
-	timepoints := toolshed.ParseTimepointIntArray(row[5])
+	timepoints, err := toolshed.ParseTimepointIntArray(row[5])
+	if err != nil {
+		return fmt.Errorf("developmental stage file is invalid: %w", err)
+	}
+	// the timepoints column can't be null, a stage without timepoints stores an empty array
+	if timepoints == nil {
+		timepoints = []int{}
+	}
+
 	ulid := toolshed.CreateULID(DevelopmentalStageULIDPrefix)
 
 	ds.UID = row[0]
@@ -42,7 +50,7 @@ func (ds *DevelopmentalStage) ParseCSV(row []string) error {
 	ds.PromoterDB = promoterDB
 	ds.Timepoints = timepoints
 
-	err := ds.Validate()
+	err = ds.Validate()
 	if err != nil {
 		return fmt.Errorf("developmental stage file is invalid: %w", err)
 	}
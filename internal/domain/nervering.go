@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"io/fs"
 
 	"neuroscan/internal/toolshed"
 )
@@ -15,10 +16,12 @@ type NerveRing struct {
 	Timepoint int            `json:"timepoint"`
 	Filename  string         `json:"filename"`
 	Color     toolshed.Color `json:"color"`
+	// Bounds is measured from the mesh on ingest, it is used by the spatial queries
+	Bounds *Bounds `json:"bounds"`
 }
 
-func (n *NerveRing) Parse(filePath string) error {
-	fileMetas, err := toolshed.FilePathParse(filePath)
+func (n *NerveRing) Parse(fsys fs.FS, layout *toolshed.Layout, filePath string) error {
+	fileMetas, err := toolshed.FilePathParse(fsys, layout, filePath)
 	if err != nil {
 		return errors.New("error parsing nerve ring file path: " + err.Error())
 	}
@@ -31,6 +34,7 @@ func (n *NerveRing) Parse(filePath string) error {
 	n.Filename = fileMeta.Filename
 	n.Timepoint = fileMeta.Timepoint
 	n.Color = fileMeta.Color
+	n.Bounds = NewBounds(fileMetas...)
 
 	return nil
 }
@@ -2,8 +2,10 @@ package domain
 
 import (
 	"errors"
+	"io/fs"
 
 	"neuroscan/internal/toolshed"
+	"neuroscan/pkg/mesh"
 )
 
 const NeuronULIDPrefix = "neu"
@@ -16,6 +18,56 @@ type Neuron struct {
 	Filename  string         `json:"filename"`
 	Color     toolshed.Color `json:"color"`
 	CellStats *CellStats     `json:"cell_stats"`
+	// SceneSource is set when the neuron was split out of a combined scene file
+	SceneSource *toolshed.SceneSource `json:"scene_source,omitempty"`
+	// FilePath is the file's path under APP_GLTF_DIR, recorded on ingest so it is never searched for
+	FilePath string `json:"-"`
+	// FileHash is the SHA256 of the file, FileURL serves it from /files versioned by the hash
+	FileHash  string     `json:"file_hash"`
+	FileURL   string     `json:"file_url"`
+	MeshStats *MeshStats `json:"mesh_stats"`
+	// FilenameLOD holds the simplified versions of the file, keyed by the percentage of triangles kept
+	FilenameLOD map[string]string `json:"filename_lod"`
+	// ExpressedPromoters are the uids of the promoters that list the neuron, or its class, among their cells
+	ExpressedPromoters []string `json:"expressed_promoters"`
+}
+
+// MeshStats are measured from the neuron's glTF mesh on ingest, in the units of the file.
+// They sit alongside the CellStats from the cell_vol/cell_sa CSVs.
+type MeshStats struct {
+	Volume      float64     `json:"volume"`
+	SurfaceArea float64     `json:"surface_area"`
+	Centroid    [3]float64  `json:"centroid"`
+	BoundingBox BoundingBox `json:"bounding_box"`
+}
+
+type BoundingBox struct {
+	Min [3]float64 `json:"min"`
+	Max [3]float64 `json:"max"`
+}
+
+// MeshDiscrepancy is a neuron whose CSV stat differs from the stat measured from its mesh.
+type MeshDiscrepancy struct {
+	UID                string  `json:"uid"`
+	Timepoint          int     `json:"timepoint"`
+	Stat               string  `json:"stat"`
+	CSV                float64 `json:"csv"`
+	Mesh               float64 `json:"mesh"`
+	RelativeDifference float64 `json:"relative_difference"`
+}
+
+// NewMeshStats converts mesh measurements, it returns nil when there are none
+func NewMeshStats(m *mesh.Measurements) *MeshStats {
+	if m == nil {
+		return nil
+	}
+
+	return &MeshStats{
+		Volume:      m.Volume,
+		SurfaceArea: m.SurfaceArea,
+		Centroid:    m.Centroid,
+		BoundingBox: BoundingBox{Min: m.Min, Max: m.Max},
+	}
 }
 
 type CellStats struct {
@@ -23,8 +75,8 @@ type CellStats struct {
 	SurfaceArea *float64 `json:"surface_area"`
 }
 
-func (n *Neuron) Parse(filePath string) error {
-	fileMetas, err := toolshed.FilePathParse(filePath)
+func (n *Neuron) Parse(fsys fs.FS, layout *toolshed.Layout, filePath string) error {
+	fileMetas, err := toolshed.FilePathParse(fsys, layout, filePath)
 	if err != nil {
 		return errors.New("error parsing neuron file path: " + err.Error())
 	}
@@ -35,12 +87,26 @@ func (n *Neuron) Parse(filePath string) error {
 	n.UID = fileMeta.UID
 	n.ULID = ulid
 	n.Filename = fileMeta.Filename
+	n.FileHash = fileMeta.Filehash
 	n.Timepoint = fileMeta.Timepoint
 	n.Color = fileMeta.Color
+	n.MeshStats = NewMeshStats(fileMeta.Mesh)
 
 	return nil
 }
 
+// ParseSceneNode fills the neuron from a node of a combined scene file
+func (n *Neuron) ParseSceneNode(node toolshed.SceneNode) {
+	n.UID = node.UID
+	n.ULID = toolshed.CreateULID(NeuronULIDPrefix)
+	n.Filename = node.Filename
+	n.FileHash = node.Filehash
+	n.Timepoint = node.Timepoint
+	n.Color = node.Color
+	n.SceneSource = &node.Source
+	n.MeshStats = NewMeshStats(node.Mesh)
+}
+
 func (n *Neuron) Validate() error {
 	if n.ID == 0 {
 		return errors.New("id is invalid")
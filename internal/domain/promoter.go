@@ -1,47 +1,72 @@
 package domain
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
-	"strconv"
+	"slices"
+	"strings"
+	"unicode"
 
 	"neuroscan/internal/toolshed"
 )
 
 const PromoterULIDPrefix = "prmtr"
 
+const (
+	// PromoterCellLineaging marks cells listed in cells_by_lineaging, PromoterCellOther those in other_cells
+	PromoterCellLineaging = "lineaging"
+	PromoterCellOther     = "other"
+)
+
 type Promoter struct {
 	ID                        int    `json:"-"`
 	ULID                      string `json:"id"`
 	UID                       string `json:"uid"`
 	Wormbase                  string `json:"wormbase"`
 	CellularExpressionPattern string `json:"cellular_expression_pattern"`
-	TimepointStart            int    `json:"timepoint_start"`
-	TimepointEnd              int    `json:"timepoint_end"`
-	CellsByLineaging          string `json:"cells_by_lineaging"`
-	ExpressionPatterns        string `json:"expression_patterns"`
-	Information               string `json:"information"`
-	OtherCells                string `json:"other_cells"`
+	// Promoters are expressed from and to a time of development in minutes, on the axis of the
+	// developmental stages' begin and end. These aren't dataset timepoints so they don't reference
+	// the timepoints table. An end of 0 is open.
+	TimepointStart     int    `json:"timepoint_start"`
+	TimepointEnd       int    `json:"timepoint_end"`
+	CellsByLineaging   string `json:"cells_by_lineaging"`
+	ExpressionPatterns string `json:"expression_patterns"`
+	Information        string `json:"information"`
+	OtherCells         string `json:"other_cells"`
+	// The gene of the WormBase ID, filled on ingest from the reference file given with --wormbase
+	WormbaseName         string `json:"wormbase_name"`
+	WormbaseSequenceName string `json:"wormbase_sequence_name"`
+	WormbaseDescription  string `json:"wormbase_description"`
+	// WormbaseStatus is matched, unknown or dead once checked, empty otherwise
+	WormbaseStatus string `json:"wormbase_status"`
 }
 
-func (p *Promoter) ParseCSV(row []string) error {
-	if len(row) != 8 {
-		return errors.New("promoter file is invalid")
-	}
-
-	timepointStart, _ := strconv.Atoi(row[3])
-	timepointEnd, _ := strconv.Atoi(row[4])
-	ulid := toolshed.CreateULID(PromoterULIDPrefix)
+// PromoterCSVSchema declares the columns of the promoters CSV, they may come in any order
+var PromoterCSVSchema = toolshed.CSVSchema{
+	{Name: "uid", Aliases: []string{"promoter"}, Kind: toolshed.CSVString, Required: true},
+	{Name: "wormbase", Aliases: []string{"wormbase_id"}, Kind: toolshed.CSVString},
+	{Name: "cellular_expression_pattern", Kind: toolshed.CSVString},
+	{Name: "timepoint_start", Kind: toolshed.CSVInt},
+	{Name: "timepoint_end", Kind: toolshed.CSVInt},
+	{Name: "cells_by_lineaging", Kind: toolshed.CSVString},
+	{Name: "expression_patterns", Kind: toolshed.CSVString},
+	{Name: "information", Kind: toolshed.CSVString},
+	{Name: "other_cells", Kind: toolshed.CSVString},
+}
 
-	p.UID = row[0]
-	p.ULID = ulid
-	p.Wormbase = row[1]
-	p.TimepointStart = timepointStart
-	p.TimepointEnd = timepointEnd
-	p.CellsByLineaging = row[4]
-	p.ExpressionPatterns = row[5]
-	p.Information = row[6]
-	p.OtherCells = row[7]
+// ParseCSV fills the promoter from a row checked against PromoterCSVSchema
+func (p *Promoter) ParseCSV(record toolshed.CSVRecord) error {
+	p.UID = record.String("uid")
+	p.ULID = toolshed.CreateULID(PromoterULIDPrefix)
+	p.Wormbase = record.String("wormbase")
+	p.CellularExpressionPattern = record.String("cellular_expression_pattern")
+	p.TimepointStart = record.Int("timepoint_start")
+	p.TimepointEnd = record.Int("timepoint_end")
+	p.CellsByLineaging = record.String("cells_by_lineaging")
+	p.ExpressionPatterns = record.String("expression_patterns")
+	p.Information = record.String("information")
+	p.OtherCells = record.String("other_cells")
 
 	err := p.Validate()
 	if err != nil {
@@ -56,5 +81,99 @@ func (p *Promoter) Validate() error {
 		return errors.New("uid is required")
 	}
 
+	if p.TimepointStart != 0 && p.TimepointEnd != 0 && p.TimepointEnd < p.TimepointStart {
+		return fmt.Errorf("timepoint_end %d is before timepoint_start %d", p.TimepointEnd, p.TimepointStart)
+	}
+
 	return nil
 }
+
+// PromoterCell is a cell a promoter drives expression in
+type PromoterCell struct {
+	Cell   string `json:"cell"`
+	Source string `json:"source"`
+}
+
+// Cells lists the cells of cells_by_lineaging and other_cells, which are separated by spaces,
+// commas or semicolons
+func (p Promoter) Cells() []PromoterCell {
+	var cells []PromoterCell
+	for source, list := range map[string]string{PromoterCellLineaging: p.CellsByLineaging, PromoterCellOther: p.OtherCells} {
+		for _, cell := range splitCells(list) {
+			cells = append(cells, PromoterCell{Cell: cell, Source: source})
+		}
+	}
+
+	slices.SortFunc(cells, func(a, b PromoterCell) int {
+		return cmp.Or(cmp.Compare(a.Source, b.Source), cmp.Compare(a.Cell, b.Cell))
+	})
+
+	return slices.Compact(cells)
+}
+
+func splitCells(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';'
+	})
+}
+
+// PromoterCellCandidates returns the uppercased neuron uids that may decide how the cells are
+// matched by PromoterCellNames: each cell, its possible left and right sides, and the sides of
+// its possible class.
+func PromoterCellCandidates(cells []string) []string {
+	var candidates []string
+	for _, cell := range cells {
+		cell = strings.ToUpper(strings.TrimSpace(cell))
+		if cell == "" {
+			continue
+		}
+
+		candidates = append(candidates, cell, cell+"L", cell+"R")
+		if class, ok := bilateralClass(cell); ok {
+			candidates = append(candidates, class+"L", class+"R")
+		}
+	}
+
+	slices.Sort(candidates)
+	return slices.Compact(candidates)
+}
+
+// PromoterCellNames returns the uppercased names a cell is looked up by. Promoters may list a
+// bilateral pair by its class, so AIY matches AIYL and AIYR, and AIYL matches AIY. A class is only
+// a class when both of its sides are among the neuron uids, so ADL matches ADLL and ADLR but is
+// never cut down to AD, and AVL, which has no sides, matches only itself.
+func PromoterCellNames(cell string, neurons []string) []string {
+	cell = strings.ToUpper(strings.TrimSpace(cell))
+	if cell == "" {
+		return nil
+	}
+
+	isNeuron := func(uid string) bool {
+		return slices.ContainsFunc(neurons, func(neuron string) bool { return strings.EqualFold(neuron, uid) })
+	}
+
+	names := []string{cell}
+	if isNeuron(cell+"L") && isNeuron(cell+"R") {
+		names = append(names, cell+"L", cell+"R")
+	}
+
+	if class, ok := bilateralClass(cell); ok && isNeuron(class+"L") && isNeuron(class+"R") {
+		names = append(names, class)
+	}
+
+	return names
+}
+
+// bilateralClass cuts the side off a name ending in L or R, whether or not it is one side of a pair
+func bilateralClass(cell string) (string, bool) {
+	if len(cell) < 2 {
+		return "", false
+	}
+
+	switch cell[len(cell)-1] {
+	case 'L', 'R':
+		return cell[:len(cell)-1], true
+	}
+
+	return "", false
+}
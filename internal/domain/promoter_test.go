@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPromoterParseCSV(t *testing.T) {
+	t.Parallel()
+
+	header, err := PromoterCSVSchema.ParseHeader([]string{"uid", "wormbase", "cellular_expression_pattern", "timepoint_start", "timepoint_end", "cells_by_lineaging", "expression_patterns", "information", "other_cells"})
+	if err != nil {
+		t.Fatalf("Expected header to parse, got %v", err)
+	}
+
+	record, err := header.Record([]string{"ttx-3", "WBGene00006654", "AIY", "300", "720", "AIYL AIYR", "head", "", "ASKL"})
+	if err != nil {
+		t.Fatalf("Expected row to be valid, got %v", err)
+	}
+
+	promoter := Promoter{}
+	if err := promoter.ParseCSV(record); err != nil {
+		t.Fatalf("Expected promoter to parse, got %v", err)
+	}
+
+	if promoter.TimepointStart != 300 || promoter.TimepointEnd != 720 {
+		t.Errorf("Expected timepoints 300 to 720, got %d to %d", promoter.TimepointStart, promoter.TimepointEnd)
+	}
+
+	if promoter.CellsByLineaging != "AIYL AIYR" || promoter.CellularExpressionPattern != "AIY" || promoter.OtherCells != "ASKL" {
+		t.Errorf("Expected cells to be read by column, got %q %q %q", promoter.CellsByLineaging, promoter.CellularExpressionPattern, promoter.OtherCells)
+	}
+
+	record, _ = header.Record([]string{"ttx-3", "", "", "720", "300", "", "", "", ""})
+	if err := promoter.ParseCSV(record); err == nil {
+		t.Error("Expected a promoter ending before it starts to be rejected")
+	}
+}
+
+func TestPromoterCells(t *testing.T) {
+	t.Parallel()
+
+	promoter := Promoter{CellsByLineaging: "AIYL AIYR,  ASK", OtherCells: "AIYL;"}
+
+	expected := []PromoterCell{
+		{Cell: "AIYL", Source: PromoterCellLineaging},
+		{Cell: "AIYR", Source: PromoterCellLineaging},
+		{Cell: "ASK", Source: PromoterCellLineaging},
+		{Cell: "AIYL", Source: PromoterCellOther},
+	}
+
+	if cells := promoter.Cells(); !slices.Equal(cells, expected) {
+		t.Errorf("Expected %v, got %v", expected, cells)
+	}
+}
+
+func TestPromoterCellNames(t *testing.T) {
+	t.Parallel()
+
+	neurons := []string{"AIYL", "AIYR", "ADLL", "ADLR", "ADL", "AVL", "PVR", "ADAL", "ADAR"}
+
+	tests := []struct {
+		cell     string
+		expected []string
+	}{
+		{cell: "aiy", expected: []string{"AIY", "AIYL", "AIYR"}},
+		{cell: "AIYL", expected: []string{"AIYL", "AIY"}},
+		// ADL is a class of its own and not a side of AD
+		{cell: "ADL", expected: []string{"ADL", "ADLL", "ADLR"}},
+		{cell: "ADLL", expected: []string{"ADLL", "ADL"}},
+		{cell: "ADLR", expected: []string{"ADLR", "ADL"}},
+		{cell: "AVL", expected: []string{"AVL"}},
+		{cell: "PVR", expected: []string{"PVR"}},
+		{cell: " ", expected: nil},
+	}
+
+	for _, tt := range tests {
+		if names := PromoterCellNames(tt.cell, neurons); !slices.Equal(names, tt.expected) {
+			t.Errorf("Expected %q to match %v, got %v", tt.cell, tt.expected, names)
+		}
+	}
+
+	candidates := PromoterCellCandidates([]string{"adl"})
+	for _, uid := range []string{"ADL", "ADLL", "ADLR", "ADR"} {
+		if !slices.Contains(candidates, uid) {
+			t.Errorf("Expected %s among the candidates, got %v", uid, candidates)
+		}
+	}
+}
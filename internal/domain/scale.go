@@ -2,12 +2,18 @@ package domain
 
 import (
 	"errors"
+	"io/fs"
+	"math"
 
 	"neuroscan/internal/toolshed"
+	"neuroscan/pkg/gltf"
 )
 
 const ScaleULIDPrefix = "scl"
 
+// ScaleMicrometersExtra is the key of the glTF extras giving the physical length of a scale bar
+const ScaleMicrometersExtra = "micrometers"
+
 type Scale struct {
 	ID        int            `json:"-"`
 	ULID      string         `json:"id"`
@@ -15,10 +21,15 @@ type Scale struct {
 	Timepoint int            `json:"timepoint"`
 	Filename  string         `json:"filename"`
 	Color     toolshed.Color `json:"color"`
+	// Length is the longest side of the scale bar mesh, in the units of the glTF files
+	Length *float64 `json:"length"`
+	// Micrometers is the physical length the scale bar stands for, from the extras of its glTF
+	// or else the --scale-micrometers ingest flag
+	Micrometers *float64 `json:"micrometers"`
 }
 
-func (s *Scale) Parse(filePath string) error {
-	fileMetas, err := toolshed.FilePathParse(filePath)
+func (s *Scale) Parse(fsys fs.FS, layout *toolshed.Layout, filePath string) error {
+	fileMetas, err := toolshed.FilePathParse(fsys, layout, filePath)
 	if err != nil {
 		return errors.New("error parsing scale file path: " + err.Error())
 	}
@@ -32,9 +43,69 @@ func (s *Scale) Parse(filePath string) error {
 	s.Timepoint = fileMeta.Timepoint
 	s.Color = fileMeta.Color
 
+	// the bar can be split over several nodes, measure all of them together
+	lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	measured := false
+	for _, meta := range fileMetas {
+		if meta.Mesh == nil {
+			continue
+		}
+		measured = true
+		for axis := range 3 {
+			lo[axis] = math.Min(lo[axis], meta.Mesh.Min[axis])
+			hi[axis] = math.Max(hi[axis], meta.Mesh.Max[axis])
+		}
+	}
+	if measured {
+		length := math.Max(hi[0]-lo[0], math.Max(hi[1]-lo[1], hi[2]-lo[2]))
+		s.Length = &length
+	}
+
+	doc, err := gltf.OpenFS(fsys, filePath)
+	if err != nil {
+		return errors.New("error opening scale file: " + err.Error())
+	}
+	s.Micrometers = scaleMicrometers(doc)
+
+	return nil
+}
+
+// scaleMicrometers reads the micrometers extra of the scale bar's nodes, scenes, asset or document, in that order
+func scaleMicrometers(doc *gltf.Document) *float64 {
+	extras := []any{}
+	for _, node := range doc.Nodes {
+		extras = append(extras, node.Extras)
+	}
+	for _, scene := range doc.Scenes {
+		extras = append(extras, scene.Extras)
+	}
+	extras = append(extras, doc.Asset.Extras, doc.Extras)
+
+	for _, extra := range extras {
+		values, ok := extra.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if um, ok := values[ScaleMicrometersExtra].(float64); ok && um > 0 {
+			return &um
+		}
+	}
+
 	return nil
 }
 
+// MicrometersPerUnit converts glTF units to micrometers from the stored length and micrometers,
+// ok is false when the scale bar has no measurable mesh or nothing says how long it is.
+func (s *Scale) MicrometersPerUnit() (float64, bool) {
+	if s.Length == nil || s.Micrometers == nil || *s.Length <= 0 {
+		return 0, false
+	}
+
+	return *s.Micrometers / *s.Length, true
+}
+
 func (s *Scale) Validate() error {
 	if s.ID == 0 {
 		return errors.New("id is invalid")
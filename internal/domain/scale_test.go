@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"testing"
+
+	"neuroscan/pkg/gltf"
+)
+
+func TestScaleMicrometers(t *testing.T) {
+	t.Parallel()
+
+	doc := &gltf.Document{
+		Asset: gltf.Asset{Extras: map[string]any{"micrometers": 10.0}},
+		Nodes: []*gltf.Node{{Name: "scale_5um"}, {Name: "bar", Extras: map[string]any{"micrometers": 5.0}}},
+	}
+
+	if um := scaleMicrometers(doc); um == nil || *um != 5 {
+		t.Errorf("Expected the node extras to give 5 micrometers, got %v", um)
+	}
+
+	doc.Nodes[1].Extras = nil
+	if um := scaleMicrometers(doc); um == nil || *um != 10 {
+		t.Errorf("Expected the asset extras to give 10 micrometers, got %v", um)
+	}
+
+	// the name of the bar isn't read
+	doc.Asset.Extras = map[string]any{"micrometers": "10"}
+	if um := scaleMicrometers(doc); um != nil {
+		t.Errorf("Expected no length without a numeric extra, got %v", *um)
+	}
+
+	length, micrometers := 2.0, 5.0
+	scale := Scale{Length: &length, Micrometers: &micrometers}
+	if factor, ok := scale.MicrometersPerUnit(); !ok || factor != 2.5 {
+		t.Errorf("Expected 2.5 micrometers per unit, got %v", factor)
+	}
+}
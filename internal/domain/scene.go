@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"neuroscan/internal/toolshed"
+)
+
+// MaxSceneEntities caps how many entities a single composed scene can hold.
+const MaxSceneEntities = 1000
+
+// SceneRequest lists the neurons, contacts and synapses to merge into one GLB.
+type SceneRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// SceneEntity is what scene composition needs to know about a neuron, contact or synapse.
+type SceneEntity struct {
+	ULID      string
+	UID       string
+	Entity    string
+	Timepoint int
+	Filename  string
+	Color     toolshed.Color
+	// SceneSource is set when the entity lives inside a combined scene file
+	SceneSource *toolshed.SceneSource
+	// FilePath is the path of the entity's file, or of its combined scene, under APP_GLTF_DIR
+	FilePath string
+}
+
+// SceneEntityType returns the entity folder of an ULID from its prefix.
+func SceneEntityType(ulid string) (string, bool) {
+	prefix, _, ok := strings.Cut(ulid, "_")
+	if !ok {
+		return "", false
+	}
+
+	switch prefix {
+	case NeuronULIDPrefix:
+		return "neurons", true
+	case ContactULIDPrefix:
+		return "contacts", true
+	case SynapseULIDPrefix:
+		return "synapses", true
+	}
+
+	return "", false
+}
+
+func (r *SceneRequest) Validate() error {
+	if len(r.IDs) == 0 {
+		return errors.New("ids are required")
+	}
+
+	if len(r.IDs) > MaxSceneEntities {
+		return fmt.Errorf("a scene can hold at most %d entities", MaxSceneEntities)
+	}
+
+	for _, id := range r.IDs {
+		if _, ok := SceneEntityType(id); !ok {
+			return fmt.Errorf("%q is not a neuron, contact or synapse id", id)
+		}
+	}
+
+	return nil
+}
+
+func (n Neuron) SceneEntity() SceneEntity {
+	return SceneEntity{ULID: n.ULID, UID: n.UID, Entity: "neurons", Timepoint: n.Timepoint, Filename: n.Filename, Color: n.Color, SceneSource: n.SceneSource, FilePath: n.FilePath}
+}
+
+func (c Contact) SceneEntity() SceneEntity {
+	return SceneEntity{ULID: c.ULID, UID: c.UID, Entity: "contacts", Timepoint: c.Timepoint, Filename: c.Filename, Color: c.Color, SceneSource: c.SceneSource, FilePath: c.FilePath}
+}
+
+func (s Synapse) SceneEntity() SceneEntity {
+	return SceneEntity{ULID: s.ULID, UID: s.UID, Entity: "synapses", Timepoint: s.Timepoint, Filename: s.Filename, Color: s.Color, SceneSource: s.SceneSource, FilePath: s.FilePath}
+}
+
+// MeshExport is an entity's mesh converted to one of the mesh.Formats.
+type MeshExport struct {
+	Filename    string
+	ContentType string
+	// Units is "um" when the mesh was scaled with the timepoint's scale bar, otherwise "file"
+	Units string
+	Data  []byte
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"neuroscan/internal/toolshed"
+	"neuroscan/pkg/mesh"
+)
+
+// DefaultNearestCount is how many entities a nearest query returns when k is left out.
+const DefaultNearestCount = 10
+
+// MaxNearestCount caps k on nearest queries.
+const MaxNearestCount = 500
+
+// SpatialEntityTypes are the entity types spatial queries search.
+var SpatialEntityTypes = []string{"neurons", "contacts", "synapses"}
+
+// Bounds is where an entity sits in its scene, measured from its mesh on ingest.
+type Bounds struct {
+	Centroid    [3]float64  `json:"centroid"`
+	BoundingBox BoundingBox `json:"bounding_box"`
+}
+
+// NewBounds takes the bounds of the first measured node, it returns nil when no node has a mesh
+func NewBounds(metas ...toolshed.NeuroscanFilepathData) *Bounds {
+	for _, meta := range metas {
+		if meta.Mesh != nil {
+			return newBounds(meta.Mesh)
+		}
+	}
+
+	return nil
+}
+
+func newBounds(m *mesh.Measurements) *Bounds {
+	return &Bounds{
+		Centroid:    m.Centroid,
+		BoundingBox: BoundingBox{Min: m.Min, Max: m.Max},
+	}
+}
+
+// SpatialEntity is a neuron, contact or synapse returned by a spatial query.
+type SpatialEntity struct {
+	ULID        string      `json:"id"`
+	UID         string      `json:"uid"`
+	Entity      string      `json:"entity"`
+	Timepoint   int         `json:"timepoint"`
+	Centroid    [3]float64  `json:"centroid"`
+	BoundingBox BoundingBox `json:"bounding_box"`
+	// Distance from the query point to the centroid, only set by nearest queries
+	Distance *float64 `json:"distance,omitempty"`
+}
+
+// SpatialRequest holds the query parameters of the spatial endpoints. The region or point can
+// be given as "x,y,z" coordinates, or taken from another entity such as the nerve ring.
+type SpatialRequest struct {
+	Timepoint *int     `query:"timepoint"`
+	Types     []string `query:"type"`
+	Min       string   `query:"min"`
+	Max       string   `query:"max"`
+	Point     string   `query:"point"`
+	Entity    string   `query:"entity"`
+	K         int      `query:"k"`
+	// Overlap matches entities whose bounding box touches the region, instead of their centroid lying in it
+	Overlap bool `query:"overlap"`
+
+	// Region and Center are parsed from Min, Max and Point by Validate
+	Region *BoundingBox `query:"-"`
+	Center *[3]float64  `query:"-"`
+}
+
+func (r *SpatialRequest) Validate() error {
+	if r.Timepoint == nil && r.Entity == "" {
+		return errors.New("timepoint is required")
+	}
+
+	var types []string
+	for _, t := range r.Types {
+		types = append(types, strings.Split(t, ",")...)
+	}
+	if len(types) == 0 {
+		types = SpatialEntityTypes
+	}
+	for _, t := range types {
+		if !slices.Contains(SpatialEntityTypes, t) {
+			return fmt.Errorf("type must be one of %s", strings.Join(SpatialEntityTypes, ", "))
+		}
+	}
+	r.Types = types
+
+	if r.K <= 0 {
+		r.K = DefaultNearestCount
+	}
+
+	if r.K > MaxNearestCount {
+		return fmt.Errorf("k can be at most %d", MaxNearestCount)
+	}
+
+	if (r.Min == "") != (r.Max == "") {
+		return errors.New("min and max must be given together")
+	}
+
+	if r.Min != "" {
+		lo, err := ParsePoint(r.Min)
+		if err != nil {
+			return err
+		}
+
+		hi, err := ParsePoint(r.Max)
+		if err != nil {
+			return err
+		}
+
+		for axis := range 3 {
+			if lo[axis] > hi[axis] {
+				return errors.New("min must not be greater than max")
+			}
+		}
+
+		r.Region = &BoundingBox{Min: lo, Max: hi}
+	}
+
+	if r.Point != "" {
+		p, err := ParsePoint(r.Point)
+		if err != nil {
+			return err
+		}
+
+		r.Center = &p
+	}
+
+	return nil
+}
+
+// ParsePoint parses "x,y,z" into a point.
+func ParsePoint(s string) ([3]float64, error) {
+	var p [3]float64
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return p, fmt.Errorf("point %q must be x,y,z", s)
+	}
+
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return p, fmt.Errorf("point %q must be x,y,z", s)
+		}
+		p[i] = v
+	}
+
+	return p, nil
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"io/fs"
 	"strings"
 
 	"neuroscan/internal/toolshed"
@@ -31,6 +32,17 @@ type Synapse struct {
 	Color        toolshed.Color `json:"color"`
 	CellStats    *CellStats     `json:"cell_stats"`
 	SynapseStats *SynapseStats  `json:"synapse_stats"`
+	// SceneSource is set when the synapse was split out of a combined scene file
+	SceneSource *toolshed.SceneSource `json:"scene_source,omitempty"`
+	// FilePath is the file's path under APP_GLTF_DIR, recorded on ingest so it is never searched for
+	FilePath string `json:"-"`
+	// FileHash is the SHA256 of the file, FileURL serves it from /files versioned by the hash
+	FileHash string `json:"file_hash"`
+	FileURL  string `json:"file_url"`
+	// FilenameLOD holds the simplified versions of the file, keyed by the percentage of triangles kept
+	FilenameLOD map[string]string `json:"filename_lod"`
+	// Bounds is measured from the mesh on ingest, it is used by the spatial queries
+	Bounds *Bounds `json:"bounds"`
 }
 
 type SynapseStats struct {
@@ -58,8 +70,8 @@ func getSynapseType(uid string) *SynapseType {
 	return &synapseType
 }
 
-func (s *Synapse) Parse(filePath string) error {
-	fileMetas, err := toolshed.FilePathParse(filePath)
+func (s *Synapse) Parse(fsys fs.FS, layout *toolshed.Layout, filePath string) error {
+	fileMetas, err := toolshed.FilePathParse(fsys, layout, filePath)
 	if err != nil {
 		return errors.New("error parsing synapse file path: " + err.Error())
 	}
@@ -71,13 +83,28 @@ func (s *Synapse) Parse(filePath string) error {
 	s.UID = fileMeta.UID
 	s.ULID = ulid
 	s.Filename = fileMeta.Filename
+	s.FileHash = fileMeta.Filehash
 	s.Timepoint = fileMeta.Timepoint
 	s.Color = fileMeta.Color
+	s.Bounds = NewBounds(fileMetas...)
 	s.SynapseType = *synapseType
 
 	return nil
 }
 
+// ParseSceneNode fills the synapse from a node of a combined scene file
+func (s *Synapse) ParseSceneNode(node toolshed.SceneNode) {
+	s.UID = node.UID
+	s.ULID = toolshed.CreateULID(SynapseULIDPrefix)
+	s.Filename = node.Filename
+	s.FileHash = node.Filehash
+	s.Timepoint = node.Timepoint
+	s.Color = node.Color
+	s.SynapseType = *getSynapseType(node.UID)
+	s.SceneSource = &node.Source
+	s.Bounds = NewBounds(node.NeuroscanFilepathData)
+}
+
 func (s *Synapse) Validate() error {
 	if s.ID == 0 {
 		return errors.New("id is invalid")
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"neuroscan/internal/toolshed"
+)
+
+const TimepointULIDPrefix = "tmpnt"
+
+// TimelineUnits are the units of the developmental axis
+const TimelineUnits = "hours_post_hatch"
+
+// Timepoint is a dataset of the release, which every entity belongs to. Rows are created on
+// ingest for each timepoint found in the source, the timepoints CSV adds when and where the
+// specimen was imaged.
+type Timepoint struct {
+	ULID      string `json:"id"`
+	Timepoint int    `json:"timepoint"`
+	Stage     string `json:"stage"`
+	// HoursPostHatch is nil until the timepoints CSV gives it
+	HoursPostHatch *float64 `json:"hours_post_hatch"`
+	DatasetSource  string   `json:"dataset_source"`
+	SpecimenID     string   `json:"specimen_id"`
+}
+
+// TimepointULID is the id of a timepoint, derived from its number so that it stays the same
+// across ingests, e.g. tmpnt_23
+func TimepointULID(timepoint int) string {
+	return fmt.Sprintf("%s_%d", TimepointULIDPrefix, timepoint)
+}
+
+// NewTimepoint returns a timepoint found in the source, without its CSV details
+func NewTimepoint(timepoint int, stage string) Timepoint {
+	return Timepoint{
+		ULID:      TimepointULID(timepoint),
+		Timepoint: timepoint,
+		Stage:     stage,
+	}
+}
+
+// TimepointCSVSchema declares the columns of the timepoints CSV, they may come in any order
+var TimepointCSVSchema = toolshed.CSVSchema{
+	{Name: "timepoint", Kind: toolshed.CSVInt, Required: true},
+	{Name: "stage", Aliases: []string{"dev_stage", "developmental_stage"}, Kind: toolshed.CSVString},
+	{Name: "hours_post_hatch", Aliases: []string{"hph"}, Kind: toolshed.CSVFloat},
+	{Name: "dataset_source", Aliases: []string{"source", "dataset"}, Kind: toolshed.CSVString},
+	{Name: "specimen_id", Aliases: []string{"specimen"}, Kind: toolshed.CSVString},
+}
+
+// ParseCSV fills the timepoint from a row checked against TimepointCSVSchema
+func (t *Timepoint) ParseCSV(record toolshed.CSVRecord) error {
+	*t = NewTimepoint(record.Int("timepoint"), record.String("stage"))
+	t.HoursPostHatch = record.Float("hours_post_hatch")
+	t.DatasetSource = record.String("dataset_source")
+	t.SpecimenID = record.String("specimen_id")
+
+	err := t.Validate()
+	if err != nil {
+		return fmt.Errorf("error validating timepoint: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Timepoint) Validate() error {
+	if t.Timepoint < 0 {
+		return errors.New("timepoint can't be negative")
+	}
+
+	if t.HoursPostHatch != nil && *t.HoursPostHatch < 0 {
+		return errors.New("hours_post_hatch can't be negative")
+	}
+
+	return nil
+}
+
+// TimelineStage is a developmental stage on the timeline with the timepoints imaged during it
+type TimelineStage struct {
+	Stage string `json:"stage"`
+	Order int    `json:"order"`
+	// Start and End span the hours post-hatch of the stage's timepoints, nil when none has them
+	Start      *float64    `json:"start"`
+	End        *float64    `json:"end"`
+	Timepoints []Timepoint `json:"timepoints"`
+}
+
+// Timeline is the developmental axis the timepoints are placed on
+type Timeline struct {
+	Units  string          `json:"units"`
+	Stages []TimelineStage `json:"stages"`
+}
+
+// NewTimeline groups the timepoints by stage. Stages follow the order of the developmental
+// stages, stages without one come after them in name order. Within a stage timepoints are
+// ordered by hours post-hatch, those without hours last, then by number.
+func NewTimeline(timepoints []Timepoint, devStages []DevelopmentalStage) Timeline {
+	order := map[string]int{}
+	for _, devStage := range devStages {
+		order[devStage.UID] = devStage.Order
+	}
+
+	byStage := map[string][]Timepoint{}
+	for _, timepoint := range timepoints {
+		byStage[timepoint.Stage] = append(byStage[timepoint.Stage], timepoint)
+	}
+
+	timeline := Timeline{Units: TimelineUnits, Stages: []TimelineStage{}}
+	for stage, stageTimepoints := range byStage {
+		slices.SortFunc(stageTimepoints, compareTimepoints)
+
+		timelineStage := TimelineStage{Stage: stage, Order: -1, Timepoints: stageTimepoints}
+		if o, ok := order[stage]; ok {
+			timelineStage.Order = o
+		}
+
+		for _, timepoint := range stageTimepoints {
+			if hours := timepoint.HoursPostHatch; hours != nil {
+				if timelineStage.Start == nil || *hours < *timelineStage.Start {
+					timelineStage.Start = hours
+				}
+				if timelineStage.End == nil || *hours > *timelineStage.End {
+					timelineStage.End = hours
+				}
+			}
+		}
+
+		timeline.Stages = append(timeline.Stages, timelineStage)
+	}
+
+	slices.SortFunc(timeline.Stages, func(a, b TimelineStage) int {
+		// stages without an order go last
+		if (a.Order < 0) != (b.Order < 0) {
+			return cmp.Compare(b.Order, a.Order)
+		}
+		return cmp.Or(cmp.Compare(a.Order, b.Order), cmp.Compare(a.Stage, b.Stage))
+	})
+
+	return timeline
+}
+
+func compareTimepoints(a, b Timepoint) int {
+	switch {
+	case a.HoursPostHatch != nil && b.HoursPostHatch == nil:
+		return -1
+	case a.HoursPostHatch == nil && b.HoursPostHatch != nil:
+		return 1
+	case a.HoursPostHatch != nil && *a.HoursPostHatch != *b.HoursPostHatch:
+		return cmp.Compare(*a.HoursPostHatch, *b.HoursPostHatch)
+	}
+
+	return cmp.Compare(a.Timepoint, b.Timepoint)
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "testing"
+
+func TestNewTimeline(t *testing.T) {
+	t.Parallel()
+
+	hours := func(h float64) *float64 { return &h }
+
+	timepoints := []Timepoint{
+		{Timepoint: 36, Stage: "L2", HoursPostHatch: hours(27)},
+		{Timepoint: 23, Stage: "L1", HoursPostHatch: hours(5)},
+		{Timepoint: 12, Stage: "L1"},
+		{Timepoint: 0, Stage: "L1", HoursPostHatch: hours(0)},
+		{Timepoint: 99, Stage: "dauer"},
+	}
+	devStages := []DevelopmentalStage{{UID: "L2", Order: 2}, {UID: "L1", Order: 1}}
+
+	timeline := NewTimeline(timepoints, devStages)
+
+	if timeline.Units != TimelineUnits || len(timeline.Stages) != 3 {
+		t.Fatalf("Expected 3 stages in %s, got %d in %s", TimelineUnits, len(timeline.Stages), timeline.Units)
+	}
+
+	for i, stage := range []string{"L1", "L2", "dauer"} {
+		if timeline.Stages[i].Stage != stage {
+			t.Errorf("Expected stage %d to be %s, got %s", i, stage, timeline.Stages[i].Stage)
+		}
+	}
+
+	l1 := timeline.Stages[0]
+	for i, timepoint := range []int{0, 23, 12} {
+		if l1.Timepoints[i].Timepoint != timepoint {
+			t.Errorf("Expected L1 timepoint %d to be %d, got %d", i, timepoint, l1.Timepoints[i].Timepoint)
+		}
+	}
+
+	if l1.Start == nil || l1.End == nil || *l1.Start != 0 || *l1.End != 5 {
+		t.Errorf("Expected L1 to span 0 to 5 hours, got %v to %v", l1.Start, l1.End)
+	}
+
+	if dauer := timeline.Stages[2]; dauer.Order != -1 || dauer.Start != nil {
+		t.Errorf("Expected dauer to have no order or hours, got %d %v", dauer.Order, dauer.Start)
+	}
+}
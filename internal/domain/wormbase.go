@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"strings"
+
+	"neuroscan/internal/toolshed"
+)
+
+// The result of checking a promoter's WormBase ID against the reference file
+const (
+	WormbaseMatched = "matched"
+	WormbaseUnknown = "unknown"
+	WormbaseDead    = "dead"
+)
+
+// WormbaseTSVSchema declares the columns of a WormBase gene identifier dump. Only the gene id
+// is required, taxon and status are read so that the columns of a full dump are accepted.
+var WormbaseTSVSchema = toolshed.CSVSchema{
+	{Name: "gene_id", Aliases: []string{"wbgene", "wormbase", "wormbase_id"}, Kind: toolshed.CSVString, Required: true},
+	{Name: "public_name", Aliases: []string{"name", "gene_name"}, Kind: toolshed.CSVString},
+	{Name: "sequence_name", Aliases: []string{"sequence"}, Kind: toolshed.CSVString},
+	{Name: "description", Aliases: []string{"concise_description"}, Kind: toolshed.CSVString},
+	{Name: "status", Kind: toolshed.CSVString},
+	{Name: "taxon_id", Aliases: []string{"taxon"}, Kind: toolshed.CSVString},
+}
+
+// WormbaseGene is a gene of the WormBase reference file
+type WormbaseGene struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	SequenceName string `json:"sequence_name"`
+	Description  string `json:"description"`
+	// Dead genes have been merged into another or withdrawn
+	Dead bool `json:"dead"`
+}
+
+// WormbaseGenes indexes the genes of the reference file by id, and by name and sequence name
+// for promoters that give those instead of an id
+type WormbaseGenes struct {
+	byID   map[string]WormbaseGene
+	byName map[string]string
+}
+
+func NewWormbaseGenes() *WormbaseGenes {
+	return &WormbaseGenes{
+		byID:   map[string]WormbaseGene{},
+		byName: map[string]string{},
+	}
+}
+
+// Add indexes a row checked against WormbaseTSVSchema
+func (g *WormbaseGenes) Add(record toolshed.CSVRecord) WormbaseGene {
+	gene := WormbaseGene{
+		ID:           record.String("gene_id"),
+		Name:         record.String("public_name"),
+		SequenceName: record.String("sequence_name"),
+		Description:  record.String("description"),
+		Dead:         strings.EqualFold(record.String("status"), "dead"),
+	}
+
+	g.byID[strings.ToUpper(gene.ID)] = gene
+
+	// a live gene keeps its name when a dead one shares it
+	for _, name := range []string{gene.Name, gene.SequenceName} {
+		key := strings.ToLower(name)
+		if key == "" {
+			continue
+		}
+
+		if current, ok := g.byName[key]; ok && !g.byID[current].Dead {
+			continue
+		}
+		g.byName[key] = strings.ToUpper(gene.ID)
+	}
+
+	return gene
+}
+
+// Len returns the number of genes
+func (g *WormbaseGenes) Len() int {
+	return len(g.byID)
+}
+
+// Lookup finds a gene by its id, or else by its public or sequence name, ignoring case
+func (g *WormbaseGenes) Lookup(identifier string) (WormbaseGene, bool) {
+	identifier = strings.TrimSpace(identifier)
+
+	if gene, ok := g.byID[strings.ToUpper(identifier)]; ok {
+		return gene, true
+	}
+
+	if id, ok := g.byName[strings.ToLower(identifier)]; ok {
+		return g.byID[id], true
+	}
+
+	return WormbaseGene{}, false
+}
+
+// EnrichWormbase checks the promoter's WormBase ID against the reference genes and stores the
+// gene's canonical id, names and description. A promoter without an ID is left unchecked.
+func (p *Promoter) EnrichWormbase(genes *WormbaseGenes) {
+	if p.Wormbase == "" {
+		return
+	}
+
+	gene, ok := genes.Lookup(p.Wormbase)
+	if !ok {
+		p.WormbaseStatus = WormbaseUnknown
+		return
+	}
+
+	p.Wormbase = gene.ID
+	p.WormbaseName = gene.Name
+	p.WormbaseSequenceName = gene.SequenceName
+	p.WormbaseDescription = gene.Description
+	p.WormbaseStatus = WormbaseMatched
+	if gene.Dead {
+		p.WormbaseStatus = WormbaseDead
+	}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "testing"
+
+func TestEnrichWormbase(t *testing.T) {
+	t.Parallel()
+
+	header, err := WormbaseTSVSchema.ParseHeader([]string{"taxon_id", "gene_id", "public_name", "sequence_name", "status", "description"})
+	if err != nil {
+		t.Fatalf("Expected header to parse, got %v", err)
+	}
+
+	genes := NewWormbaseGenes()
+	for _, row := range [][]string{
+		{"6239", "WBGene00006654", "ttx-3", "C40H5.5", "Live", "LIM homeobox protein"},
+		{"6239", "WBGene00000001", "old-1", "X1.1", "Dead", ""},
+	} {
+		record, err := header.Record(row)
+		if err != nil {
+			t.Fatalf("Expected row to be valid, got %v", err)
+		}
+		genes.Add(record)
+	}
+
+	promoter := Promoter{UID: "ttx-3", Wormbase: "ttx-3"}
+	promoter.EnrichWormbase(genes)
+
+	if promoter.WormbaseStatus != WormbaseMatched || promoter.Wormbase != "WBGene00006654" {
+		t.Errorf("Expected ttx-3 to match WBGene00006654, got %s %s", promoter.WormbaseStatus, promoter.Wormbase)
+	}
+
+	if promoter.WormbaseSequenceName != "C40H5.5" || promoter.WormbaseDescription != "LIM homeobox protein" {
+		t.Errorf("Expected the gene's sequence name and description, got %q %q", promoter.WormbaseSequenceName, promoter.WormbaseDescription)
+	}
+
+	for wormbase, status := range map[string]string{"wbgene00000001": WormbaseDead, "WBGene99999999": WormbaseUnknown, "": ""} {
+		promoter := Promoter{UID: "p", Wormbase: wormbase}
+		promoter.EnrichWormbase(genes)
+
+		if promoter.WormbaseStatus != status {
+			t.Errorf("Expected %q to be %q, got %q", wormbase, status, promoter.WormbaseStatus)
+		}
+	}
+}
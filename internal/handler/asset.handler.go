@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/service"
+	"neuroscan/internal/toolshed"
+
+	"github.com/labstack/echo/v4"
+)
+
+// immutableCacheControl is sent for URLs carrying the file hash, their content never changes
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+type AssetHandler struct {
+	assetService service.AssetService
+	root         string
+	// precompressRoot holds the br and gz variants at the same paths as the files under root
+	precompressRoot string
+}
+
+func NewAssetHandler(assetService service.AssetService, root string, precompressRoot string) *AssetHandler {
+	return &AssetHandler{assetService: assetService, root: root, precompressRoot: precompressRoot}
+}
+
+// ServeAsset serves a file from the glTF directory. Clients that accept br or gzip get the
+// variant precompressed on ingest from the precompress directory, the stored hash is sent as
+// a strong ETag and a URL that carries it as ?v=<hash> is cached for good. Range and
+// conditional requests are handled by http.ServeContent. Files that weren't ingested are
+// still served, without an ETag.
+func (h *AssetHandler) ServeAsset(c echo.Context) error {
+	name, err := url.PathUnescape(c.Param("*"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return err
+	}
+
+	// cleaning against the root keeps the path inside the glTF directory
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	asset, err := h.assetService.GetAsset(c.Request().Context(), name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	file := filepath.Join(h.root, filepath.FromSlash(name))
+	encoding := negotiateEncoding(c.Request().Header.Get(echo.HeaderAcceptEncoding), asset)
+
+	var f *os.File
+	if encoding != "" {
+		f, err = os.Open(filepath.Join(h.precompressRoot, filepath.FromSlash(name)) + toolshed.EncodingExtension(encoding))
+		if errors.Is(err, fs.ErrNotExist) {
+			// the variant was removed since ingest, fall back to the file itself
+			encoding = ""
+		}
+	}
+	if encoding == "" {
+		f, err = os.Open(file)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return echo.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return echo.ErrNotFound
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, contentType(name))
+	header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	header.Set(echo.HeaderCacheControl, "no-cache")
+
+	if encoding != "" {
+		header.Set(echo.HeaderContentEncoding, encoding)
+	}
+
+	if asset.Hash != "" {
+		// each encoding is a different representation, so it needs its own strong ETag
+		etag := asset.Hash
+		if encoding != "" {
+			etag += "-" + encoding
+		}
+		header.Set("ETag", strconv.Quote(etag))
+
+		if c.QueryParam("v") == asset.Hash {
+			header.Set(echo.HeaderCacheControl, immutableCacheControl)
+		}
+	}
+
+	http.ServeContent(c.Response(), c.Request(), path.Base(name), info.ModTime(), f)
+	return nil
+}
+
+// negotiateEncoding picks the first precompressed variant of the asset that the
+// Accept-Encoding header allows, or "" for the file itself.
+func negotiateEncoding(acceptEncoding string, asset domain.Asset) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		accepted[strings.ToLower(strings.TrimSpace(coding))] = !found || strings.Trim(q, "0.") != ""
+	}
+
+	for _, encoding := range toolshed.PrecompressEncodings {
+		allowed, listed := accepted[encoding]
+		if !listed {
+			allowed = accepted["*"]
+		}
+		if allowed && asset.HasEncoding(encoding) {
+			return encoding
+		}
+	}
+
+	return ""
+}
+
+func contentType(name string) string {
+	switch path.Ext(name) {
+	case ".glb":
+		return "model/gltf-binary"
+	case ".gltf":
+		return "model/gltf+json"
+	}
+
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+
+	// sniffing a compressed variant would see the compressed bytes
+	return echo.MIMEOctetStream
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"neuroscan/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// fakeAssetService returns the assets it holds, and an empty asset for files that weren't ingested
+type fakeAssetService map[string]domain.Asset
+
+func (s fakeAssetService) GetAsset(ctx context.Context, path string) (domain.Asset, error) {
+	return s[path], nil
+}
+
+func (s fakeAssetService) SaveAsset(ctx context.Context, asset domain.Asset) error {
+	s[asset.Path] = asset
+	return nil
+}
+
+func (s fakeAssetService) TruncateAssets(ctx context.Context) error {
+	clear(s)
+	return nil
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	t.Parallel()
+
+	asset := domain.Asset{Encodings: []string{"br", "gzip"}}
+
+	tests := []struct {
+		acceptEncoding string
+		asset          domain.Asset
+		expected       string
+	}{
+		{acceptEncoding: "gzip, deflate, br", asset: asset, expected: "br"},
+		{acceptEncoding: "gzip;q=0.5, br;q=0", asset: asset, expected: "gzip"},
+		{acceptEncoding: "BR;q=1.0", asset: asset, expected: "br"},
+		{acceptEncoding: "br;q=0.000, gzip;q=0", asset: asset, expected: ""},
+		{acceptEncoding: "*", asset: asset, expected: "br"},
+		{acceptEncoding: "br;q=0, *", asset: asset, expected: "gzip"},
+		{acceptEncoding: "*;q=0", asset: asset, expected: ""},
+		{acceptEncoding: "identity", asset: asset, expected: ""},
+		{acceptEncoding: "", asset: asset, expected: ""},
+		{acceptEncoding: "gzip, br", asset: domain.Asset{Encodings: []string{"gzip"}}, expected: "gzip"},
+		{acceptEncoding: "gzip, br", asset: domain.Asset{}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.acceptEncoding, tt.asset); got != tt.expected {
+			t.Errorf("Expected %q to pick %q, got %q", tt.acceptEncoding, tt.expected, got)
+		}
+	}
+}
+
+func TestServeAsset(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	root := filepath.Join(dir, "gltf")
+	precompressRoot := filepath.Join(dir, "precompressed")
+	writeTestFile(t, filepath.Join(root, "L1", "23", "neurons", "ADAL.gltf"), `{"asset":{"version":"2.0"}}`)
+	writeTestFile(t, filepath.Join(root, "L1", "23", "neurons", "ADAR.gltf"), `{"asset":{"version":"2.0"}}`)
+	// only the brotli variant is on disk, the gzip one was removed since ingest
+	writeTestFile(t, filepath.Join(precompressRoot, "L1", "23", "neurons", "ADAL.gltf.br"), "brotli")
+	writeTestFile(t, filepath.Join(dir, "secret"), "outside")
+	writeTestFile(t, filepath.Join(root, "secret"), "inside")
+
+	assets := fakeAssetService{
+		"L1/23/neurons/ADAL.gltf": {Path: "L1/23/neurons/ADAL.gltf", Hash: "abc", Size: 27, Encodings: []string{"br", "gzip"}},
+	}
+
+	e := echo.New()
+	e.GET("/files/*", NewAssetHandler(assets, root, precompressRoot).ServeAsset)
+
+	tests := []struct {
+		name            string
+		target          string
+		header          http.Header
+		status          int
+		body            string
+		contentEncoding string
+		etag            string
+		cacheControl    string
+	}{
+		{
+			name:   "brotli variant",
+			target: "/files/L1/23/neurons/ADAL.gltf", header: http.Header{"Accept-Encoding": {"gzip, br"}},
+			status: http.StatusOK, body: "brotli", contentEncoding: "br", etag: `"abc-br"`, cacheControl: "no-cache",
+		},
+		{
+			name:   "missing gzip variant falls back to the file",
+			target: "/files/L1/23/neurons/ADAL.gltf", header: http.Header{"Accept-Encoding": {"gzip"}},
+			status: http.StatusOK, body: `{"asset":{"version":"2.0"}}`, etag: `"abc"`, cacheControl: "no-cache",
+		},
+		{
+			name:   "versioned URL",
+			target: "/files/L1/23/neurons/ADAL.gltf?v=abc",
+			status: http.StatusOK, body: `{"asset":{"version":"2.0"}}`, etag: `"abc"`, cacheControl: immutableCacheControl,
+		},
+		{
+			name:   "outdated version",
+			target: "/files/L1/23/neurons/ADAL.gltf?v=old",
+			status: http.StatusOK, body: `{"asset":{"version":"2.0"}}`, etag: `"abc"`, cacheControl: "no-cache",
+		},
+		{
+			name:   "revalidation",
+			target: "/files/L1/23/neurons/ADAL.gltf", header: http.Header{"If-None-Match": {`"abc"`}},
+			status: http.StatusNotModified, etag: `"abc"`, cacheControl: "no-cache",
+		},
+		{
+			name:   "revalidation of another encoding",
+			target: "/files/L1/23/neurons/ADAL.gltf", header: http.Header{"If-None-Match": {`"abc"`}, "Accept-Encoding": {"br"}},
+			status: http.StatusOK, body: "brotli", contentEncoding: "br", etag: `"abc-br"`, cacheControl: "no-cache",
+		},
+		{
+			name:   "range of the variant",
+			target: "/files/L1/23/neurons/ADAL.gltf", header: http.Header{"Accept-Encoding": {"br"}, "Range": {"bytes=0-1"}},
+			status: http.StatusPartialContent, body: "br", contentEncoding: "br", etag: `"abc-br"`, cacheControl: "no-cache",
+		},
+		{
+			name:   "file that wasn't ingested",
+			target: "/files/L1/23/neurons/ADAR.gltf", header: http.Header{"Accept-Encoding": {"br"}},
+			status: http.StatusOK, body: `{"asset":{"version":"2.0"}}`, cacheControl: "no-cache",
+		},
+		{name: "missing file", target: "/files/L1/23/neurons/AVAL.gltf", status: http.StatusNotFound},
+		{
+			name:   "path outside the root",
+			target: "/files/..%2fsecret",
+			status: http.StatusOK, body: "inside", cacheControl: "no-cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusNotFound {
+				return
+			}
+
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, rec.Body.String())
+			}
+			if got := rec.Header().Get(echo.HeaderContentEncoding); got != tt.contentEncoding {
+				t.Errorf("Expected Content-Encoding %q, got %q", tt.contentEncoding, got)
+			}
+			if got := rec.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("Expected ETag %q, got %q", tt.etag, got)
+			}
+			if got := rec.Header().Get(echo.HeaderCacheControl); got != tt.cacheControl {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.cacheControl, got)
+			}
+			if got := rec.Header().Get(echo.HeaderVary); got != echo.HeaderAcceptEncoding {
+				t.Errorf("Expected Vary Accept-Encoding, got %q", got)
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, name string, data string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -41,6 +41,90 @@ func (h *CphateHandler) CphateByTimepoint(c echo.Context) error {
 	return nil
 }
 
+// CphateTree returns the CPHATE hierarchy of a timepoint as nested clusters for the dendrogram view.
+func (h *CphateHandler) CphateTree(c echo.Context) error {
+	var req domain.APIV1Request
+
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return err
+	}
+
+	if req.Timepoint == nil {
+		c.JSON(http.StatusBadRequest, errors.New("timepoint is required"))
+		return errors.New("timepoint is required")
+	}
+
+	tree, err := h.cphateService.GetCphateTree(c.Request().Context(), *req.Timepoint)
+	if errors.Is(err, service.ErrCphateNotFound) {
+		c.JSON(http.StatusNotFound, err.Error())
+		return err
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return err
+	}
+
+	c.JSON(http.StatusOK, tree)
+	return nil
+}
+
+// NeuronCphate returns which cluster a neuron belongs to at every CPHATE iteration and who it merges with.
+func (h *CphateHandler) NeuronCphate(c echo.Context) error {
+	var req domain.APIV1Request
+
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return err
+	}
+
+	if req.Timepoint == nil {
+		c.JSON(http.StatusBadRequest, errors.New("timepoint is required"))
+		return errors.New("timepoint is required")
+	}
+
+	membership, err := h.cphateService.GetNeuronCphate(c.Request().Context(), *req.Timepoint, req.UID)
+	if errors.Is(err, service.ErrCphateNotFound) || errors.Is(err, service.ErrCphateNeuronNotFound) {
+		c.JSON(http.StatusNotFound, err.Error())
+		return err
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return err
+	}
+
+	c.JSON(http.StatusOK, membership)
+	return nil
+}
+
+// CompareCphates compares the CPHATE clusterings of two timepoints at one iteration.
+func (h *CphateHandler) CompareCphates(c echo.Context) error {
+	var req domain.CphateCompareRequest
+
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return err
+	}
+
+	comparison, err := h.cphateService.CompareCphates(c.Request().Context(), *req.From, *req.To, *req.Iteration)
+	if errors.Is(err, service.ErrCphateNotFound) || errors.Is(err, service.ErrCphateIterationNotFound) {
+		c.JSON(http.StatusNotFound, err.Error())
+		return err
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return err
+	}
+
+	c.JSON(http.StatusOK, comparison)
+	return nil
+}
+
 func (h *CphateHandler) CountCphates(c echo.Context) error {
 	var req domain.APIV1Request
 
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/service"
+	"neuroscan/pkg/mesh"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MeshHandler struct {
+	meshService service.MeshService
+}
+
+func NewMeshHandler(meshService service.MeshService) *MeshHandler {
+	return &MeshHandler{meshService: meshService}
+}
+
+// ExportMesh serves the mesh of a neuron, contact or synapse as OBJ, STL or PLY, OBJ by default.
+func (h *MeshHandler) ExportMesh(c echo.Context) error {
+	var req domain.APIV1Request
+
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return err
+	}
+
+	// /neurons/:ulid/mesh only exports neurons, and the same for contacts and synapses
+	route, _, _ := strings.Cut(strings.TrimPrefix(c.Path(), "/"), "/")
+	if entity, ok := domain.SceneEntityType(req.ULID); !ok || entity != route {
+		c.JSON(http.StatusBadRequest, "invalid ID")
+		return errors.New("invalid ID")
+	}
+
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = mesh.FormatOBJ
+	}
+
+	if !slices.Contains(mesh.Formats, format) {
+		msg := fmt.Sprintf("format must be one of %s", strings.Join(mesh.Formats, ", "))
+		c.JSON(http.StatusBadRequest, msg)
+		return errors.New(msg)
+	}
+
+	export, err := h.meshService.ExportMesh(c.Request().Context(), req.ULID, format)
+	if errors.Is(err, service.ErrSceneEntityNotFound) || errors.Is(err, service.ErrSceneFileNotFound) {
+		c.JSON(http.StatusNotFound, err.Error())
+		return err
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, export.Filename))
+	c.Response().Header().Set("X-Mesh-Units", export.Units)
+
+	return c.Blob(http.StatusOK, export.ContentType, export.Data)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"neuroscan/internal/domain"
@@ -34,3 +35,31 @@ func (h *PromoterHandler) SearchPromoters(c echo.Context) error {
 	c.JSON(http.StatusOK, promoters)
 	return nil
 }
+
+// PromoterNeurons returns the neurons the promoter is expressed in, optionally at one timepoint.
+func (h *PromoterHandler) PromoterNeurons(c echo.Context) error {
+	var req domain.APIV1Request
+
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return err
+	}
+
+	if req.UID == "" {
+		c.JSON(http.StatusBadRequest, "invalid promoter UID")
+		return errors.New("invalid promoter UID")
+	}
+
+	neurons, err := h.promoterService.GetPromoterNeurons(c.Request().Context(), req.UID, req.Timepoint)
+	if errors.Is(err, service.ErrPromoterNotFound) {
+		c.JSON(http.StatusNotFound, err.Error())
+		return err
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return err
+	}
+
+	c.JSON(http.StatusOK, neurons)
+	return nil
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SceneHandler struct {
+	sceneService service.SceneService
+}
+
+func NewSceneHandler(sceneService service.SceneService) *SceneHandler {
+	return &SceneHandler{sceneService: sceneService}
+}
+
+// ComposeScene merges the requested neurons, contacts and synapses into a single GLB.
+func (h *SceneHandler) ComposeScene(c echo.Context) error {
+	var req domain.SceneRequest
+
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return err
+	}
+
+	file, hash, err := h.sceneService.ComposeScene(c.Request().Context(), req)
+	if errors.Is(err, service.ErrSceneEntityNotFound) || errors.Is(err, service.ErrSceneFileNotFound) {
+		c.JSON(http.StatusNotFound, err.Error())
+		return err
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "model/gltf-binary")
+	c.Response().Header().Set("ETag", `"`+hash+`"`)
+	c.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="scene.glb"`)
+
+	return c.File(file)
+}
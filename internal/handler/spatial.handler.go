@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SpatialHandler struct {
+	spatialService service.SpatialService
+}
+
+func NewSpatialHandler(spatialService service.SpatialService) *SpatialHandler {
+	return &SpatialHandler{spatialService: spatialService}
+}
+
+// WithinBox lists the neurons, contacts and synapses inside a region at a timepoint.
+func (h *SpatialHandler) WithinBox(c echo.Context) error {
+	var req domain.SpatialRequest
+
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return err
+	}
+
+	entities, err := h.spatialService.WithinBox(c.Request().Context(), req)
+	return h.respond(c, entities, err)
+}
+
+// Nearest lists the neurons, contacts and synapses closest to a point or another entity.
+func (h *SpatialHandler) Nearest(c echo.Context) error {
+	var req domain.SpatialRequest
+
+	if err := c.Bind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return err
+	}
+
+	entities, err := h.spatialService.Nearest(c.Request().Context(), req)
+	return h.respond(c, entities, err)
+}
+
+func (h *SpatialHandler) respond(c echo.Context, entities []domain.SpatialEntity, err error) error {
+	if errors.Is(err, service.ErrSpatialQueryInvalid) {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return err
+	}
+	if errors.Is(err, service.ErrSpatialEntityNotFound) {
+		c.JSON(http.StatusNotFound, err.Error())
+		return err
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	return c.JSON(http.StatusOK, entities)
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"neuroscan/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TimepointHandler struct {
+	timepointService service.TimepointService
+}
+
+func NewTimepointHandler(timepointService service.TimepointService) *TimepointHandler {
+	return &TimepointHandler{timepointService: timepointService}
+}
+
+func (h *TimepointHandler) SearchTimepoints(c echo.Context) error {
+	timepoints, err := h.timepointService.SearchTimepoints(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return err
+	}
+
+	c.JSON(http.StatusOK, timepoints)
+	return nil
+}
+
+// Timeline returns the timepoints grouped by developmental stage, in hours post-hatch.
+func (h *TimepointHandler) Timeline(c echo.Context) error {
+	timeline, err := h.timepointService.GetTimeline(c.Request().Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err)
+		return err
+	}
+
+	c.JSON(http.StatusOK, timeline)
+	return nil
+}
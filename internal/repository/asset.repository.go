@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"neuroscan/internal/cache"
+	"neuroscan/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AssetRepository interface {
+	GetAsset(ctx context.Context, path string) (domain.Asset, error)
+	SaveAsset(ctx context.Context, asset domain.Asset) error
+	TruncateAssets(ctx context.Context) error
+}
+
+type PostgresAssetRepository struct {
+	cache cache.Cache
+	DB    *pgxpool.Pool
+}
+
+func NewPostgresAssetRepository(db *pgxpool.Pool, c cache.Cache) *PostgresAssetRepository {
+	return &PostgresAssetRepository{
+		cache: c,
+		DB:    db,
+	}
+}
+
+func (r *PostgresAssetRepository) GetAsset(ctx context.Context, path string) (domain.Asset, error) {
+	query := "SELECT path, hash, size, encodings FROM assets WHERE path = $1"
+
+	var asset domain.Asset
+	err := r.DB.QueryRow(ctx, query, path).Scan(&asset.Path, &asset.Hash, &asset.Size, &asset.Encodings)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Asset{}, nil
+		}
+
+		return domain.Asset{}, err
+	}
+
+	return asset, nil
+}
+
+// SaveAsset records an asset, replacing the row of a file that was ingested before.
+func (r *PostgresAssetRepository) SaveAsset(ctx context.Context, asset domain.Asset) error {
+	query := `INSERT INTO assets (path, hash, size, encodings) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (path) DO UPDATE SET hash = EXCLUDED.hash, size = EXCLUDED.size, encodings = EXCLUDED.encodings, updated_at = now()`
+
+	encodings := asset.Encodings
+	if encodings == nil {
+		encodings = []string{}
+	}
+
+	_, err := r.DB.Exec(ctx, query, asset.Path, asset.Hash, asset.Size, encodings)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresAssetRepository) TruncateAssets(ctx context.Context) error {
+	query := "TRUNCATE TABLE assets"
+
+	_, err := r.DB.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
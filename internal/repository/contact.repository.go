@@ -36,29 +36,46 @@ type ContactRepository interface {
 	IngestContact(ctx context.Context, contact domain.Contact, skipExisting bool, force bool) (bool, error)
 	TruncateContacts(ctx context.Context) error
 	ValidContactTimepoints(ctx context.Context) ([]int, error)
+	ContactAreas(ctx context.Context, timepoint int) ([]domain.ContactArea, error)
 }
 
+// contactColumns are the columns selected into a Contact, in struct order
+const contactColumns = "id, ulid, uid, timepoint, filename, color, surface_area, scene_source, filename_lod, centroid, bbox_min, bbox_max, file_path, file_hash"
+
 type Contact struct {
-	ID          int             `db:"id"`
-	ULID        string          `db:"ulid"`
-	UID         string          `db:"uid"`
-	Timepoint   int             `db:"timepoint"`
-	Filename    string          `db:"filename"`
-	Color       toolshed.Color  `db:"color"`
-	SurfaceArea sql.NullFloat64 `db:"surface_area"`
+	ID          int                   `db:"id"`
+	ULID        string                `db:"ulid"`
+	UID         string                `db:"uid"`
+	Timepoint   int                   `db:"timepoint"`
+	Filename    string                `db:"filename"`
+	Color       toolshed.Color        `db:"color"`
+	SurfaceArea sql.NullFloat64       `db:"surface_area"`
+	SceneSource *toolshed.SceneSource `db:"scene_source"`
+	FilenameLOD map[string]string     `db:"filename_lod"`
+	Centroid    []float64             `db:"centroid"`
+	BBoxMin     []float64             `db:"bbox_min"`
+	BBoxMax     []float64             `db:"bbox_max"`
+	FilePath    string                `db:"file_path"`
+	FileHash    string                `db:"file_hash"`
 }
 
 func (c *Contact) ToDomain(neuron *domain.Neuron, totalPatches *int, totalCellPatchSA *float64, ranking *domain.Ranking) domain.Contact {
 	contact := domain.Contact{
-		ID:         c.ID,
-		ULID:       c.ULID,
-		UID:        c.UID,
-		Timepoint:  c.Timepoint,
-		Filename:   c.Filename,
-		Color:      c.Color,
-		CellStats:  &domain.CellStats{},
-		PatchStats: &domain.PatchStats{},
-		Ranking:    ranking,
+		ID:          c.ID,
+		ULID:        c.ULID,
+		UID:         c.UID,
+		Timepoint:   c.Timepoint,
+		Filename:    c.Filename,
+		Color:       c.Color,
+		CellStats:   &domain.CellStats{},
+		PatchStats:  &domain.PatchStats{},
+		Ranking:     ranking,
+		SceneSource: c.SceneSource,
+		FilenameLOD: c.FilenameLOD,
+		Bounds:      toBounds(c.Centroid, c.BBoxMin, c.BBoxMax),
+		FilePath:    c.FilePath,
+		FileHash:    c.FileHash,
+		FileURL:     domain.AssetURL(c.FilePath, c.FileHash),
 	}
 
 	if c.SurfaceArea.Valid {
@@ -101,10 +118,10 @@ func NewPostgresContactRepository(db *pgxpool.Pool, c cache.Cache) *PostgresCont
 }
 
 func (r *PostgresContactRepository) GetContactByULID(ctx context.Context, id string) (domain.Contact, error) {
-	query := "SELECT * FROM contacts WHERE ulid = $1"
+	query := "SELECT " + contactColumns + " FROM contacts WHERE ulid = $1"
 
 	var contact Contact
-	err := r.DB.QueryRow(ctx, query, id).Scan(&contact.ID, &contact.ULID, &contact.UID, &contact.Timepoint, &contact.Filename, &contact.Color, &contact.SurfaceArea)
+	err := r.DB.QueryRow(ctx, query, id).Scan(&contact.ID, &contact.ULID, &contact.UID, &contact.Timepoint, &contact.Filename, &contact.Color, &contact.SurfaceArea, &contact.SceneSource, &contact.FilenameLOD, &contact.Centroid, &contact.BBoxMin, &contact.BBoxMax, &contact.FilePath, &contact.FileHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Contact{}, nil
@@ -137,10 +154,10 @@ func (r *PostgresContactRepository) GetContactByULID(ctx context.Context, id str
 }
 
 func (r *PostgresContactRepository) GetContactByUID(ctx context.Context, uid string, timepoint int) (domain.Contact, error) {
-	query := "SELECT * FROM contacts WHERE uid = $1 AND timepoint = $2"
+	query := "SELECT " + contactColumns + " FROM contacts WHERE uid = $1 AND timepoint = $2"
 
 	var contact Contact
-	err := r.DB.QueryRow(ctx, query, uid, timepoint).Scan(&contact.ID, &contact.ULID, &contact.UID, &contact.Timepoint, &contact.Filename, &contact.Color, &contact.SurfaceArea)
+	err := r.DB.QueryRow(ctx, query, uid, timepoint).Scan(&contact.ID, &contact.ULID, &contact.UID, &contact.Timepoint, &contact.Filename, &contact.Color, &contact.SurfaceArea, &contact.SceneSource, &contact.FilenameLOD, &contact.Centroid, &contact.BBoxMin, &contact.BBoxMax, &contact.FilePath, &contact.FileHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Contact{}, nil
@@ -189,7 +206,7 @@ func (r *PostgresContactRepository) ContactExists(ctx context.Context, uid strin
 }
 
 func (r *PostgresContactRepository) SearchContacts(ctx context.Context, query domain.APIV1Request) ([]domain.Contact, error) {
-	q := "SELECT * FROM contacts "
+	q := "SELECT " + contactColumns + " FROM contacts "
 
 	parsedQuery, args := r.ParseContactAPIV1Request(ctx, query)
 
@@ -242,9 +259,11 @@ func (r *PostgresContactRepository) CreateContact(ctx context.Context, contact d
 		return fmt.Errorf("contact already exists")
 	}
 
-	query := "INSERT INTO contacts (uid, ulid, timepoint, filename, color) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING"
+	query := "INSERT INTO contacts (uid, ulid, timepoint, filename, color, scene_source, filename_lod, centroid, bbox_min, bbox_max, file_path, file_hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING"
+
+	centroid, bboxMin, bboxMax := boundsColumns(contact.Bounds)
 
-	_, err = r.DB.Exec(ctx, query, contact.UID, contact.ULID, contact.Timepoint, contact.Filename, contact.Color)
+	_, err = r.DB.Exec(ctx, query, contact.UID, contact.ULID, contact.Timepoint, contact.Filename, contact.Color, contact.SceneSource, lodFilenames(contact.FilenameLOD), centroid, bboxMin, bboxMax, contact.FilePath, contact.FileHash)
 	if err != nil {
 		return err
 	}
@@ -300,10 +319,10 @@ func (r *PostgresContactRepository) ContactNeuron(ctx context.Context, uid strin
 		return domain.Neuron{}, errors.New("invalid uid")
 	}
 
-	query := "SELECT * FROM neurons WHERE uid = $1 and timepoint = $2"
+	query := "SELECT " + neuronColumns + " FROM neurons WHERE uid = $1 and timepoint = $2"
 
 	var neuron Neuron
-	err := r.DB.QueryRow(ctx, query, cellUID, timepoint).Scan(&neuron.ID, &neuron.ULID, &neuron.UID, &neuron.Timepoint, &neuron.Filename, &neuron.Color, &neuron.Volume, &neuron.SurfaceArea)
+	err := r.DB.QueryRow(ctx, query, cellUID, timepoint).Scan(&neuron.ID, &neuron.ULID, &neuron.UID, &neuron.Timepoint, &neuron.Filename, &neuron.Color, &neuron.Volume, &neuron.SurfaceArea, &neuron.SceneSource, &neuron.MeshVolume, &neuron.MeshSurfaceArea, &neuron.Centroid, &neuron.BBoxMin, &neuron.BBoxMax, &neuron.FilenameLOD, &neuron.FilePath, &neuron.FileHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Neuron{}, nil
@@ -622,3 +641,25 @@ func (r *PostgresContactRepository) ValidContactTimepoints(ctx context.Context)
 
 	return timepoints, nil
 }
+
+// ContactAreas returns the surface area of every contact of a timepoint that has one
+func (r *PostgresContactRepository) ContactAreas(ctx context.Context, timepoint int) ([]domain.ContactArea, error) {
+	query := "SELECT uid, surface_area FROM contacts WHERE timepoint = $1 AND surface_area IS NOT NULL ORDER BY uid"
+
+	rows, err := r.DB.Query(ctx, query, timepoint)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	areas := []domain.ContactArea{}
+	for rows.Next() {
+		var area domain.ContactArea
+		if err := rows.Scan(&area.UID, &area.SurfaceArea); err != nil {
+			return nil, err
+		}
+		areas = append(areas, area)
+	}
+
+	return areas, rows.Err()
+}
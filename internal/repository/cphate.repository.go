@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"neuroscan/internal/cache"
 	"neuroscan/internal/domain"
@@ -14,6 +16,7 @@ import (
 
 type CphateRepository interface {
 	GetCphateByTimepoint(ctx context.Context, timepoint int) (domain.Cphate, error)
+	GetCphateByNeuron(ctx context.Context, timepoint int, uid string) (domain.Cphate, error)
 	CountCphates(ctx context.Context, timepoint int) (int, error)
 	CphateExists(ctx context.Context, timepoint int) (bool, error)
 	CreateCphate(ctx context.Context, cphate domain.Cphate) error
@@ -34,11 +37,16 @@ func NewPostgresCphateRepository(db *pgxpool.Pool, c cache.Cache) *PostgresCphat
 	}
 }
 
+// cphateNodeColumns are selected from cphate_nodes n joined to their parent p, the neurons of
+// a node are aggregated in their original order
+const cphateNodeColumns = `n.ulid, n.iteration, n.cluster, n.serial, n.iteration_count, n.cluster_count, n.obj_file, n.color, coalesce(p.ulid, ''),
+	array_remove(array_agg(nn.uid ORDER BY nn.position), NULL)`
+
 func (r *PostgresCphateRepository) GetCphateByTimepoint(ctx context.Context, timepoint int) (domain.Cphate, error) {
-	query := "SELECT id, uid, ulid, timepoint, structure FROM cphates WHERE timepoint = $1"
+	query := "SELECT id, uid, ulid, timepoint FROM cphates WHERE timepoint = $1"
 
 	var cphate domain.Cphate
-	err := r.DB.QueryRow(ctx, query, timepoint).Scan(&cphate.ID, &cphate.UID, &cphate.ULID, &cphate.Timepoint, &cphate.Structure)
+	err := r.DB.QueryRow(ctx, query, timepoint).Scan(&cphate.ID, &cphate.UID, &cphate.ULID, &cphate.Timepoint)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Cphate{}, nil
@@ -47,9 +55,73 @@ func (r *PostgresCphateRepository) GetCphateByTimepoint(ctx context.Context, tim
 		return domain.Cphate{}, err
 	}
 
+	nodesQuery := `SELECT ` + cphateNodeColumns + `
+		FROM cphate_nodes n
+		LEFT JOIN cphate_nodes p ON p.id = n.parent_id
+		LEFT JOIN cphate_node_neurons nn ON nn.node_id = n.id
+		WHERE n.cphate_id = $1
+		GROUP BY n.id, p.ulid
+		ORDER BY n.iteration, n.cluster`
+
+	cphate.Structure, err = r.getCphateNodes(ctx, nodesQuery, cphate.ID)
+	if err != nil {
+		return domain.Cphate{}, err
+	}
+
 	return cphate, nil
 }
 
+// GetCphateByNeuron returns the CPHATE of the timepoint with only the clusters that hold the neuron
+func (r *PostgresCphateRepository) GetCphateByNeuron(ctx context.Context, timepoint int, uid string) (domain.Cphate, error) {
+	query := "SELECT id, uid, ulid, timepoint FROM cphates WHERE timepoint = $1"
+
+	var cphate domain.Cphate
+	err := r.DB.QueryRow(ctx, query, timepoint).Scan(&cphate.ID, &cphate.UID, &cphate.ULID, &cphate.Timepoint)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Cphate{}, nil
+		}
+
+		return domain.Cphate{}, err
+	}
+
+	nodesQuery := `SELECT ` + cphateNodeColumns + `
+		FROM cphate_nodes n
+		LEFT JOIN cphate_nodes p ON p.id = n.parent_id
+		LEFT JOIN cphate_node_neurons nn ON nn.node_id = n.id
+		WHERE n.cphate_id = $1 AND EXISTS (SELECT 1 FROM cphate_node_neurons m WHERE m.node_id = n.id AND m.uid = $2)
+		GROUP BY n.id, p.ulid
+		ORDER BY n.iteration, n.cluster`
+
+	cphate.Structure, err = r.getCphateNodes(ctx, nodesQuery, cphate.ID, uid)
+	if err != nil {
+		return domain.Cphate{}, err
+	}
+
+	return cphate, nil
+}
+
+func (r *PostgresCphateRepository) getCphateNodes(ctx context.Context, query string, args ...any) (domain.CphateMeta, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	structure := domain.CphateMeta{}
+	for rows.Next() {
+		var item domain.CphateMetaItem
+		err := rows.Scan(&item.ULID, &item.I, &item.C, &item.S, &item.IterationCount, &item.ClusterCount, &item.ObjFile, &item.Color, &item.Parent, &item.Neurons)
+		if err != nil {
+			return nil, err
+		}
+
+		structure = append(structure, item)
+	}
+
+	return structure, rows.Err()
+}
+
 func (r *PostgresCphateRepository) CountCphates(ctx context.Context, timepoint int) (int, error) {
 	query := "SELECT COUNT(*) FROM cphates WHERE timepoint = $1"
 
@@ -88,14 +160,47 @@ func (r *PostgresCphateRepository) CreateCphate(ctx context.Context, cphate doma
 		return fmt.Errorf("cphate already exists")
 	}
 
-	query := "INSERT INTO cphates (uid, ulid, timepoint, structure) VALUES ($1, $2, $3, $4)"
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := "INSERT INTO cphates (uid, ulid, timepoint) VALUES ($1, $2, $3) RETURNING id"
 
-	_, err = r.DB.Exec(ctx, query, cphate.UID, cphate.ULID, cphate.Timepoint, cphate.Structure)
+	var cphateID int
+	err = tx.QueryRow(ctx, query, cphate.UID, cphate.ULID, cphate.Timepoint).Scan(&cphateID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// parents sit at later iterations, inserting those first lets every node look its parent up
+	items := slices.Clone(cphate.Structure)
+	slices.SortFunc(items, func(a, b domain.CphateMetaItem) int {
+		return cmp.Or(cmp.Compare(b.I, a.I), cmp.Compare(a.C, b.C))
+	})
+
+	nodeQuery := `INSERT INTO cphate_nodes (ulid, cphate_id, parent_id, timepoint, iteration, cluster, serial, iteration_count, cluster_count, obj_file, color)
+		VALUES ($1, $2, (SELECT id FROM cphate_nodes WHERE ulid = $3), $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+
+	batch := &pgx.Batch{}
+	for _, item := range items {
+		var nodeID int
+		err = tx.QueryRow(ctx, nodeQuery, item.ULID, cphateID, item.Parent, cphate.Timepoint, item.I, item.C, item.S, item.IterationCount, item.ClusterCount, item.ObjFile, item.Color).Scan(&nodeID)
+		if err != nil {
+			return err
+		}
+
+		for position, uid := range item.Neurons {
+			batch.Queue("INSERT INTO cphate_node_neurons (node_id, uid, position) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", nodeID, uid, position)
+		}
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *PostgresCphateRepository) DeleteCphate(ctx context.Context, timepoint int) error {
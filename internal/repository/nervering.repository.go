@@ -34,10 +34,11 @@ func NewPostgresNerveRingRepository(db *pgxpool.Pool, c cache.Cache) *PostgresNe
 }
 
 func (r *PostgresNerveRingRepository) GetNerveRingByTimepoint(ctx context.Context, timepoint int) (domain.NerveRing, error) {
-	query := "SELECT id, uid, ulid, timepoint, filename, color FROM nerve_rings WHERE timepoint = $1"
+	query := "SELECT id, uid, ulid, timepoint, filename, color, centroid, bbox_min, bbox_max FROM nerve_rings WHERE timepoint = $1"
 
 	var nerveRing domain.NerveRing
-	err := r.DB.QueryRow(ctx, query, timepoint).Scan(&nerveRing.ID, &nerveRing.UID, &nerveRing.ULID, &nerveRing.Timepoint, &nerveRing.Filename, &nerveRing.Color)
+	var centroid, bboxMin, bboxMax []float64
+	err := r.DB.QueryRow(ctx, query, timepoint).Scan(&nerveRing.ID, &nerveRing.UID, &nerveRing.ULID, &nerveRing.Timepoint, &nerveRing.Filename, &nerveRing.Color, &centroid, &bboxMin, &bboxMax)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.NerveRing{}, nil
@@ -46,6 +47,8 @@ func (r *PostgresNerveRingRepository) GetNerveRingByTimepoint(ctx context.Contex
 		return domain.NerveRing{}, err
 	}
 
+	nerveRing.Bounds = toBounds(centroid, bboxMin, bboxMax)
+
 	return nerveRing, nil
 }
 
@@ -75,9 +78,11 @@ func (r *PostgresNerveRingRepository) CreateNerveRing(ctx context.Context, nerve
 		return fmt.Errorf("nerve ring already exists")
 	}
 
-	query := "INSERT INTO nerve_rings (uid, ulid, timepoint, filename, color) VALUES ($1, $2, $3, $4, $5)"
+	query := "INSERT INTO nerve_rings (uid, ulid, timepoint, filename, color, centroid, bbox_min, bbox_max) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+
+	centroid, bboxMin, bboxMax := boundsColumns(nerveRing.Bounds)
 
-	_, err = r.DB.Exec(ctx, query, nerveRing.UID, nerveRing.ULID, nerveRing.Timepoint, nerveRing.Filename, nerveRing.Color)
+	_, err = r.DB.Exec(ctx, query, nerveRing.UID, nerveRing.ULID, nerveRing.Timepoint, nerveRing.Filename, nerveRing.Color, centroid, bboxMin, bboxMax)
 	if err != nil {
 		return err
 	}
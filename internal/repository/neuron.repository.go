@@ -27,28 +27,48 @@ type NeuronRepository interface {
 	IngestNeuron(ctx context.Context, neuron domain.Neuron, skipExisting bool, force bool) (bool, error)
 	TruncateNeurons(ctx context.Context) error
 	ValidNeuronTimepoints(ctx context.Context) ([]int, error)
+	MeshDiscrepancies(ctx context.Context, threshold float64) ([]domain.MeshDiscrepancy, error)
+	NeuronUIDs(ctx context.Context, timepoint int) ([]string, error)
+	ExpressedPromoters(ctx context.Context, uids []string) (map[string][]string, error)
 }
 
+// neuronColumns are the columns selected into a Neuron, in struct order
+const neuronColumns = "id, ulid, uid, timepoint, filename, color, volume, surface_area, scene_source, mesh_volume, mesh_surface_area, centroid, bbox_min, bbox_max, filename_lod, file_path, file_hash"
+
 type Neuron struct {
-	ID          int             `db:"id"`
-	ULID        string          `db:"ulid"`
-	UID         string          `db:"uid"`
-	Timepoint   int             `db:"timepoint"`
-	Filename    string          `db:"filename"`
-	Color       toolshed.Color  `db:"color"`
-	Volume      sql.NullFloat64 `db:"volume"`
-	SurfaceArea sql.NullFloat64 `db:"surface_area"`
+	ID              int                   `db:"id"`
+	ULID            string                `db:"ulid"`
+	UID             string                `db:"uid"`
+	Timepoint       int                   `db:"timepoint"`
+	Filename        string                `db:"filename"`
+	Color           toolshed.Color        `db:"color"`
+	Volume          sql.NullFloat64       `db:"volume"`
+	SurfaceArea     sql.NullFloat64       `db:"surface_area"`
+	SceneSource     *toolshed.SceneSource `db:"scene_source"`
+	MeshVolume      sql.NullFloat64       `db:"mesh_volume"`
+	MeshSurfaceArea sql.NullFloat64       `db:"mesh_surface_area"`
+	Centroid        []float64             `db:"centroid"`
+	BBoxMin         []float64             `db:"bbox_min"`
+	BBoxMax         []float64             `db:"bbox_max"`
+	FilenameLOD     map[string]string     `db:"filename_lod"`
+	FilePath        string                `db:"file_path"`
+	FileHash        string                `db:"file_hash"`
 }
 
 func (n *Neuron) ToDomain() domain.Neuron {
 	neuron := domain.Neuron{
-		ID:        n.ID,
-		ULID:      n.ULID,
-		UID:       n.UID,
-		Timepoint: n.Timepoint,
-		Filename:  n.Filename,
-		Color:     n.Color,
-		CellStats: &domain.CellStats{},
+		ID:          n.ID,
+		ULID:        n.ULID,
+		UID:         n.UID,
+		Timepoint:   n.Timepoint,
+		Filename:    n.Filename,
+		Color:       n.Color,
+		CellStats:   &domain.CellStats{},
+		SceneSource: n.SceneSource,
+		FilenameLOD: n.FilenameLOD,
+		FilePath:    n.FilePath,
+		FileHash:    n.FileHash,
+		FileURL:     domain.AssetURL(n.FilePath, n.FileHash),
 	}
 
 	if n.Volume.Valid {
@@ -59,6 +79,17 @@ func (n *Neuron) ToDomain() domain.Neuron {
 		neuron.CellStats.SurfaceArea = &n.SurfaceArea.Float64
 	}
 
+	if n.MeshVolume.Valid && n.MeshSurfaceArea.Valid {
+		neuron.MeshStats = &domain.MeshStats{
+			Volume:      n.MeshVolume.Float64,
+			SurfaceArea: n.MeshSurfaceArea.Float64,
+		}
+
+		copy(neuron.MeshStats.Centroid[:], n.Centroid)
+		copy(neuron.MeshStats.BoundingBox.Min[:], n.BBoxMin)
+		copy(neuron.MeshStats.BoundingBox.Max[:], n.BBoxMax)
+	}
+
 	return neuron
 }
 
@@ -75,10 +106,10 @@ func NewPostgresNeuronRepository(db *pgxpool.Pool, c cache.Cache) *PostgresNeuro
 }
 
 func (r *PostgresNeuronRepository) GetNeuronByULID(ctx context.Context, id string) (domain.Neuron, error) {
-	query := "SELECT * FROM neurons WHERE ulid = $1"
+	query := "SELECT " + neuronColumns + " FROM neurons WHERE ulid = $1"
 
 	var neuron Neuron
-	err := r.DB.QueryRow(ctx, query, id).Scan(&neuron.ID, &neuron.ULID, &neuron.UID, &neuron.Timepoint, &neuron.Filename, &neuron.Color, &neuron.Volume, &neuron.SurfaceArea)
+	err := r.DB.QueryRow(ctx, query, id).Scan(&neuron.ID, &neuron.ULID, &neuron.UID, &neuron.Timepoint, &neuron.Filename, &neuron.Color, &neuron.Volume, &neuron.SurfaceArea, &neuron.SceneSource, &neuron.MeshVolume, &neuron.MeshSurfaceArea, &neuron.Centroid, &neuron.BBoxMin, &neuron.BBoxMax, &neuron.FilenameLOD, &neuron.FilePath, &neuron.FileHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Neuron{}, nil
@@ -91,10 +122,10 @@ func (r *PostgresNeuronRepository) GetNeuronByULID(ctx context.Context, id strin
 }
 
 func (r *PostgresNeuronRepository) GetNeuronByUID(ctx context.Context, uid string, timepoint int) (domain.Neuron, error) {
-	query := "SELECT * FROM neurons WHERE uid = $1 AND timepoint = $2"
+	query := "SELECT " + neuronColumns + " FROM neurons WHERE uid = $1 AND timepoint = $2"
 
 	var neuron Neuron
-	err := r.DB.QueryRow(ctx, query, uid, timepoint).Scan(&neuron.ID, &neuron.ULID, &neuron.UID, &neuron.Timepoint, &neuron.Filename, &neuron.Color, &neuron.Volume, &neuron.SurfaceArea)
+	err := r.DB.QueryRow(ctx, query, uid, timepoint).Scan(&neuron.ID, &neuron.ULID, &neuron.UID, &neuron.Timepoint, &neuron.Filename, &neuron.Color, &neuron.Volume, &neuron.SurfaceArea, &neuron.SceneSource, &neuron.MeshVolume, &neuron.MeshSurfaceArea, &neuron.Centroid, &neuron.BBoxMin, &neuron.BBoxMax, &neuron.FilenameLOD, &neuron.FilePath, &neuron.FileHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Neuron{}, nil
@@ -123,7 +154,7 @@ func (r *PostgresNeuronRepository) NeuronExists(ctx context.Context, uid string,
 }
 
 func (r *PostgresNeuronRepository) SearchNeurons(ctx context.Context, query domain.APIV1Request) ([]domain.Neuron, error) {
-	q := "SELECT * FROM neurons "
+	q := "SELECT " + neuronColumns + " FROM neurons "
 
 	parsedQuery, args := r.ParseNeuronAPIV1Request(ctx, query)
 
@@ -176,9 +207,20 @@ func (r *PostgresNeuronRepository) CreateNeuron(ctx context.Context, neuron doma
 		return fmt.Errorf("neuron already exists")
 	}
 
-	query := "INSERT INTO neurons (uid, ulid, timepoint, filename, color) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING"
+	query := "INSERT INTO neurons (uid, ulid, timepoint, filename, color, scene_source, mesh_volume, mesh_surface_area, centroid, bbox_min, bbox_max, filename_lod, file_path, file_hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) ON CONFLICT DO NOTHING"
+
+	var meshVolume, meshSurfaceArea *float64
+	var centroid, bboxMin, bboxMax []float64
 
-	_, err = r.DB.Exec(ctx, query, neuron.UID, neuron.ULID, neuron.Timepoint, neuron.Filename, neuron.Color)
+	if neuron.MeshStats != nil {
+		meshVolume = &neuron.MeshStats.Volume
+		meshSurfaceArea = &neuron.MeshStats.SurfaceArea
+		centroid = neuron.MeshStats.Centroid[:]
+		bboxMin = neuron.MeshStats.BoundingBox.Min[:]
+		bboxMax = neuron.MeshStats.BoundingBox.Max[:]
+	}
+
+	_, err = r.DB.Exec(ctx, query, neuron.UID, neuron.ULID, neuron.Timepoint, neuron.Filename, neuron.Color, neuron.SceneSource, meshVolume, meshSurfaceArea, centroid, bboxMin, bboxMax, lodFilenames(neuron.FilenameLOD), neuron.FilePath, neuron.FileHash)
 	if err != nil {
 		return err
 	}
@@ -186,6 +228,15 @@ func (r *PostgresNeuronRepository) CreateNeuron(ctx context.Context, neuron doma
 	return nil
 }
 
+// lodFilenames stores entities without simplified versions as NULL rather than an empty object
+func lodFilenames(filenames map[string]string) any {
+	if len(filenames) == 0 {
+		return nil
+	}
+
+	return filenames
+}
+
 // UpdateNeuron takes a neuron and updates the fields accordingly
 func (r *PostgresNeuronRepository) UpdateNeuron(ctx context.Context, neuron domain.Neuron) error {
 	query := `UPDATE neurons SET `
@@ -489,3 +540,85 @@ func (r *PostgresNeuronRepository) ValidNeuronTimepoints(ctx context.Context) ([
 
 	return timepoints, nil
 }
+
+// MeshDiscrepancies returns the neurons whose CSV volume or surface area differs from the value
+// measured from their mesh by more than threshold, relative to the larger of the two.
+func (r *PostgresNeuronRepository) MeshDiscrepancies(ctx context.Context, threshold float64) ([]domain.MeshDiscrepancy, error) {
+	query := `
+		SELECT uid, timepoint, stat, csv, mesh, abs(csv - mesh) / greatest(abs(csv), abs(mesh)) AS relative_difference
+		FROM (
+			SELECT uid, timepoint, 'volume' AS stat, volume AS csv, mesh_volume AS mesh FROM neurons
+			UNION ALL
+			SELECT uid, timepoint, 'surface_area' AS stat, surface_area AS csv, mesh_surface_area AS mesh FROM neurons
+		) stats
+		WHERE csv IS NOT NULL AND mesh IS NOT NULL AND greatest(abs(csv), abs(mesh)) > 0
+		AND abs(csv - mesh) / greatest(abs(csv), abs(mesh)) > $1
+		ORDER BY relative_difference DESC, uid, timepoint`
+
+	rows, err := r.DB.Query(ctx, query, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discrepancies := []domain.MeshDiscrepancy{}
+	for rows.Next() {
+		var d domain.MeshDiscrepancy
+		if err := rows.Scan(&d.UID, &d.Timepoint, &d.Stat, &d.CSV, &d.Mesh, &d.RelativeDifference); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	return discrepancies, rows.Err()
+}
+
+// NeuronUIDs returns the uids of the neurons of a timepoint in order
+func (r *PostgresNeuronRepository) NeuronUIDs(ctx context.Context, timepoint int) ([]string, error) {
+	query := "SELECT DISTINCT uid FROM neurons WHERE timepoint = $1 ORDER BY uid"
+
+	rows, err := r.DB.Query(ctx, query, timepoint)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uids := []string{}
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		uids = append(uids, uid)
+	}
+
+	return uids, rows.Err()
+}
+
+// ExpressedPromoters returns the uids of the promoters expressed in each of the neurons, keyed by neuron uid
+func (r *PostgresNeuronRepository) ExpressedPromoters(ctx context.Context, uids []string) (map[string][]string, error) {
+	query := `
+		SELECT n.uid, array_agg(DISTINCT p.uid ORDER BY p.uid)
+		FROM unnest($1::text[]) AS n(uid)
+		JOIN promoter_cells pc ON ` + fmt.Sprintf(promoterCellMatch, "n.uid") + `
+		JOIN promoters p ON p.id = pc.promoter_id
+		GROUP BY n.uid`
+
+	rows, err := r.DB.Query(ctx, query, uids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promoters := map[string][]string{}
+	for rows.Next() {
+		var uid string
+		var expressed []string
+		if err := rows.Scan(&uid, &expressed); err != nil {
+			return nil, err
+		}
+		promoters[uid] = expressed
+	}
+
+	return promoters, rows.Err()
+}
@@ -22,8 +22,23 @@ type PromoterRepository interface {
 	DeletePromoter(ctx context.Context, uid string) error
 	IngestPromoter(ctx context.Context, promoter domain.Promoter, skipExisting bool, force bool) (bool, error)
 	TruncatePromoters(ctx context.Context) error
+	GetPromoterNeurons(ctx context.Context, uid string, timepoint *int) ([]domain.Neuron, error)
 }
 
+// promoterColumns are the columns selected into a Promoter, in struct order
+const promoterColumns = "id, uid, ulid, wormbase, cellular_expression_pattern, timepoint_start, timepoint_end, cells_by_lineaging, expression_patterns, information, other_cells, wormbase_name, wormbase_sequence_name, wormbase_description, wormbase_status"
+
+// promoterCellMatch matches a cell listed by a promoter to the uid of a neuron. A bilateral pair
+// may be listed by its class, e.g. AIY for AIYL and AIYR, which only counts when both sides are
+// neurons, so that ADL isn't taken for a side of AD.
+const promoterCellMatch = `(upper(pc.cell) = upper(%[1]s) OR (
+	upper(%[1]s) IN (upper(pc.cell) || 'L', upper(pc.cell) || 'R')
+	AND (SELECT count(DISTINCT upper(side.uid)) FROM neurons side WHERE upper(side.uid) IN (upper(pc.cell) || 'L', upper(pc.cell) || 'R')) = 2
+))`
+
+// promoterOpenEnd is true when a promoter's expression has no end, its timepoint_end being null or 0
+const promoterOpenEnd = "coalesce(timepoint_end, 0) = 0"
+
 type PostgresPromoterRepository struct {
 	cache cache.Cache
 	DB    *pgxpool.Pool
@@ -37,10 +52,10 @@ func NewPostgresPromoterRepository(db *pgxpool.Pool, c cache.Cache) *PostgresPro
 }
 
 func (r *PostgresPromoterRepository) GetPromoterByUID(ctx context.Context, uid string) (domain.Promoter, error) {
-	query := "SELECT id, uid, ulid, wormbase, cellular_expression_pattern, timepoint_start, timepoint_end, cells_by_lineaging, expression_patterns, information, other_cells FROM promoters WHERE uid = $1"
+	query := "SELECT " + promoterColumns + " FROM promoters WHERE uid = $1"
 
 	var promoter domain.Promoter
-	err := r.DB.QueryRow(ctx, query, uid).Scan(&promoter.ID, &promoter.UID, &promoter.ULID, &promoter.Wormbase, &promoter.CellularExpressionPattern, &promoter.TimepointStart, &promoter.TimepointEnd, &promoter.CellsByLineaging, &promoter.ExpressionPatterns, &promoter.Information, &promoter.OtherCells)
+	err := r.DB.QueryRow(ctx, query, uid).Scan(&promoter.ID, &promoter.UID, &promoter.ULID, &promoter.Wormbase, &promoter.CellularExpressionPattern, &promoter.TimepointStart, &promoter.TimepointEnd, &promoter.CellsByLineaging, &promoter.ExpressionPatterns, &promoter.Information, &promoter.OtherCells, &promoter.WormbaseName, &promoter.WormbaseSequenceName, &promoter.WormbaseDescription, &promoter.WormbaseStatus)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Promoter{}, nil
@@ -69,9 +84,12 @@ func (r *PostgresPromoterRepository) PromoterExists(ctx context.Context, uid str
 }
 
 func (r *PostgresPromoterRepository) SearchPromoters(ctx context.Context, query domain.APIV1Request) ([]domain.Promoter, error) {
-	q := "SELECT id, uid, ulid, wormbase, cellular_expression_pattern, timepoint_start, timepoint_end, cells_by_lineaging, expression_patterns, information, other_cells FROM promoters "
+	q := "SELECT " + promoterColumns + " FROM promoters "
 
-	parsedQuery, args := r.ParsePromoterAPIV1Request(ctx, query)
+	parsedQuery, args, err := r.ParsePromoterAPIV1Request(ctx, query)
+	if err != nil {
+		return nil, err
+	}
 
 	q += parsedQuery
 
@@ -94,11 +112,14 @@ func (r *PostgresPromoterRepository) CountPromoters(ctx context.Context, query d
 
 	q := "SELECT COUNT(*) FROM promoters "
 
-	parsedQuery, args := r.ParsePromoterAPIV1Request(ctx, query)
+	parsedQuery, args, err := r.ParsePromoterAPIV1Request(ctx, query)
+	if err != nil {
+		return 0, err
+	}
 
 	q += parsedQuery
 
-	err := r.DB.QueryRow(ctx, q, args...).Scan(&count)
+	err = r.DB.QueryRow(ctx, q, args...).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -116,14 +137,31 @@ func (r *PostgresPromoterRepository) CreatePromoter(ctx context.Context, promote
 		return fmt.Errorf("promoter already exists")
 	}
 
-	query := "INSERT INTO promoters (uid, ulid, wormbase, cellular_expression_pattern, timepoint_start, timepoint_end, cells_by_lineaging, expression_patterns, information, other_cells) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT DO NOTHING"
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := "INSERT INTO promoters (uid, ulid, wormbase, cellular_expression_pattern, timepoint_start, timepoint_end, cells_by_lineaging, expression_patterns, information, other_cells, wormbase_name, wormbase_sequence_name, wormbase_description, wormbase_status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id"
 
-	_, err = r.DB.Exec(ctx, query, promoter.UID, promoter.ULID, promoter.Wormbase, promoter.CellularExpressionPattern, promoter.TimepointStart, promoter.TimepointEnd, promoter.CellsByLineaging, promoter.ExpressionPatterns, promoter.Information, promoter.OtherCells)
+	var promoterID int
+	err = tx.QueryRow(ctx, query, promoter.UID, promoter.ULID, promoter.Wormbase, promoter.CellularExpressionPattern, promoter.TimepointStart, promoter.TimepointEnd, promoter.CellsByLineaging, promoter.ExpressionPatterns, promoter.Information, promoter.OtherCells, promoter.WormbaseName, promoter.WormbaseSequenceName, promoter.WormbaseDescription, promoter.WormbaseStatus).Scan(&promoterID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// the cells are kept in their own table so promoters can be searched by the cells they express in
+	batch := &pgx.Batch{}
+	for _, cell := range promoter.Cells() {
+		batch.Queue("INSERT INTO promoter_cells (promoter_id, cell, source) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", promoterID, cell.Cell, cell.Source)
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *PostgresPromoterRepository) DeletePromoter(ctx context.Context, uid string) error {
@@ -173,13 +211,43 @@ func (r *PostgresPromoterRepository) IngestPromoter(ctx context.Context, promote
 	return true, nil
 }
 
-func (r *PostgresPromoterRepository) ParsePromoterAPIV1Request(ctx context.Context, req domain.APIV1Request) (string, []any) {
+func (r *PostgresPromoterRepository) ParsePromoterAPIV1Request(ctx context.Context, req domain.APIV1Request) (string, []any, error) {
 	queryParts := []string{"where 1=1"}
 	args := []any{}
 
+	// promoters are expressed over a range of timepoints rather than at one
 	if req.Timepoint != nil {
 		args = append(args, req.Timepoint)
-		queryParts = append(queryParts, fmt.Sprintf("timepoint = $%d", len(args)))
+		queryParts = append(queryParts, fmt.Sprintf("coalesce(timepoint_start, 0) <= $%d AND (%s OR timepoint_end >= $%d)", len(args), promoterOpenEnd, len(args)))
+	}
+
+	if req.TimepointFrom != nil {
+		args = append(args, req.TimepointFrom)
+		queryParts = append(queryParts, fmt.Sprintf("(%s OR timepoint_end >= $%d)", promoterOpenEnd, len(args)))
+	}
+
+	if req.TimepointTo != nil {
+		args = append(args, req.TimepointTo)
+		queryParts = append(queryParts, fmt.Sprintf("coalesce(timepoint_start, 0) <= $%d", len(args)))
+	}
+
+	if len(req.Cells) > 0 {
+		neurons, err := r.knownNeurons(ctx, domain.PromoterCellCandidates(req.Cells))
+		if err != nil {
+			return "", nil, err
+		}
+
+		names := []string{}
+		for _, cell := range req.Cells {
+			names = append(names, domain.PromoterCellNames(cell, neurons)...)
+		}
+		args = append(args, names)
+		queryParts = append(queryParts, fmt.Sprintf("EXISTS (SELECT 1 FROM promoter_cells pc WHERE pc.promoter_id = promoters.id AND upper(pc.cell) = ANY($%d))", len(args)))
+	}
+
+	if len(req.Wormbase) > 0 {
+		args = append(args, req.Wormbase)
+		queryParts = append(queryParts, fmt.Sprintf("wormbase = ANY($%d)", len(args)))
 	}
 
 	if len(req.UIDs) > 0 {
@@ -196,7 +264,7 @@ func (r *PostgresPromoterRepository) ParsePromoterAPIV1Request(ctx context.Conte
 
 	// if count is true, return the query and args before adding the sort and limit
 	if req.Count {
-		return query, args
+		return query, args, nil
 	}
 
 	if req.Sort != "" {
@@ -226,5 +294,45 @@ func (r *PostgresPromoterRepository) ParsePromoterAPIV1Request(ctx context.Conte
 		query += fmt.Sprintf(" offset $%d", len(args))
 	}
 
-	return query, args
+	return query, args, nil
+}
+
+// knownNeurons returns which of the uppercased uids are the uid of a neuron at any timepoint
+func (r *PostgresPromoterRepository) knownNeurons(ctx context.Context, uids []string) ([]string, error) {
+	rows, err := r.DB.Query(ctx, "SELECT DISTINCT upper(uid) FROM neurons WHERE upper(uid) = ANY($1)", uids)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// GetPromoterNeurons returns the neurons the promoter expresses in, at every timepoint unless one is given
+func (r *PostgresPromoterRepository) GetPromoterNeurons(ctx context.Context, uid string, timepoint *int) ([]domain.Neuron, error) {
+	query := "SELECT " + neuronColumns + " FROM neurons WHERE EXISTS (SELECT 1 FROM promoter_cells pc JOIN promoters p ON p.id = pc.promoter_id WHERE p.uid = $1 AND " + fmt.Sprintf(promoterCellMatch, "neurons.uid") + ")"
+	args := []any{uid}
+
+	if timepoint != nil {
+		args = append(args, *timepoint)
+		query += " AND timepoint = $2"
+	}
+
+	query += " ORDER BY timepoint, uid"
+
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	neurons, err := pgx.CollectRows(rows, pgx.RowToStructByName[Neuron])
+	if err != nil {
+		return nil, err
+	}
+
+	domainNeurons := make([]domain.Neuron, len(neurons))
+	for i := range neurons {
+		domainNeurons[i] = neurons[i].ToDomain()
+	}
+
+	return domainNeurons, nil
 }
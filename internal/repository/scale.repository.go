@@ -34,10 +34,10 @@ func NewPostgresScaleRepository(db *pgxpool.Pool, c cache.Cache) *PostgresScaleR
 }
 
 func (r *PostgresScaleRepository) GetScaleByTimepoint(ctx context.Context, timepoint int) ([]domain.Scale, error) {
-	query := "SELECT id, uid, ulid, timepoint, filename, color FROM scales WHERE timepoint = $1"
+	query := "SELECT id, uid, ulid, timepoint, filename, color, length, micrometers FROM scales WHERE timepoint = $1"
 
 	var scale domain.Scale
-	err := r.DB.QueryRow(ctx, query, timepoint).Scan(&scale.ID, &scale.UID, &scale.ULID, &scale.Timepoint, &scale.Filename, &scale.Color)
+	err := r.DB.QueryRow(ctx, query, timepoint).Scan(&scale.ID, &scale.UID, &scale.ULID, &scale.Timepoint, &scale.Filename, &scale.Color, &scale.Length, &scale.Micrometers)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return []domain.Scale{}, nil
@@ -75,9 +75,9 @@ func (r *PostgresScaleRepository) CreateScale(ctx context.Context, scale domain.
 		return fmt.Errorf("scale already exists")
 	}
 
-	query := "INSERT INTO scales (uid, ulid, timepoint, filename, color) VALUES ($1, $2, $3, $4, $5)"
+	query := "INSERT INTO scales (uid, ulid, timepoint, filename, color, length, micrometers) VALUES ($1, $2, $3, $4, $5, $6, $7)"
 
-	_, err = r.DB.Exec(ctx, query, scale.UID, scale.ULID, scale.Timepoint, scale.Filename, scale.Color)
+	_, err = r.DB.Exec(ctx, query, scale.UID, scale.ULID, scale.Timepoint, scale.Filename, scale.Color, scale.Length, scale.Micrometers)
 	if err != nil {
 		return err
 	}
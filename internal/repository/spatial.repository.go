@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"neuroscan/internal/cache"
+	"neuroscan/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SpatialRepository interface {
+	// GetSpatialEntity looks up the bounds of a neuron, contact, synapse or nerve ring by its ULID
+	GetSpatialEntity(ctx context.Context, ulid string) (domain.SpatialEntity, error)
+	// WithinBox returns the entities whose centroid lies in the box, or whose bounding box overlaps it
+	WithinBox(ctx context.Context, timepoint int, types []string, box domain.BoundingBox, overlap bool) ([]domain.SpatialEntity, error)
+	// Nearest returns the k entities with their centroid closest to point, nearest first
+	Nearest(ctx context.Context, timepoint int, types []string, point [3]float64, k int, exclude string) ([]domain.SpatialEntity, error)
+}
+
+// spatialTables are the tables spatial queries run over, keyed by entity type
+var spatialTables = map[string]string{
+	"neurons":     "neurons",
+	"contacts":    "contacts",
+	"synapses":    "synapses",
+	"nerve_rings": "nerve_rings",
+}
+
+type SpatialEntity struct {
+	ULID      string          `db:"ulid"`
+	UID       string          `db:"uid"`
+	Entity    string          `db:"entity"`
+	Timepoint int             `db:"timepoint"`
+	Centroid  []float64       `db:"centroid"`
+	BBoxMin   []float64       `db:"bbox_min"`
+	BBoxMax   []float64       `db:"bbox_max"`
+	Distance  sql.NullFloat64 `db:"distance"`
+}
+
+func (s *SpatialEntity) ToDomain() domain.SpatialEntity {
+	entity := domain.SpatialEntity{
+		ULID:      s.ULID,
+		UID:       s.UID,
+		Entity:    s.Entity,
+		Timepoint: s.Timepoint,
+	}
+
+	copy(entity.Centroid[:], s.Centroid)
+	copy(entity.BoundingBox.Min[:], s.BBoxMin)
+	copy(entity.BoundingBox.Max[:], s.BBoxMax)
+
+	if s.Distance.Valid {
+		entity.Distance = &s.Distance.Float64
+	}
+
+	return entity
+}
+
+type PostgresSpatialRepository struct {
+	cache cache.Cache
+	DB    *pgxpool.Pool
+}
+
+func NewPostgresSpatialRepository(db *pgxpool.Pool, c cache.Cache) *PostgresSpatialRepository {
+	return &PostgresSpatialRepository{
+		cache: c,
+		DB:    db,
+	}
+}
+
+// spatialSource unions the measured entities of the given types into one relation
+func spatialSource(types []string) (string, error) {
+	var selects []string
+	for _, t := range types {
+		table, ok := spatialTables[t]
+		if !ok {
+			return "", fmt.Errorf("unknown spatial entity type %q", t)
+		}
+
+		selects = append(selects, fmt.Sprintf("SELECT ulid, uid, '%s' AS entity, timepoint, centroid, bbox_min, bbox_max FROM %s WHERE centroid IS NOT NULL", t, table))
+	}
+
+	if len(selects) == 0 {
+		return "", errors.New("no spatial entity types given")
+	}
+
+	return "(" + strings.Join(selects, " UNION ALL ") + ") AS spatial", nil
+}
+
+func (r *PostgresSpatialRepository) GetSpatialEntity(ctx context.Context, ulid string) (domain.SpatialEntity, error) {
+	source, err := spatialSource([]string{"neurons", "contacts", "synapses", "nerve_rings"})
+	if err != nil {
+		return domain.SpatialEntity{}, err
+	}
+
+	query := "SELECT ulid, uid, entity, timepoint, centroid, bbox_min, bbox_max, NULL::double precision AS distance FROM " + source + " WHERE ulid = $1 LIMIT 1"
+
+	rows, _ := r.DB.Query(ctx, query, ulid)
+	entity, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[SpatialEntity])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.SpatialEntity{}, nil
+		}
+
+		return domain.SpatialEntity{}, err
+	}
+
+	return entity.ToDomain(), nil
+}
+
+func (r *PostgresSpatialRepository) WithinBox(ctx context.Context, timepoint int, types []string, box domain.BoundingBox, overlap bool) ([]domain.SpatialEntity, error) {
+	source, err := spatialSource(types)
+	if err != nil {
+		return nil, err
+	}
+
+	// arrays are 1-indexed, $2-$4 are the box minimum and $5-$7 its maximum
+	condition := "centroid[1] BETWEEN $2 AND $5 AND centroid[2] BETWEEN $3 AND $6 AND centroid[3] BETWEEN $4 AND $7"
+	if overlap {
+		condition = "bbox_min[1] <= $5 AND bbox_max[1] >= $2 AND bbox_min[2] <= $6 AND bbox_max[2] >= $3 AND bbox_min[3] <= $7 AND bbox_max[3] >= $4"
+	}
+
+	query := "SELECT ulid, uid, entity, timepoint, centroid, bbox_min, bbox_max, NULL::double precision AS distance FROM " + source +
+		" WHERE timepoint = $1 AND " + condition + " ORDER BY entity, uid"
+
+	rows, _ := r.DB.Query(ctx, query, timepoint, box.Min[0], box.Min[1], box.Min[2], box.Max[0], box.Max[1], box.Max[2])
+	entities, err := pgx.CollectRows(rows, pgx.RowToStructByName[SpatialEntity])
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.SpatialEntity, len(entities))
+	for i, entity := range entities {
+		results[i] = entity.ToDomain()
+	}
+
+	return results, nil
+}
+
+func (r *PostgresSpatialRepository) Nearest(ctx context.Context, timepoint int, types []string, point [3]float64, k int, exclude string) ([]domain.SpatialEntity, error) {
+	source, err := spatialSource(types)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT ulid, uid, entity, timepoint, centroid, bbox_min, bbox_max, distance FROM (" +
+		"SELECT *, sqrt(power(centroid[1] - $2, 2) + power(centroid[2] - $3, 2) + power(centroid[3] - $4, 2)) AS distance FROM " + source +
+		" WHERE timepoint = $1 AND ulid <> $5) AS measured ORDER BY distance, uid LIMIT $6"
+
+	rows, _ := r.DB.Query(ctx, query, timepoint, point[0], point[1], point[2], exclude, k)
+	entities, err := pgx.CollectRows(rows, pgx.RowToStructByName[SpatialEntity])
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.SpatialEntity, len(entities))
+	for i, entity := range entities {
+		results[i] = entity.ToDomain()
+	}
+
+	return results, nil
+}
+
+// boundsColumns splits bounds into the centroid, bbox_min and bbox_max columns, all NULL without bounds
+func boundsColumns(bounds *domain.Bounds) (centroid, bboxMin, bboxMax []float64) {
+	if bounds == nil {
+		return nil, nil, nil
+	}
+
+	return bounds.Centroid[:], bounds.BoundingBox.Min[:], bounds.BoundingBox.Max[:]
+}
+
+// toBounds is the reverse of boundsColumns
+func toBounds(centroid, bboxMin, bboxMax []float64) *domain.Bounds {
+	if len(centroid) != 3 || len(bboxMin) != 3 || len(bboxMax) != 3 {
+		return nil
+	}
+
+	bounds := &domain.Bounds{}
+	copy(bounds.Centroid[:], centroid)
+	copy(bounds.BoundingBox.Min[:], bboxMin)
+	copy(bounds.BoundingBox.Max[:], bboxMax)
+
+	return bounds
+}
@@ -29,14 +29,24 @@ type SynapseRepository interface {
 	ValidSynapseTimepoints(ctx context.Context) ([]int, error)
 }
 
+// synapseColumns are the columns selected into a Synapse, in struct order
+const synapseColumns = "id, ulid, uid, timepoint, synapse_type, filename, color, scene_source, filename_lod, centroid, bbox_min, bbox_max, file_path, file_hash"
+
 type Synapse struct {
-	ID          int            `db:"id"`
-	ULID        string         `db:"ulid"`
-	UID         string         `db:"uid"`
-	Timepoint   int            `db:"timepoint"`
-	SynapseType sql.NullString `db:"synapse_type"`
-	Filename    string         `db:"filename"`
-	Color       toolshed.Color `db:"color"`
+	ID          int                   `db:"id"`
+	ULID        string                `db:"ulid"`
+	UID         string                `db:"uid"`
+	Timepoint   int                   `db:"timepoint"`
+	SynapseType sql.NullString        `db:"synapse_type"`
+	Filename    string                `db:"filename"`
+	Color       toolshed.Color        `db:"color"`
+	SceneSource *toolshed.SceneSource `db:"scene_source"`
+	FilenameLOD map[string]string     `db:"filename_lod"`
+	Centroid    []float64             `db:"centroid"`
+	BBoxMin     []float64             `db:"bbox_min"`
+	BBoxMax     []float64             `db:"bbox_max"`
+	FilePath    string                `db:"file_path"`
+	FileHash    string                `db:"file_hash"`
 }
 
 func (s *Synapse) ToDomain(neuron *domain.Neuron, totalTypeSynapses *int, totalCellSynapses *int, synapses *[]domain.SynapseItem) domain.Synapse {
@@ -49,6 +59,12 @@ func (s *Synapse) ToDomain(neuron *domain.Neuron, totalTypeSynapses *int, totalC
 		Color:        s.Color,
 		CellStats:    &domain.CellStats{},
 		SynapseStats: &domain.SynapseStats{},
+		SceneSource:  s.SceneSource,
+		FilenameLOD:  s.FilenameLOD,
+		Bounds:       toBounds(s.Centroid, s.BBoxMin, s.BBoxMax),
+		FilePath:     s.FilePath,
+		FileHash:     s.FileHash,
+		FileURL:      domain.AssetURL(s.FilePath, s.FileHash),
 	}
 
 	if s.SynapseType.Valid {
@@ -105,10 +121,10 @@ func NewPostgresSynapseRepository(db *pgxpool.Pool, c cache.Cache) *PostgresSyna
 }
 
 func (r *PostgresSynapseRepository) GetSynapseByULID(ctx context.Context, id string) (domain.Synapse, error) {
-	query := "SELECT * FROM synapses WHERE ulid = $1"
+	query := "SELECT " + synapseColumns + " FROM synapses WHERE ulid = $1"
 
 	var synapse Synapse
-	err := r.DB.QueryRow(ctx, query, id).Scan(&synapse.ID, &synapse.ULID, &synapse.UID, &synapse.Timepoint, &synapse.SynapseType, &synapse.Filename, &synapse.Color)
+	err := r.DB.QueryRow(ctx, query, id).Scan(&synapse.ID, &synapse.ULID, &synapse.UID, &synapse.Timepoint, &synapse.SynapseType, &synapse.Filename, &synapse.Color, &synapse.SceneSource, &synapse.FilenameLOD, &synapse.Centroid, &synapse.BBoxMin, &synapse.BBoxMax, &synapse.FilePath, &synapse.FileHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Synapse{}, nil
@@ -141,10 +157,10 @@ func (r *PostgresSynapseRepository) GetSynapseByULID(ctx context.Context, id str
 }
 
 func (r *PostgresSynapseRepository) GetSynapseByUID(ctx context.Context, uid string, timepoint int) (domain.Synapse, error) {
-	query := "SELECT * FROM synapses WHERE uid = $1 AND timepoint = $2"
+	query := "SELECT " + synapseColumns + " FROM synapses WHERE uid = $1 AND timepoint = $2"
 
 	var synapse Synapse
-	err := r.DB.QueryRow(ctx, query, uid, timepoint).Scan(&synapse.ID, &synapse.ULID, &synapse.UID, &synapse.Timepoint, &synapse.SynapseType, &synapse.Filename, &synapse.Color)
+	err := r.DB.QueryRow(ctx, query, uid, timepoint).Scan(&synapse.ID, &synapse.ULID, &synapse.UID, &synapse.Timepoint, &synapse.SynapseType, &synapse.Filename, &synapse.Color, &synapse.SceneSource, &synapse.FilenameLOD, &synapse.Centroid, &synapse.BBoxMin, &synapse.BBoxMax, &synapse.FilePath, &synapse.FileHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Synapse{}, nil
@@ -193,7 +209,7 @@ func (r *PostgresSynapseRepository) SynapseExists(ctx context.Context, uid strin
 }
 
 func (r *PostgresSynapseRepository) SearchSynapses(ctx context.Context, query domain.APIV1Request) ([]domain.Synapse, error) {
-	q := "SELECT id, uid, ulid, timepoint, synapse_type, filename, color FROM synapses "
+	q := "SELECT " + synapseColumns + " FROM synapses "
 
 	parsedQuery, args := r.ParseSynapseAPIV1Request(ctx, query)
 
@@ -296,10 +312,10 @@ func (r *PostgresSynapseRepository) SynapseCell(ctx context.Context, synapseUID
 		return domain.Neuron{}, errors.New("invalid cell UID")
 	}
 
-	query := "SELECT * FROM neurons WHERE uid = $1 AND timepoint = $2;"
+	query := "SELECT " + neuronColumns + " FROM neurons WHERE uid = $1 AND timepoint = $2;"
 
 	var neuron Neuron
-	err := r.DB.QueryRow(ctx, query, cellUID, timepoint).Scan(&neuron.ID, &neuron.ULID, &neuron.UID, &neuron.Timepoint, &neuron.Filename, &neuron.Color, &neuron.Volume, &neuron.SurfaceArea)
+	err := r.DB.QueryRow(ctx, query, cellUID, timepoint).Scan(&neuron.ID, &neuron.ULID, &neuron.UID, &neuron.Timepoint, &neuron.Filename, &neuron.Color, &neuron.Volume, &neuron.SurfaceArea, &neuron.SceneSource, &neuron.MeshVolume, &neuron.MeshSurfaceArea, &neuron.Centroid, &neuron.BBoxMin, &neuron.BBoxMax, &neuron.FilenameLOD, &neuron.FilePath, &neuron.FileHash)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Neuron{}, nil
@@ -437,9 +453,11 @@ func (r *PostgresSynapseRepository) CreateSynapse(ctx context.Context, synapse d
 		return fmt.Errorf("synapse already exists")
 	}
 
-	query := "INSERT INTO synapses (uid, ulid, timepoint, synapse_type, filename, color) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING"
+	query := "INSERT INTO synapses (uid, ulid, timepoint, synapse_type, filename, color, scene_source, filename_lod, centroid, bbox_min, bbox_max, file_path, file_hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) ON CONFLICT DO NOTHING"
+
+	centroid, bboxMin, bboxMax := boundsColumns(synapse.Bounds)
 
-	_, err = r.DB.Exec(ctx, query, synapse.UID, synapse.ULID, synapse.Timepoint, synapse.SynapseType, synapse.Filename, synapse.Color)
+	_, err = r.DB.Exec(ctx, query, synapse.UID, synapse.ULID, synapse.Timepoint, synapse.SynapseType, synapse.Filename, synapse.Color, synapse.SceneSource, lodFilenames(synapse.FilenameLOD), centroid, bboxMin, bboxMax, synapse.FilePath, synapse.FileHash)
 	if err != nil {
 		return err
 	}
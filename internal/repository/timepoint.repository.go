@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"neuroscan/internal/cache"
+	"neuroscan/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TimepointRepository interface {
+	TimepointExists(ctx context.Context, timepoint int) (bool, error)
+	SearchTimepoints(ctx context.Context) ([]domain.Timepoint, error)
+	SaveTimepoints(ctx context.Context, timepoints []domain.Timepoint) error
+	IngestTimepoint(ctx context.Context, timepoint domain.Timepoint, skipExisting bool, force bool) (bool, error)
+	MissingTimepoints(ctx context.Context, timepoints []int) ([]int, error)
+	ResetTimepoints(ctx context.Context) error
+}
+
+// timepointColumns are the columns selected into a Timepoint, in struct order
+const timepointColumns = "ulid, timepoint, stage, hours_post_hatch, dataset_source, specimen_id"
+
+type PostgresTimepointRepository struct {
+	cache cache.Cache
+	DB    *pgxpool.Pool
+}
+
+func NewPostgresTimepointRepository(db *pgxpool.Pool, c cache.Cache) *PostgresTimepointRepository {
+	return &PostgresTimepointRepository{
+		cache: c,
+		DB:    db,
+	}
+}
+
+func (r *PostgresTimepointRepository) TimepointExists(ctx context.Context, timepoint int) (bool, error) {
+	query := "SELECT EXISTS(SELECT 1 FROM timepoints WHERE timepoint = $1)"
+
+	var exists bool
+	err := r.DB.QueryRow(ctx, query, timepoint).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *PostgresTimepointRepository) SearchTimepoints(ctx context.Context) ([]domain.Timepoint, error) {
+	query := "SELECT " + timepointColumns + " FROM timepoints ORDER BY timepoint"
+
+	rows, err := r.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	timepoints, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Timepoint])
+	if err != nil {
+		return nil, err
+	}
+
+	return timepoints, nil
+}
+
+// SaveTimepoints creates the timepoints found in an ingest source so the entities referencing
+// them can be inserted. Existing timepoints keep their details, only a missing stage is filled.
+func (r *PostgresTimepointRepository) SaveTimepoints(ctx context.Context, timepoints []domain.Timepoint) error {
+	query := `
+		INSERT INTO timepoints (ulid, timepoint, stage) VALUES ($1, $2, $3)
+		ON CONFLICT (timepoint) DO UPDATE SET stage = excluded.stage WHERE timepoints.stage = ''`
+
+	batch := &pgx.Batch{}
+	for _, timepoint := range timepoints {
+		batch.Queue(query, timepoint.ULID, timepoint.Timepoint, timepoint.Stage)
+	}
+
+	return r.DB.SendBatch(ctx, batch).Close()
+}
+
+// IngestTimepoint stores the details of a timepoint from the timepoints CSV. Timepoints are
+// referenced by every entity so they are updated in place, force has nothing to replace.
+func (r *PostgresTimepointRepository) IngestTimepoint(ctx context.Context, timepoint domain.Timepoint, skipExisting bool, force bool) (bool, error) {
+	if skipExisting {
+		exists, err := r.TimepointExists(ctx, timepoint.Timepoint)
+		if err != nil {
+			return false, err
+		}
+
+		if exists {
+			return true, nil
+		}
+	}
+
+	// an empty stage in the CSV keeps the stage found in the source
+	query := `
+		INSERT INTO timepoints (` + timepointColumns + `) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (timepoint) DO UPDATE SET
+			stage = coalesce(nullif(excluded.stage, ''), timepoints.stage),
+			hours_post_hatch = excluded.hours_post_hatch,
+			dataset_source = excluded.dataset_source,
+			specimen_id = excluded.specimen_id`
+
+	_, err := r.DB.Exec(ctx, query, timepoint.ULID, timepoint.Timepoint, timepoint.Stage, timepoint.HoursPostHatch, timepoint.DatasetSource, timepoint.SpecimenID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MissingTimepoints returns the given timepoints that aren't in the timepoints table, in order
+func (r *PostgresTimepointRepository) MissingTimepoints(ctx context.Context, timepoints []int) ([]int, error) {
+	query := `
+		SELECT DISTINCT t FROM unnest($1::int[]) AS given(t)
+		WHERE NOT EXISTS (SELECT 1 FROM timepoints WHERE timepoint = t)
+		ORDER BY t`
+
+	rows, err := r.DB.Query(ctx, query, timepoints)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// ResetTimepoints clears what the timepoints CSV stored. The rows are referenced by every entity
+// so they can't be truncated, the stage goes back to that of the developmental stage listing the
+// timepoint and the folders of the next ingest fill any stage left empty.
+func (r *PostgresTimepointRepository) ResetTimepoints(ctx context.Context) error {
+	query := `
+		UPDATE timepoints t SET
+			stage = coalesce((SELECT ds.uid FROM developmental_stages ds WHERE t.timepoint = any(ds.timepoints) ORDER BY ds."order" LIMIT 1), ''),
+			hours_post_hatch = null,
+			dataset_source = '',
+			specimen_id = ''`
+
+	_, err := r.DB.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/repository"
+)
+
+type AssetService interface {
+	GetAsset(ctx context.Context, path string) (domain.Asset, error)
+	SaveAsset(ctx context.Context, asset domain.Asset) error
+	TruncateAssets(ctx context.Context) error
+}
+
+type assetService struct {
+	repo repository.AssetRepository
+}
+
+func NewAssetService(repo repository.AssetRepository) AssetService {
+	return &assetService{
+		repo: repo,
+	}
+}
+
+func (s *assetService) GetAsset(ctx context.Context, path string) (domain.Asset, error) {
+	return s.repo.GetAsset(ctx, path)
+}
+
+func (s *assetService) SaveAsset(ctx context.Context, asset domain.Asset) error {
+	return s.repo.SaveAsset(ctx, asset)
+}
+
+func (s *assetService) TruncateAssets(ctx context.Context) error {
+	return s.repo.TruncateAssets(ctx)
+}
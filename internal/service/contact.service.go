@@ -23,6 +23,7 @@ type ContactService interface {
 	TruncateContacts(ctx context.Context) error
 	ParseMeta(ctx context.Context, row []string, timepoint int, dataType string) error
 	ValidContactTimepoints(ctx context.Context) ([]int, error)
+	ContactAreas(ctx context.Context, timepoint int) ([]domain.ContactArea, error)
 }
 
 type contactService struct {
@@ -101,3 +102,7 @@ func (s *contactService) ParseMeta(ctx context.Context, row []string, timepoint
 func (s *contactService) ValidContactTimepoints(ctx context.Context) ([]int, error) {
 	return s.repo.ValidContactTimepoints(ctx)
 }
+
+func (s *contactService) ContactAreas(ctx context.Context, timepoint int) ([]domain.ContactArea, error) {
+	return s.repo.ContactAreas(ctx, timepoint)
+}
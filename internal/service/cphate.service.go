@@ -2,13 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"neuroscan/internal/domain"
 	"neuroscan/internal/repository"
 )
 
+var (
+	// ErrCphateNotFound is returned when no CPHATE was ingested for the timepoint
+	ErrCphateNotFound = errors.New("cphate not found")
+	// ErrCphateNeuronNotFound is returned when a neuron isn't in any cluster of the timepoint's CPHATE
+	ErrCphateNeuronNotFound = errors.New("neuron not found in cphate")
+	// ErrCphateIterationNotFound is returned when a compared timepoint has no such iteration
+	ErrCphateIterationNotFound = errors.New("cphate iteration not found")
+)
+
 type CphateService interface {
 	GetCphateByTimepoint(ctx context.Context, timepoint int) (domain.Cphate, error)
+	GetCphateTree(ctx context.Context, timepoint int) (domain.CphateTree, error)
+	GetNeuronCphate(ctx context.Context, timepoint int, uid string) (domain.NeuronCphate, error)
+	CompareCphates(ctx context.Context, from int, to int, iteration int) (domain.CphateComparison, error)
 	CountCphates(ctx context.Context, timepoint int) (int, error)
 	CphateExists(ctx context.Context, timepoint int) (bool, error)
 	CreateCphate(ctx context.Context, cphate domain.Cphate) error
@@ -30,6 +44,63 @@ func (s *cphateService) GetCphateByTimepoint(ctx context.Context, timepoint int)
 	return s.repo.GetCphateByTimepoint(ctx, timepoint)
 }
 
+// GetCphateTree returns the CPHATE hierarchy of the timepoint nested from the last iteration down
+func (s *cphateService) GetCphateTree(ctx context.Context, timepoint int) (domain.CphateTree, error) {
+	cphate, err := s.repo.GetCphateByTimepoint(ctx, timepoint)
+	if err != nil {
+		return domain.CphateTree{}, err
+	}
+
+	if cphate.ULID == "" {
+		return domain.CphateTree{}, ErrCphateNotFound
+	}
+
+	return cphate.Tree(), nil
+}
+
+// GetNeuronCphate returns the cluster the neuron belongs to at every iteration of the timepoint's CPHATE
+func (s *cphateService) GetNeuronCphate(ctx context.Context, timepoint int, uid string) (domain.NeuronCphate, error) {
+	cphate, err := s.repo.GetCphateByNeuron(ctx, timepoint, uid)
+	if err != nil {
+		return domain.NeuronCphate{}, err
+	}
+
+	if cphate.ULID == "" {
+		return domain.NeuronCphate{}, ErrCphateNotFound
+	}
+
+	membership := cphate.Membership(uid)
+	if len(membership.Iterations) == 0 {
+		return domain.NeuronCphate{}, fmt.Errorf("%w: %s", ErrCphateNeuronNotFound, uid)
+	}
+
+	return membership, nil
+}
+
+// CompareCphates compares the clusterings of two timepoints at the iteration
+func (s *cphateService) CompareCphates(ctx context.Context, from int, to int, iteration int) (domain.CphateComparison, error) {
+	cphates := make([]domain.Cphate, 2)
+	for i, timepoint := range []int{from, to} {
+		cphate, err := s.repo.GetCphateByTimepoint(ctx, timepoint)
+		if err != nil {
+			return domain.CphateComparison{}, err
+		}
+
+		if cphate.ULID == "" {
+			return domain.CphateComparison{}, fmt.Errorf("%w: timepoint %d", ErrCphateNotFound, timepoint)
+		}
+
+		cphates[i] = cphate
+	}
+
+	comparison, ok := domain.CompareCphates(cphates[0], cphates[1], iteration)
+	if !ok {
+		return domain.CphateComparison{}, fmt.Errorf("%w: iteration %d is missing at timepoint %d or %d", ErrCphateIterationNotFound, iteration, from, to)
+	}
+
+	return comparison, nil
+}
+
 func (s *cphateService) CountCphates(ctx context.Context, timepoint int) (int, error) {
 	return s.repo.CountCphates(ctx, timepoint)
 }
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/repository"
+	"neuroscan/pkg/gltf"
+	"neuroscan/pkg/mesh"
+)
+
+var meshContentTypes = map[string]string{
+	mesh.FormatOBJ: "model/obj",
+	mesh.FormatSTL: "model/stl",
+	mesh.FormatPLY: "application/ply",
+}
+
+type MeshService interface {
+	// ExportMesh converts the glTF of a neuron, contact or synapse to format, in micrometers
+	// when the timepoint's scale bar says how long it is.
+	ExportMesh(ctx context.Context, id string, format string) (domain.MeshExport, error)
+}
+
+type meshService struct {
+	neuronRepo  repository.NeuronRepository
+	contactRepo repository.ContactRepository
+	synapseRepo repository.SynapseRepository
+	scaleRepo   repository.ScaleRepository
+	gltfDir     string
+}
+
+func NewMeshService(neuronRepo repository.NeuronRepository, contactRepo repository.ContactRepository, synapseRepo repository.SynapseRepository, scaleRepo repository.ScaleRepository, gltfDir string) MeshService {
+	return &meshService{
+		neuronRepo:  neuronRepo,
+		contactRepo: contactRepo,
+		synapseRepo: synapseRepo,
+		scaleRepo:   scaleRepo,
+		gltfDir:     gltfDir,
+	}
+}
+
+func (s *meshService) ExportMesh(ctx context.Context, id string, format string) (domain.MeshExport, error) {
+	contentType, ok := meshContentTypes[format]
+	if !ok {
+		return domain.MeshExport{}, fmt.Errorf("unknown mesh format %q", format)
+	}
+
+	entity, err := findSceneEntity(ctx, s.neuronRepo, s.contactRepo, s.synapseRepo, id)
+	if err != nil {
+		return domain.MeshExport{}, err
+	}
+
+	fsys := os.DirFS(s.gltfDir)
+	file, err := sceneFile(fsys, entity)
+	if err != nil {
+		return domain.MeshExport{}, err
+	}
+
+	doc, err := gltf.OpenFS(fsys, file)
+	if err != nil {
+		return domain.MeshExport{}, fmt.Errorf("unable to open %s: %w", file, err)
+	}
+
+	if err := gltf.Validate(doc).Err(); err != nil {
+		return domain.MeshExport{}, fmt.Errorf("unable to read %s: %w", file, err)
+	}
+
+	var m *mesh.Mesh
+	if entity.SceneSource != nil {
+		m, err = mesh.FromNode(doc, entity.SceneSource.Node)
+	} else {
+		m, err = mesh.FromDocument(doc)
+	}
+	if err != nil {
+		return domain.MeshExport{}, err
+	}
+
+	units := "file"
+	comment := fmt.Sprintf("%s %s at timepoint %d, in glTF file units", entity.Entity, entity.UID, entity.Timepoint)
+
+	scales, err := s.scaleRepo.GetScaleByTimepoint(ctx, entity.Timepoint)
+	if err != nil {
+		return domain.MeshExport{}, err
+	}
+	for _, scale := range scales {
+		if factor, ok := scale.MicrometersPerUnit(); ok {
+			m = m.Scaled(factor)
+			units = "um"
+			comment = fmt.Sprintf("%s %s at timepoint %d, in micrometers", entity.Entity, entity.UID, entity.Timepoint)
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf, format, entity.UID, comment); err != nil {
+		return domain.MeshExport{}, err
+	}
+
+	return domain.MeshExport{
+		Filename:    fmt.Sprintf("%s_%d.%s", entity.UID, entity.Timepoint, format),
+		ContentType: contentType,
+		Units:       units,
+		Data:        buf.Bytes(),
+	}, nil
+}
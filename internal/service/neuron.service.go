@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -23,6 +24,8 @@ type NeuronService interface {
 	TruncateNeurons(ctx context.Context) error
 	ParseMeta(ctx context.Context, row []string, timepoint int, dataType string) error
 	ValidNeuronTimepoints(ctx context.Context) ([]int, error)
+	MeshDiscrepancies(ctx context.Context, threshold float64) ([]domain.MeshDiscrepancy, error)
+	NeuronUIDs(ctx context.Context, timepoint int) ([]string, error)
 }
 
 type neuronService struct {
@@ -36,11 +39,27 @@ func NewNeuronService(repo repository.NeuronRepository) NeuronService {
 }
 
 func (s *neuronService) GetNeuronByULID(ctx context.Context, id string) (domain.Neuron, error) {
-	return s.repo.GetNeuronByULID(ctx, id)
+	neuron, err := s.repo.GetNeuronByULID(ctx, id)
+	if err != nil {
+		return domain.Neuron{}, err
+	}
+
+	neurons := []domain.Neuron{neuron}
+	err = s.addExpressedPromoters(ctx, neurons)
+
+	return neurons[0], err
 }
 
 func (s *neuronService) GetNeuronByUID(ctx context.Context, uid string, timepoint int) (domain.Neuron, error) {
-	return s.repo.GetNeuronByUID(ctx, uid, timepoint)
+	neuron, err := s.repo.GetNeuronByUID(ctx, uid, timepoint)
+	if err != nil {
+		return domain.Neuron{}, err
+	}
+
+	neurons := []domain.Neuron{neuron}
+	err = s.addExpressedPromoters(ctx, neurons)
+
+	return neurons[0], err
 }
 
 func (s *neuronService) NeuronExists(ctx context.Context, uid string, timepoint int) (bool, error) {
@@ -48,7 +67,41 @@ func (s *neuronService) NeuronExists(ctx context.Context, uid string, timepoint
 }
 
 func (s *neuronService) SearchNeurons(ctx context.Context, query domain.APIV1Request) ([]domain.Neuron, error) {
-	return s.repo.SearchNeurons(ctx, query)
+	neurons, err := s.repo.SearchNeurons(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return neurons, s.addExpressedPromoters(ctx, neurons)
+}
+
+// addExpressedPromoters resolves the promoters expressed in each neuron, a neuron that wasn't
+// found keeps none
+func (s *neuronService) addExpressedPromoters(ctx context.Context, neurons []domain.Neuron) error {
+	var uids []string
+	for _, neuron := range neurons {
+		if neuron.UID != "" && !slices.Contains(uids, neuron.UID) {
+			uids = append(uids, neuron.UID)
+		}
+	}
+
+	if len(uids) == 0 {
+		return nil
+	}
+
+	promoters, err := s.repo.ExpressedPromoters(ctx, uids)
+	if err != nil {
+		return err
+	}
+
+	for i := range neurons {
+		neurons[i].ExpressedPromoters = promoters[neurons[i].UID]
+		if neurons[i].ExpressedPromoters == nil {
+			neurons[i].ExpressedPromoters = []string{}
+		}
+	}
+
+	return nil
 }
 
 func (s *neuronService) CountNeurons(ctx context.Context, query domain.APIV1Request) (int, error) {
@@ -109,3 +162,11 @@ func (s *neuronService) ParseMeta(ctx context.Context, row []string, timepoint i
 func (s *neuronService) ValidNeuronTimepoints(ctx context.Context) ([]int, error) {
 	return s.repo.ValidNeuronTimepoints(ctx)
 }
+
+func (s *neuronService) MeshDiscrepancies(ctx context.Context, threshold float64) ([]domain.MeshDiscrepancy, error) {
+	return s.repo.MeshDiscrepancies(ctx, threshold)
+}
+
+func (s *neuronService) NeuronUIDs(ctx context.Context, timepoint int) ([]string, error) {
+	return s.repo.NeuronUIDs(ctx, timepoint)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"neuroscan/internal/domain"
 	"neuroscan/internal/repository"
@@ -15,8 +16,12 @@ type PromoterService interface {
 	CreatePromoter(ctx context.Context, promoter domain.Promoter) error
 	IngestPromoter(ctx context.Context, promoter domain.Promoter, skipExisting bool, force bool) (bool, error)
 	TruncatePromoters(ctx context.Context) error
+	GetPromoterNeurons(ctx context.Context, uid string, timepoint *int) ([]domain.Neuron, error)
 }
 
+// ErrPromoterNotFound is returned when no promoter has the uid
+var ErrPromoterNotFound = errors.New("promoter not found")
+
 type promoterService struct {
 	repo repository.PromoterRepository
 }
@@ -54,3 +59,17 @@ func (s *promoterService) IngestPromoter(ctx context.Context, promoter domain.Pr
 func (s *promoterService) TruncatePromoters(ctx context.Context) error {
 	return s.repo.TruncatePromoters(ctx)
 }
+
+// GetPromoterNeurons returns the neurons whose uid matches a cell the promoter is expressed in
+func (s *promoterService) GetPromoterNeurons(ctx context.Context, uid string, timepoint *int) ([]domain.Neuron, error) {
+	exists, err := s.repo.PromoterExists(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrPromoterNotFound
+	}
+
+	return s.repo.GetPromoterNeurons(ctx, uid, timepoint)
+}
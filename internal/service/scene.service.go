@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/repository"
+	"neuroscan/pkg/gltf"
+	"neuroscan/pkg/gltf/compose"
+	"neuroscan/pkg/logging"
+)
+
+// DefaultSceneCacheSize is how many bytes of composed scenes are kept when no size is configured.
+const DefaultSceneCacheSize int64 = 2 << 30
+
+var (
+	// ErrSceneEntityNotFound is returned when no neuron, contact or synapse has the requested ULID.
+	ErrSceneEntityNotFound = errors.New("scene entity not found")
+	// ErrSceneFileNotFound is returned when an entity's glTF file isn't where ingest recorded it under the glTF directory.
+	ErrSceneFileNotFound = errors.New("scene file not found")
+)
+
+type SceneService interface {
+	// ComposeScene merges the requested entities into one GLB and returns its path on disk
+	// along with the content hash it is cached under. The same set of ids in any order
+	// makes the same scene.
+	ComposeScene(ctx context.Context, req domain.SceneRequest) (string, string, error)
+}
+
+type sceneService struct {
+	neuronRepo  repository.NeuronRepository
+	contactRepo repository.ContactRepository
+	synapseRepo repository.SynapseRepository
+	gltfDir     string
+	cacheDir    string
+	// cacheSize caps the bytes of scenes kept in cacheDir, the least recently served go first
+	cacheSize int64
+}
+
+func NewSceneService(neuronRepo repository.NeuronRepository, contactRepo repository.ContactRepository, synapseRepo repository.SynapseRepository, gltfDir string, cacheDir string, cacheSize int64) SceneService {
+	return &sceneService{
+		neuronRepo:  neuronRepo,
+		contactRepo: contactRepo,
+		synapseRepo: synapseRepo,
+		gltfDir:     gltfDir,
+		cacheDir:    cacheDir,
+		cacheSize:   cacheSize,
+	}
+}
+
+func (s *sceneService) ComposeScene(ctx context.Context, req domain.SceneRequest) (string, string, error) {
+	// sorted so that every ordering of the same ids is cached as one file
+	ids := slices.Clone(req.IDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	entities := make([]domain.SceneEntity, 0, len(ids))
+	for _, id := range ids {
+		entity, err := findSceneEntity(ctx, s.neuronRepo, s.contactRepo, s.synapseRepo, id)
+		if err != nil {
+			return "", "", err
+		}
+		entities = append(entities, entity)
+	}
+
+	fsys := os.DirFS(s.gltfDir)
+	files := make([]string, len(entities))
+	hash := sha256.New()
+	for i, entity := range entities {
+		file, err := sceneFile(fsys, entity)
+		if err != nil {
+			return "", "", err
+		}
+		files[i] = file
+
+		info, err := fs.Stat(fsys, file)
+		if err != nil {
+			return "", "", err
+		}
+
+		// the ULID changes on every ingest and the file stat on every change to the mesh,
+		// so the hash covers everything that ends up in the composed scene
+		fmt.Fprintf(hash, "%s|%s|%v|%s|%d|%d\n", entity.ULID, entity.UID, entity.Color, file, info.Size(), info.ModTime().UnixNano())
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	out := filepath.Join(s.cacheDir, sum+".glb")
+	if _, err := os.Stat(out); err == nil {
+		// the modification time tells pruneCache which scenes were served last
+		now := time.Now()
+		_ = os.Chtimes(out, now, now)
+		return out, sum, nil
+	}
+
+	parts := make([]compose.Part, len(entities))
+	docs := map[string]*gltf.Document{}
+	for i, entity := range entities {
+		doc, ok := docs[files[i]]
+		if !ok {
+			var err error
+			doc, err = gltf.OpenFS(fsys, files[i])
+			if err != nil {
+				return "", "", fmt.Errorf("unable to open %s: %w", files[i], err)
+			}
+			if err := gltf.Validate(doc).Err(); err != nil {
+				return "", "", fmt.Errorf("unable to read %s: %w", files[i], err)
+			}
+			docs[files[i]] = doc
+		}
+
+		parts[i] = compose.Part{
+			Name:  entity.ULID,
+			Color: [4]float64(entity.Color),
+			Extras: map[string]any{
+				"uid":       entity.UID,
+				"entity":    entity.Entity,
+				"timepoint": entity.Timepoint,
+			},
+			Doc: doc,
+		}
+		if entity.SceneSource != nil {
+			parts[i].Node = gltf.Index(entity.SceneSource.Node)
+		}
+	}
+
+	doc, err := compose.Scene(parts)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(s.cacheDir, 0o755); err != nil {
+		return "", "", err
+	}
+
+	// write next to the final path and rename so concurrent requests never serve a partial file
+	tmp, err := os.CreateTemp(s.cacheDir, sum+"-*.tmp")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gltf.NewEncoder(tmp).Encode(doc); err != nil {
+		tmp.Close()
+		return "", "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", "", err
+	}
+	if err := os.Rename(tmp.Name(), out); err != nil {
+		return "", "", err
+	}
+
+	if err := s.pruneCache(out); err != nil {
+		logging.FromContext(ctx).Warn().Err(err).Str("dir", s.cacheDir).Msg("Unable to prune the scene cache")
+	}
+
+	return out, sum, nil
+}
+
+// pruneCache removes the least recently served scenes until the cache fits cacheSize. The scene
+// just written is kept even when it alone is larger.
+func (s *sceneService) pruneCache(keep string) error {
+	entries, err := os.ReadDir(s.cacheDir)
+	if err != nil {
+		return err
+	}
+
+	var scenes []fs.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".glb" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// removed by a concurrent prune
+			continue
+		}
+		scenes = append(scenes, info)
+		total += info.Size()
+	}
+
+	slices.SortFunc(scenes, func(a, b fs.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+
+	for _, scene := range scenes {
+		if total <= s.cacheSize {
+			break
+		}
+		file := filepath.Join(s.cacheDir, scene.Name())
+		if file == keep {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= scene.Size()
+	}
+
+	return nil
+}
+
+// findSceneEntity looks up a neuron, contact or synapse by the prefix of its ULID.
+func findSceneEntity(ctx context.Context, neuronRepo repository.NeuronRepository, contactRepo repository.ContactRepository, synapseRepo repository.SynapseRepository, id string) (domain.SceneEntity, error) {
+	entity, err := getSceneEntity(ctx, neuronRepo, contactRepo, synapseRepo, id)
+	if err != nil {
+		return domain.SceneEntity{}, err
+	}
+
+	// the repositories return an empty entity when the ULID doesn't exist
+	if entity.ULID == "" {
+		return domain.SceneEntity{}, fmt.Errorf("%w: %s", ErrSceneEntityNotFound, id)
+	}
+
+	return entity, nil
+}
+
+func getSceneEntity(ctx context.Context, neuronRepo repository.NeuronRepository, contactRepo repository.ContactRepository, synapseRepo repository.SynapseRepository, id string) (domain.SceneEntity, error) {
+	entityType, _ := domain.SceneEntityType(id)
+
+	switch entityType {
+	case "neurons":
+		neuron, err := neuronRepo.GetNeuronByULID(ctx, id)
+		if err != nil {
+			return domain.SceneEntity{}, fmt.Errorf("unable to find neuron %s: %w", id, err)
+		}
+		return neuron.SceneEntity(), nil
+	case "contacts":
+		contact, err := contactRepo.GetContactByULID(ctx, id)
+		if err != nil {
+			return domain.SceneEntity{}, fmt.Errorf("unable to find contact %s: %w", id, err)
+		}
+		return contact.SceneEntity(), nil
+	case "synapses":
+		synapse, err := synapseRepo.GetSynapseByULID(ctx, id)
+		if err != nil {
+			return domain.SceneEntity{}, fmt.Errorf("unable to find synapse %s: %w", id, err)
+		}
+		return synapse.SceneEntity(), nil
+	}
+
+	return domain.SceneEntity{}, fmt.Errorf("%q is not a neuron, contact or synapse id", id)
+}
+
+// sceneFile returns the path of the entity's file under the glTF directory, as recorded on ingest.
+func sceneFile(fsys fs.FS, entity domain.SceneEntity) (string, error) {
+	if entity.FilePath == "" {
+		return "", fmt.Errorf("%w: %s %s has no recorded path, ingest it again", ErrSceneFileNotFound, entity.Entity, entity.ULID)
+	}
+
+	if _, err := fs.Stat(fsys, entity.FilePath); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrSceneFileNotFound, entity.FilePath)
+	}
+
+	return entity.FilePath, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/repository"
+)
+
+var (
+	// ErrSpatialEntityNotFound is returned when the entity a query is relative to doesn't exist or has no bounds.
+	ErrSpatialEntityNotFound = errors.New("spatial entity not found")
+	// ErrSpatialQueryInvalid is returned when a query names neither coordinates nor an entity.
+	ErrSpatialQueryInvalid = errors.New("invalid spatial query")
+)
+
+type SpatialService interface {
+	// WithinBox returns the entities inside the min/max region, or inside the bounding box of req.Entity.
+	WithinBox(ctx context.Context, req domain.SpatialRequest) ([]domain.SpatialEntity, error)
+	// Nearest returns the k entities closest to req.Point, or to the centroid of req.Entity.
+	Nearest(ctx context.Context, req domain.SpatialRequest) ([]domain.SpatialEntity, error)
+}
+
+type spatialService struct {
+	repo repository.SpatialRepository
+}
+
+func NewSpatialService(repo repository.SpatialRepository) SpatialService {
+	return &spatialService{
+		repo: repo,
+	}
+}
+
+func (s *spatialService) WithinBox(ctx context.Context, req domain.SpatialRequest) ([]domain.SpatialEntity, error) {
+	timepoint, reference, err := s.reference(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	box := req.Region
+	if box == nil {
+		if reference == nil {
+			return nil, fmt.Errorf("%w: min and max or entity are required", ErrSpatialQueryInvalid)
+		}
+		box = &reference.BoundingBox
+	}
+
+	return s.repo.WithinBox(ctx, timepoint, req.Types, *box, req.Overlap)
+}
+
+func (s *spatialService) Nearest(ctx context.Context, req domain.SpatialRequest) ([]domain.SpatialEntity, error) {
+	timepoint, reference, err := s.reference(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	point := req.Center
+	exclude := ""
+	if point == nil {
+		if reference == nil {
+			return nil, fmt.Errorf("%w: point or entity is required", ErrSpatialQueryInvalid)
+		}
+		point = &reference.Centroid
+		exclude = reference.ULID
+	}
+
+	return s.repo.Nearest(ctx, timepoint, req.Types, *point, req.K, exclude)
+}
+
+// reference looks up the entity the query is relative to, its timepoint is used when none was asked for
+func (s *spatialService) reference(ctx context.Context, req domain.SpatialRequest) (int, *domain.SpatialEntity, error) {
+	if req.Entity == "" {
+		return *req.Timepoint, nil, nil
+	}
+
+	entity, err := s.repo.GetSpatialEntity(ctx, req.Entity)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if entity.ULID == "" {
+		return 0, nil, fmt.Errorf("%w: %s", ErrSpatialEntityNotFound, req.Entity)
+	}
+
+	if req.Timepoint != nil {
+		return *req.Timepoint, &entity, nil
+	}
+
+	return entity.Timepoint, &entity, nil
+}
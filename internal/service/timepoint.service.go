@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"neuroscan/internal/domain"
+	"neuroscan/internal/repository"
+)
+
+type TimepointService interface {
+	TimepointExists(ctx context.Context, timepoint int) (bool, error)
+	SearchTimepoints(ctx context.Context) ([]domain.Timepoint, error)
+	SaveTimepoints(ctx context.Context, timepoints []domain.Timepoint) error
+	IngestTimepoint(ctx context.Context, timepoint domain.Timepoint, skipExisting bool, force bool) (bool, error)
+	GetTimeline(ctx context.Context) (domain.Timeline, error)
+	MissingTimepoints(ctx context.Context, timepoints []int) ([]int, error)
+	ResetTimepoints(ctx context.Context) error
+}
+
+type timepointService struct {
+	repo         repository.TimepointRepository
+	devStageRepo repository.DevelopmentalStageRepository
+}
+
+func NewTimepointService(repo repository.TimepointRepository, devStageRepo repository.DevelopmentalStageRepository) TimepointService {
+	return &timepointService{
+		repo:         repo,
+		devStageRepo: devStageRepo,
+	}
+}
+
+func (s *timepointService) TimepointExists(ctx context.Context, timepoint int) (bool, error) {
+	return s.repo.TimepointExists(ctx, timepoint)
+}
+
+func (s *timepointService) SearchTimepoints(ctx context.Context) ([]domain.Timepoint, error) {
+	return s.repo.SearchTimepoints(ctx)
+}
+
+func (s *timepointService) SaveTimepoints(ctx context.Context, timepoints []domain.Timepoint) error {
+	return s.repo.SaveTimepoints(ctx, timepoints)
+}
+
+func (s *timepointService) IngestTimepoint(ctx context.Context, timepoint domain.Timepoint, skipExisting bool, force bool) (bool, error) {
+	return s.repo.IngestTimepoint(ctx, timepoint, skipExisting, force)
+}
+
+func (s *timepointService) MissingTimepoints(ctx context.Context, timepoints []int) ([]int, error) {
+	return s.repo.MissingTimepoints(ctx, timepoints)
+}
+
+func (s *timepointService) ResetTimepoints(ctx context.Context) error {
+	return s.repo.ResetTimepoints(ctx)
+}
+
+// GetTimeline places the timepoints on the developmental axis, ordered by the developmental stages
+func (s *timepointService) GetTimeline(ctx context.Context) (domain.Timeline, error) {
+	timepoints, err := s.repo.SearchTimepoints(ctx)
+	if err != nil {
+		return domain.Timeline{}, err
+	}
+
+	devStages, err := s.devStageRepo.SearchDevelopmentalStages(ctx, domain.APIV1Request{})
+	if err != nil {
+		return domain.Timeline{}, err
+	}
+
+	return domain.NewTimeline(timepoints, devStages), nil
+}
@@ -0,0 +1,192 @@
+package toolshed
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CSVKind is the type the values of a column must parse as
+type CSVKind string
+
+const (
+	CSVString CSVKind = "string"
+	CSVInt    CSVKind = "int"
+	CSVFloat  CSVKind = "float"
+	CSVBool   CSVKind = "bool"
+)
+
+// CSVColumn declares a column of a CSV file. Columns are matched by their header, ignoring
+// case, spaces, dashes and underscores, so timepoint_start, Timepoint Start and timepointStart
+// are the same column.
+type CSVColumn struct {
+	Name     string
+	Aliases  []string
+	Kind     CSVKind
+	Required bool
+}
+
+// CSVSchema is the set of columns a CSV file may have, in any order
+type CSVSchema []CSVColumn
+
+// CSVHeader maps the columns of a schema to their position in a file
+type CSVHeader struct {
+	schema  CSVSchema
+	index   map[string]int
+	columns int
+	// Missing lists the optional columns the file doesn't have, they read as empty
+	Missing []string
+}
+
+// ParseHeader matches the header row of a file against the schema. Unknown, repeated and
+// missing required columns are all reported in the error.
+func (s CSVSchema) ParseHeader(header []string) (CSVHeader, error) {
+	h := CSVHeader{
+		schema:  s,
+		index:   map[string]int{},
+		columns: len(header),
+	}
+
+	var errs []error
+	for i, name := range header {
+		// the first header of files saved by Excel starts with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+
+		column, ok := s.column(name)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown column %q", name))
+			continue
+		}
+
+		if _, ok := h.index[column.Name]; ok {
+			errs = append(errs, fmt.Errorf("column %q is repeated", column.Name))
+			continue
+		}
+
+		h.index[column.Name] = i
+	}
+
+	for _, column := range s {
+		if _, ok := h.index[column.Name]; ok {
+			continue
+		}
+
+		if column.Required {
+			errs = append(errs, fmt.Errorf("missing column %q", column.Name))
+		} else {
+			h.Missing = append(h.Missing, column.Name)
+		}
+	}
+
+	if len(errs) > 0 {
+		return CSVHeader{}, errors.Join(errs...)
+	}
+
+	return h, nil
+}
+
+func (s CSVSchema) column(header string) (CSVColumn, bool) {
+	key := csvKey(header)
+
+	for _, column := range s {
+		if csvKey(column.Name) == key || slices.ContainsFunc(column.Aliases, func(alias string) bool { return csvKey(alias) == key }) {
+			return column, true
+		}
+	}
+
+	return CSVColumn{}, false
+}
+
+// csvKey keeps only the letters and digits of a header, lowercased
+func csvKey(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, header)
+}
+
+// Record checks a row against the header: it must have as many values as the header has
+// columns, required columns can't be empty and every value must parse as its column's kind.
+func (h CSVHeader) Record(row []string) (CSVRecord, error) {
+	if len(row) != h.columns {
+		return CSVRecord{}, fmt.Errorf("expected %d columns, got %d", h.columns, len(row))
+	}
+
+	var errs []error
+	for _, column := range h.schema {
+		i, ok := h.index[column.Name]
+		if !ok {
+			continue
+		}
+
+		value := strings.TrimSpace(row[i])
+		if value == "" {
+			if column.Required {
+				errs = append(errs, fmt.Errorf("%s is required", column.Name))
+			}
+			continue
+		}
+
+		var err error
+		switch column.Kind {
+		case CSVInt:
+			_, err = strconv.Atoi(value)
+		case CSVFloat:
+			_, err = strconv.ParseFloat(value, 64)
+		case CSVBool:
+			_, err = strconv.ParseBool(value)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %q is not a valid %s", column.Name, value, column.Kind))
+		}
+	}
+
+	if len(errs) > 0 {
+		return CSVRecord{}, errors.Join(errs...)
+	}
+
+	return CSVRecord{header: h, row: row}, nil
+}
+
+// CSVRecord is a row checked by CSVHeader.Record, its values are read by column name
+type CSVRecord struct {
+	header CSVHeader
+	row    []string
+}
+
+// String returns the trimmed value of a column, empty when the file doesn't have it
+func (r CSVRecord) String(name string) string {
+	i, ok := r.header.index[name]
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(r.row[i])
+}
+
+// Int returns the value of an int column, 0 when it is empty
+func (r CSVRecord) Int(name string) int {
+	value, _ := strconv.Atoi(r.String(name))
+	return value
+}
+
+// Float returns the value of a float column, nil when it is empty
+func (r CSVRecord) Float(name string) *float64 {
+	value, err := strconv.ParseFloat(r.String(name), 64)
+	if err != nil {
+		return nil
+	}
+
+	return &value
+}
+
+// Bool returns the value of a bool column, false when it is empty
+func (r CSVRecord) Bool(name string) bool {
+	value, _ := strconv.ParseBool(r.String(name))
+	return value
+}
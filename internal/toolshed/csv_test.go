@@ -0,0 +1,73 @@
+package toolshed
+
+import (
+	"strings"
+	"testing"
+)
+
+var testCSVSchema = CSVSchema{
+	{Name: "uid", Required: true},
+	{Name: "timepoint_start", Kind: CSVInt},
+	{Name: "other_cells"},
+}
+
+func TestCSVSchemaParseHeader(t *testing.T) {
+	t.Parallel()
+
+	header, err := testCSVSchema.ParseHeader([]string{"\ufeffTimepoint Start", "UID"})
+	if err != nil {
+		t.Fatalf("Expected header to parse, got %v", err)
+	}
+
+	if len(header.Missing) != 1 || header.Missing[0] != "other_cells" {
+		t.Errorf("Expected other_cells to be missing, got %v", header.Missing)
+	}
+
+	record, err := header.Record([]string{" 12 ", "unc-4"})
+	if err != nil {
+		t.Fatalf("Expected row to be valid, got %v", err)
+	}
+
+	if record.String("uid") != "unc-4" || record.Int("timepoint_start") != 12 || record.String("other_cells") != "" {
+		t.Errorf("Expected unc-4 from 12, got %s from %d", record.String("uid"), record.Int("timepoint_start"))
+	}
+}
+
+func TestCSVSchemaParseHeaderInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := testCSVSchema.ParseHeader([]string{"timepointStart", "colour", "timepoint_start"})
+	if err == nil {
+		t.Fatal("Expected header to be rejected")
+	}
+
+	for _, expected := range []string{`unknown column "colour"`, `column "timepoint_start" is repeated`, `missing column "uid"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %s in %v", expected, err)
+		}
+	}
+}
+
+func TestCSVHeaderRecordInvalid(t *testing.T) {
+	t.Parallel()
+
+	header, err := testCSVSchema.ParseHeader([]string{"uid", "timepoint_start", "other_cells"})
+	if err != nil {
+		t.Fatalf("Expected header to parse, got %v", err)
+	}
+
+	if _, err := header.Record([]string{"unc-4", "12"}); err == nil {
+		t.Error("Expected a short row to be rejected")
+	}
+
+	_, err = header.Record([]string{"", "twelve", "AIYL"})
+	if err == nil {
+		t.Fatal("Expected row to be rejected")
+	}
+
+	for _, expected := range []string{"uid is required", `timepoint_start "twelve" is not a valid int`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %s in %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package toolshed
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EntityTypes are the entity types ingest knows how to process.
+var EntityTypes = []string{"neurons", "contacts", "synapses", "cphate", "nerveRing", "scale", "promoters", "dev_stages", "meta", "scene", "timepoints"}
+
+// Template placeholders. Each placeholder matches exactly one path component,
+// except "**" which matches zero or more components.
+const (
+	layoutStage     = "{stage}"
+	layoutTimepoint = "{timepoint}"
+	layoutEntity    = "{entity}"
+	layoutFile      = "{file}"
+	layoutAny       = "*"
+	layoutAnyDepth  = "**"
+)
+
+// Layout describes how paths in an ingest source map to developmental stages,
+// timepoints and entity types. Templates are tried in order and the first one
+// matching the whole path wins.
+type Layout struct {
+	Templates    []string            `yaml:"templates"`
+	Stages       []string            `yaml:"stages"`
+	StageAliases map[string]string   `yaml:"stage_aliases"`
+	Entities     map[string][]string `yaml:"entities"`
+
+	templates      [][]string
+	entityByFolder map[string]string
+	stageByFolder  map[string]string
+}
+
+// PathInfo is the context resolved from a path by a Layout.
+type PathInfo struct {
+	Stage     string
+	Timepoint *int
+	Entity    string
+	// EntityDir is the path up to and including the entity folder.
+	EntityDir string
+}
+
+// DefaultLayout returns the layout of the NeuroSC release tree, <STAGE>/<TIMEPOINT>/<ENTITY>/<FILE>,
+// along with entity folders that sit outside of a stage such as promoters and dev_stages.
+func DefaultLayout() *Layout {
+	layout := &Layout{
+		Templates: []string{
+			"**/{stage}/{timepoint}/{entity}/**",
+			"**/{entity}/**",
+		},
+		Stages:   []string{"L1", "L2", "L3", "L4", "Adult"},
+		Entities: map[string][]string{},
+	}
+
+	for _, entity := range EntityTypes {
+		layout.Entities[entity] = []string{entity}
+	}
+
+	if err := layout.compile(); err != nil {
+		panic(err)
+	}
+
+	return layout
+}
+
+// LoadLayout reads a YAML layout file. Any section left out of the file falls
+// back to the default layout, entity folders are merged over the defaults.
+func LoadLayout(path string) (*Layout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read layout file: %w", err)
+	}
+
+	return ParseLayout(data)
+}
+
+// ParseLayout parses a YAML layout definition, see LoadLayout.
+func ParseLayout(data []byte) (*Layout, error) {
+	var layout Layout
+
+	if err := yaml.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("unable to parse layout: %w", err)
+	}
+
+	defaults := DefaultLayout()
+
+	if len(layout.Templates) == 0 {
+		layout.Templates = defaults.Templates
+	}
+
+	if len(layout.Stages) == 0 {
+		layout.Stages = defaults.Stages
+	}
+
+	entities := defaults.Entities
+	for entity, folders := range layout.Entities {
+		entities[entity] = folders
+	}
+	layout.Entities = entities
+
+	if err := layout.compile(); err != nil {
+		return nil, err
+	}
+
+	return &layout, nil
+}
+
+func (l *Layout) compile() error {
+	l.templates = nil
+	l.entityByFolder = map[string]string{}
+	l.stageByFolder = map[string]string{}
+
+	for _, template := range l.Templates {
+		parts := strings.Split(strings.Trim(template, "/"), "/")
+
+		if !slices.Contains(parts, layoutEntity) {
+			return fmt.Errorf("layout template %q has no %s placeholder", template, layoutEntity)
+		}
+
+		l.templates = append(l.templates, parts)
+	}
+
+	for entity, folders := range l.Entities {
+		if !slices.Contains(EntityTypes, entity) {
+			return fmt.Errorf("layout references unknown entity type %q", entity)
+		}
+
+		for _, folder := range folders {
+			if existing, ok := l.entityByFolder[folder]; ok && existing != entity {
+				return fmt.Errorf("layout folder %q is mapped to both %s and %s", folder, existing, entity)
+			}
+
+			l.entityByFolder[folder] = entity
+		}
+	}
+
+	for _, stage := range l.Stages {
+		l.stageByFolder[stage] = stage
+	}
+
+	for alias, stage := range l.StageAliases {
+		if !slices.Contains(l.Stages, stage) {
+			return fmt.Errorf("layout stage alias %q points at unknown stage %q", alias, stage)
+		}
+
+		l.stageByFolder[alias] = stage
+	}
+
+	return nil
+}
+
+// Resolve matches the path against the layout templates and returns the context it describes.
+func (l *Layout) Resolve(path string) (PathInfo, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for _, template := range l.templates {
+		info := PathInfo{}
+		if l.match(template, parts, 0, &info) {
+			return info, nil
+		}
+	}
+
+	return PathInfo{}, fmt.Errorf("path %q does not match the layout", path)
+}
+
+// match reports whether parts[i:] matches template, recording placeholders into info.
+func (l *Layout) match(template []string, parts []string, i int, info *PathInfo) bool {
+	if len(template) == 0 {
+		return i == len(parts)
+	}
+
+	if template[0] == layoutAnyDepth {
+		// prefer consuming as little as possible so later placeholders bind to the nearest components
+		for next := i; next <= len(parts); next++ {
+			candidate := *info
+			if l.match(template[1:], parts, next, &candidate) {
+				*info = candidate
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if i >= len(parts) {
+		return false
+	}
+
+	part := parts[i]
+	candidate := *info
+
+	switch template[0] {
+	case layoutStage:
+		stage, ok := l.stageByFolder[part]
+		if !ok {
+			return false
+		}
+		candidate.Stage = stage
+	case layoutTimepoint:
+		timepoint, err := strconv.Atoi(part)
+		if err != nil {
+			return false
+		}
+		candidate.Timepoint = &timepoint
+	case layoutEntity:
+		entity, ok := l.entityByFolder[part]
+		if !ok {
+			return false
+		}
+		candidate.Entity = entity
+		candidate.EntityDir = strings.Join(parts[:i+1], "/")
+	case layoutFile, layoutAny:
+	default:
+		if template[0] != part {
+			return false
+		}
+	}
+
+	if !l.match(template[1:], parts, i+1, &candidate) {
+		return false
+	}
+
+	*info = candidate
+
+	return true
+}
+
+// Timepoint returns the timepoint the path belongs to.
+func (l *Layout) Timepoint(path string) (int, error) {
+	info, err := l.Resolve(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if info.Timepoint == nil {
+		return 0, errors.New("timepoint not found in path")
+	}
+
+	return *info.Timepoint, nil
+}
+
+// DevStage returns the developmental stage the path belongs to.
+func (l *Layout) DevStage(path string) (string, error) {
+	info, err := l.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	if info.Stage == "" {
+		return "", errors.New("development stage not found in path")
+	}
+
+	return info.Stage, nil
+}
+
+// EntityType returns the entity type of the path.
+func (l *Layout) EntityType(path string) (string, error) {
+	info, err := l.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	return info.Entity, nil
+}
+
+// EntityFromName returns the entity type a folder or scene group name is mapped to.
+func (l *Layout) EntityFromName(name string) (string, bool) {
+	entity, ok := l.entityByFolder[name]
+	return entity, ok
+}
@@ -0,0 +1,104 @@
+package toolshed
+
+import (
+	"testing"
+)
+
+func TestDefaultLayoutResolve(t *testing.T) {
+	t.Parallel()
+
+	layout := DefaultLayout()
+
+	// a numeric parent directory must not be mistaken for the timepoint
+	info, err := layout.Resolve("2025/release/L2/48/contacts/ADALbyAIYL.gltf")
+	if err != nil {
+		t.Fatalf("Expected path to resolve, got %v", err)
+	}
+
+	if info.Stage != "L2" {
+		t.Errorf("Expected stage to be L2, got %s", info.Stage)
+	}
+
+	if info.Timepoint == nil || *info.Timepoint != 48 {
+		t.Errorf("Expected timepoint to be 48, got %v", info.Timepoint)
+	}
+
+	if info.Entity != "contacts" {
+		t.Errorf("Expected entity to be contacts, got %s", info.Entity)
+	}
+
+	if info.EntityDir != "2025/release/L2/48/contacts" {
+		t.Errorf("Expected entity dir to be 2025/release/L2/48/contacts, got %s", info.EntityDir)
+	}
+
+	info, err = layout.Resolve("promoters/promoters.csv")
+	if err != nil {
+		t.Fatalf("Expected path to resolve, got %v", err)
+	}
+
+	if info.Entity != "promoters" || info.Timepoint != nil {
+		t.Errorf("Expected promoters without a timepoint, got %+v", info)
+	}
+
+	if _, err := layout.Resolve("L1/23/unknown/ADAL.gltf"); err == nil {
+		t.Error("Expected unknown entity folder to fail")
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	t.Parallel()
+
+	layout, err := ParseLayout([]byte(`
+templates:
+  - "{stage}/{timepoint}/{entity}/{file}"
+stages: [Embryo, L1]
+stage_aliases:
+  embryo: Embryo
+entities:
+  neurons: [cells]
+`))
+	if err != nil {
+		t.Fatalf("Expected layout to parse, got %v", err)
+	}
+
+	info, err := layout.Resolve("embryo/330/cells/ADAL.gltf")
+	if err != nil {
+		t.Fatalf("Expected path to resolve, got %v", err)
+	}
+
+	if info.Stage != "Embryo" {
+		t.Errorf("Expected stage alias to resolve to Embryo, got %s", info.Stage)
+	}
+
+	if info.Timepoint == nil || *info.Timepoint != 330 {
+		t.Errorf("Expected timepoint to be 330, got %v", info.Timepoint)
+	}
+
+	if info.Entity != "neurons" {
+		t.Errorf("Expected cells to map to neurons, got %s", info.Entity)
+	}
+
+	// entity types left out of the file keep their default folder
+	if entity, err := layout.EntityType("L1/12/synapses/ADALbyAIYL.gltf"); err != nil || entity != "synapses" {
+		t.Errorf("Expected synapses, got %s (%v)", entity, err)
+	}
+
+	if _, err := layout.DevStage("L3/12/synapses/ADALbyAIYL.gltf"); err == nil {
+		t.Error("Expected stage outside of the layout to fail")
+	}
+}
+
+func TestParseLayoutInvalid(t *testing.T) {
+	t.Parallel()
+
+	for name, data := range map[string]string{
+		"missing entity":   `templates: ["{stage}/{timepoint}/{file}"]`,
+		"unknown entity":   `entities: {axons: [axons]}`,
+		"unknown alias":    `stage_aliases: {embryo: Embryo}`,
+		"duplicate folder": `entities: {neurons: [cells], contacts: [cells]}`,
+	} {
+		if _, err := ParseLayout([]byte(data)); err == nil {
+			t.Errorf("Expected %s layout to fail", name)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package toolshed
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"neuroscan/pkg/gltf"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SceneEntityTypes are the entity types that can be split out of a combined scene.
+var SceneEntityTypes = []string{"neurons", "contacts", "synapses"}
+
+// SceneSource locates an entity inside a combined scene file.
+type SceneSource struct {
+	// Node is the index of the entity's node in the scene
+	Node int `json:"node"`
+	// ByteRanges are the parts of the scene's binary data holding the node's geometry,
+	// empty when the scene embeds its buffers as data URIs
+	ByteRanges []ByteRange `json:"byte_ranges,omitempty"`
+}
+
+// ByteRange is a contiguous range of bytes in the scene file, or in one of its external buffers.
+type ByteRange struct {
+	// URI is the external buffer the range is in, empty for the scene file itself
+	URI    string `json:"uri,omitempty"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+}
+
+// SceneNode is a single entity split out of a combined scene.
+type SceneNode struct {
+	NeuroscanFilepathData
+	Entity string
+	Source SceneSource
+}
+
+// ParseScene splits a combined glTF or GLB scene into its entities. The entity type
+// of each mesh node is taken from an "entity" key in the node's extras, or else from
+// the name of the nearest ancestor node, matched against the layout's entity folders.
+// The color of each entity is taken from the material of its own mesh.
+func ParseScene(fsys fs.FS, layout *Layout, filePath string) ([]SceneNode, error) {
+	filename := filepath.Base(filePath)
+
+	filehash, err := HashFile(fsys, filePath)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting file hash")
+		return nil, err
+	}
+
+	timepoint, err := layout.Timepoint(filePath)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting timepoint")
+		return nil, err
+	}
+
+	devStageUID, err := layout.DevStage(filePath)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting developmental stage")
+		return nil, err
+	}
+
+	doc, err := gltf.OpenFS(fsys, filePath)
+	if err != nil {
+		log.Error().Err(err).Msg("Error opening gltf file")
+		return nil, err
+	}
+
+	if err := validateDocument(doc, filePath); err != nil {
+		return nil, err
+	}
+
+	binaryOffset, err := sceneBinaryOffset(fsys, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := map[int]int{}
+	for i, node := range doc.Nodes {
+		for _, child := range node.Children {
+			parents[child] = i
+		}
+	}
+
+	var sceneNodes []SceneNode
+
+	for i, node := range doc.Nodes {
+		if node.Mesh == nil {
+			continue
+		}
+
+		entity, ok := sceneNodeEntity(doc, layout, parents, i)
+		if !ok {
+			log.Debug().Str("path", filePath).Str("node", node.Name).Msg("Scene node has no entity type, skipping")
+			continue
+		}
+
+		if node.Name == "" {
+			return nil, fmt.Errorf("scene node %d has no name", i)
+		}
+
+		sceneNodes = append(sceneNodes, SceneNode{
+			NeuroscanFilepathData: NeuroscanFilepathData{
+				UID:                strings.ReplaceAll(node.Name, " ", "_"),
+				Filename:           filename,
+				Filehash:           filehash,
+				Timepoint:          timepoint,
+				DevelopmentalStage: devStageUID,
+				Color:              meshColor(doc, *node.Mesh),
+				Mesh:               measureNode(doc, i),
+			},
+			Entity: entity,
+			Source: SceneSource{
+				Node:       i,
+				ByteRanges: meshByteRanges(doc, *node.Mesh, binaryOffset),
+			},
+		})
+	}
+
+	if len(sceneNodes) == 0 {
+		return nil, errors.New("scene has no nodes with an entity type")
+	}
+
+	return sceneNodes, nil
+}
+
+// sceneNodeEntity resolves the entity type of a node from its extras or its ancestors' names.
+func sceneNodeEntity(doc *gltf.Document, layout *Layout, parents map[int]int, index int) (string, bool) {
+	if extras, ok := doc.Nodes[index].Extras.(map[string]any); ok {
+		if name, ok := extras["entity"].(string); ok {
+			if entity, ok := layout.EntityFromName(name); ok && slices.Contains(SceneEntityTypes, entity) {
+				return entity, true
+			}
+		}
+	}
+
+	// guard against cycles in malformed files
+	for depth := 0; depth < len(doc.Nodes); depth++ {
+		parent, ok := parents[index]
+		if !ok {
+			return "", false
+		}
+
+		if entity, ok := layout.EntityFromName(doc.Nodes[parent].Name); ok && slices.Contains(SceneEntityTypes, entity) {
+			return entity, true
+		}
+
+		index = parent
+	}
+
+	return "", false
+}
+
+// meshColor returns the base color of the first material used by the mesh.
+func meshColor(doc *gltf.Document, mesh int) Color {
+	if mesh < len(doc.Meshes) {
+		for _, primitive := range doc.Meshes[mesh].Primitives {
+			if primitive.Material == nil || *primitive.Material >= len(doc.Materials) {
+				continue
+			}
+
+			if pbr := doc.Materials[*primitive.Material].PBRMetallicRoughness; pbr != nil {
+				return Color(pbr.BaseColorFactorOrDefault())
+			}
+		}
+	}
+
+	return DefaultColor
+}
+
+// meshByteRanges returns the merged byte ranges of every buffer view read by the mesh.
+func meshByteRanges(doc *gltf.Document, mesh int, binaryOffset int64) []ByteRange {
+	if mesh >= len(doc.Meshes) {
+		return nil
+	}
+
+	accessors := []int{}
+	for _, primitive := range doc.Meshes[mesh].Primitives {
+		for _, accessor := range primitive.Attributes {
+			accessors = append(accessors, accessor)
+		}
+
+		if primitive.Indices != nil {
+			accessors = append(accessors, *primitive.Indices)
+		}
+	}
+
+	var ranges []ByteRange
+
+	for _, accessor := range accessors {
+		if accessor >= len(doc.Accessors) || doc.Accessors[accessor].BufferView == nil {
+			continue
+		}
+
+		view := *doc.Accessors[accessor].BufferView
+		if view >= len(doc.BufferViews) {
+			continue
+		}
+
+		bufferView := doc.BufferViews[view]
+		if bufferView.Buffer >= len(doc.Buffers) {
+			continue
+		}
+
+		buffer := doc.Buffers[bufferView.Buffer]
+
+		byteRange := ByteRange{
+			Offset: int64(bufferView.ByteOffset),
+			Length: int64(bufferView.ByteLength),
+		}
+
+		switch {
+		case buffer.IsEmbeddedResource():
+			// embedded buffers cannot be addressed by byte range
+			continue
+		case buffer.URI == "":
+			byteRange.Offset += binaryOffset
+		default:
+			byteRange.URI = buffer.URI
+		}
+
+		ranges = append(ranges, byteRange)
+	}
+
+	return mergeByteRanges(ranges)
+}
+
+// mergeByteRanges sorts the ranges and joins any that overlap or touch.
+func mergeByteRanges(ranges []ByteRange) []ByteRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(ranges, func(a, b ByteRange) int {
+		if a.URI != b.URI {
+			return strings.Compare(a.URI, b.URI)
+		}
+
+		return int(a.Offset - b.Offset)
+	})
+
+	merged := []ByteRange{ranges[0]}
+
+	for _, next := range ranges[1:] {
+		last := &merged[len(merged)-1]
+
+		if next.URI == last.URI && next.Offset <= last.Offset+last.Length {
+			last.Length = max(last.Length, next.Offset+next.Length-last.Offset)
+			continue
+		}
+
+		merged = append(merged, next)
+	}
+
+	return merged
+}
+
+// sceneBinaryOffset returns the offset of the binary chunk when the scene is a GLB.
+func sceneBinaryOffset(fsys fs.FS, filePath string) (int64, error) {
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	offset, _, err := gltf.BinaryChunkOffset(file)
+
+	return offset, err
+}
@@ -0,0 +1,115 @@
+package toolshed
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"testing/fstest"
+)
+
+const testSceneJSON = `{
+	"asset": {"version": "2.0"},
+	"buffers": [{"byteLength": 48}],
+	"bufferViews": [
+		{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 36, "byteLength": 12}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 1, "componentType": 5125, "count": 3, "type": "SCALAR"}
+	],
+	"materials": [
+		{"pbrMetallicRoughness": {"baseColorFactor": [1, 0, 0, 1]}},
+		{"pbrMetallicRoughness": {"baseColorFactor": [0, 0, 1, 1]}}
+	],
+	"meshes": [
+		{"primitives": [{"attributes": {"POSITION": 0}, "indices": 1, "material": 0}]},
+		{"primitives": [{"attributes": {"POSITION": 0}, "material": 1}]}
+	],
+	"nodes": [
+		{"name": "neurons", "children": [1]},
+		{"name": "ADAL", "mesh": 0},
+		{"name": "ADALbyAIYL", "mesh": 1, "extras": {"entity": "contacts"}},
+		{"name": "orphan", "mesh": 0}
+	]
+}`
+
+// buildTestGLB packs the JSON document and a zeroed binary chunk into a GLB.
+func buildTestGLB(t *testing.T, doc string, binLength int) []byte {
+	t.Helper()
+
+	jsonChunk := []byte(doc)
+	for len(jsonChunk)%4 != 0 {
+		jsonChunk = append(jsonChunk, ' ')
+	}
+
+	var buf bytes.Buffer
+	total := 12 + 8 + len(jsonChunk) + 8 + binLength
+
+	for _, v := range []uint32{0x46546c67, 2, uint32(total), uint32(len(jsonChunk)), 0x4e4f534a} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.Write(jsonChunk)
+
+	binary.Write(&buf, binary.LittleEndian, uint32(binLength))
+	binary.Write(&buf, binary.LittleEndian, uint32(0x004e4942))
+	buf.Write(make([]byte, binLength))
+
+	return buf.Bytes()
+}
+
+func TestParseScene(t *testing.T) {
+	t.Parallel()
+
+	glb := buildTestGLB(t, testSceneJSON, 48)
+
+	fsys := fstest.MapFS{
+		"L1/23/scene/combined.glb": {Data: glb},
+	}
+
+	nodes, err := ParseScene(fsys, DefaultLayout(), "L1/23/scene/combined.glb")
+	if err != nil {
+		t.Fatalf("Expected scene to parse, got %v", err)
+	}
+
+	if len(nodes) != 2 {
+		t.Fatalf("Expected 2 scene nodes, got %d", len(nodes))
+	}
+
+	neuron, contact := nodes[0], nodes[1]
+
+	if neuron.UID != "ADAL" || neuron.Entity != "neurons" {
+		t.Errorf("Expected ADAL to be a neuron, got %s %s", neuron.UID, neuron.Entity)
+	}
+
+	if contact.UID != "ADALbyAIYL" || contact.Entity != "contacts" {
+		t.Errorf("Expected ADALbyAIYL to be a contact, got %s %s", contact.UID, contact.Entity)
+	}
+
+	if neuron.Color != (Color{1, 0, 0, 1}) || contact.Color != (Color{0, 0, 1, 1}) {
+		t.Errorf("Expected each node to use its own material color, got %v and %v", neuron.Color, contact.Color)
+	}
+
+	if neuron.Timepoint != 23 || neuron.DevelopmentalStage != "L1" || neuron.Filename != "combined.glb" {
+		t.Errorf("Expected scene context to be resolved from the path, got %+v", neuron.NeuroscanFilepathData)
+	}
+
+	binOffset := int64(len(glb) - 48)
+
+	// both buffer views are adjacent so they merge into a single range
+	if len(neuron.Source.ByteRanges) != 1 {
+		t.Fatalf("Expected 1 byte range, got %v", neuron.Source.ByteRanges)
+	}
+
+	if r := neuron.Source.ByteRanges[0]; r.Offset != binOffset || r.Length != 48 || r.URI != "" {
+		t.Errorf("Expected range at %d of 48 bytes, got %+v", binOffset, r)
+	}
+
+	if r := contact.Source.ByteRanges[0]; r.Offset != binOffset || r.Length != 36 {
+		t.Errorf("Expected positions range at %d of 36 bytes, got %+v", binOffset, r)
+	}
+
+	if contact.Source.Node != 2 {
+		t.Errorf("Expected node index 2, got %d", contact.Source.Node)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"encoding/csv"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -16,7 +18,9 @@ import (
 	"time"
 
 	"neuroscan/pkg/gltf"
+	"neuroscan/pkg/mesh"
 
+	"github.com/andybalholm/brotli"
 	"github.com/oklog/ulid/v2"
 	"github.com/rs/zerolog/log"
 )
@@ -28,10 +32,15 @@ type NeuroscanFilepathData struct {
 	Timepoint          int
 	DevelopmentalStage string
 	Color              Color
+	// Mesh is measured from the node's geometry, nil when the node has no triangles
+	Mesh *mesh.Measurements
 }
 
 type Color [4]float64
 
+// DefaultColor is used for files whose material has no base color, it is the glTF default
+var DefaultColor = Color{1, 1, 1, 1}
+
 func ValidExtension(fileName string, extensions []string) bool {
 	// get the file extension
 	ext := filepath.Ext(fileName)
@@ -53,9 +62,9 @@ func ValidExtension(fileName string, extensions []string) bool {
 	return false
 }
 
-// HashFile returns the SHA256 hash of a file
-func HashFile(path string) (string, error) {
-	file, err := os.Open(path)
+// HashFile returns the SHA256 hash of a file in fsys
+func HashFile(fsys fs.FS, path string) (string, error) {
+	file, err := fsys.Open(path)
 	if err != nil {
 		return "", err
 	}
@@ -69,70 +78,6 @@ func HashFile(path string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// GetTimepoint returns the timepoint based on the folder name in the filepath
-func GetTimepoint(filePath string) (int, error) {
-	// the timepoint is one of the following: 0, 5, 8, 23, 27, 36, or 48
-	// it's based on the name of a folder somewhere in the middle of the path
-
-	// split the path into parts
-	parts := strings.SplitSeq(filePath, "/")
-
-	// iterate over the parts
-	for part := range parts {
-		// if the part is a number, return it
-		if tp, err := strconv.Atoi(part); err == nil {
-			log.Debug().Int("timepoint", tp).Msg("Getting timepoint")
-			return tp, nil
-		}
-	}
-
-	// if no timepoint is found, return an error
-	return 0, errors.New("timepoint not found in path")
-}
-
-// GetDevStage returns the development stage based on the folder name in the filepath
-func GetDevStage(filePath string) (string, error) {
-	// the development stage is one of the following: L1, L2, L3, L4, Adult
-	// it's based on the name of a folder somewhere in the middle of the path
-
-	// split the path into parts
-	parts := strings.SplitSeq(filePath, "/")
-
-	// iterate over the parts
-	for part := range parts {
-		// if the part is one of the development stages, return it
-		if part == "L1" || part == "L2" || part == "L3" || part == "L4" || part == "Adult" {
-			log.Debug().Str("stage", part).Msg("Getting development stage")
-			return part, nil
-		}
-	}
-
-	// if no development stage is found, return an error
-	return "", errors.New("development stage not found in path")
-}
-
-// GetEntityType returns the entity type based on the folder name in the filepath
-func GetEntityType(filePath string) (string, error) {
-	log.Debug().Str("path", filePath).Msg("Getting entity type")
-	// the entity type is one of the following: neurons, synapses, contacts, cphate, nerveRing
-	// it's based on the name of a folder somewhere in the middle of the path
-
-	// split the path into parts
-	parts := strings.SplitSeq(filePath, "/")
-
-	// iterate over the parts
-	for part := range parts {
-		// if the part is one of the entity types, return it
-		if part == "neurons" || part == "synapses" || part == "contacts" || part == "cphate" || part == "nerveRing" || part == "scale" || part == "promoters" || part == "dev_stages" || part == "meta" {
-			log.Debug().Str("type", part).Msg("Getting entity type")
-			return part, nil
-		}
-	}
-
-	// if no entity type is found, return an error
-	return "", errors.New("entity type not found in path")
-}
-
 func GetContactUIDNeurons(filename string) (string, string) {
 	// if the filename does not contain "by" then return empty strings
 	if !strings.Contains(strings.ToLower(filename), "by") {
@@ -165,23 +110,28 @@ func GetTimeNow() string {
 	return now.Format(timeFormat)
 }
 
-// FilePathParse takes a filepath and returns the various metadata relating to the context of the file
-func FilePathParse(filePath string) ([]NeuroscanFilepathData, error) {
+// ErrNoNodes is returned for a glTF file without nodes, there is no entity to name after them.
+var ErrNoNodes = errors.New("gltf file has no nodes")
+
+// FilePathParse takes a filepath inside fsys and returns the various metadata relating to the context of the file,
+// the developmental stage and timepoint are resolved through the layout. It returns one entry per node, and
+// ErrNoNodes rather than none.
+func FilePathParse(fsys fs.FS, layout *Layout, filePath string) ([]NeuroscanFilepathData, error) {
 	filename := filepath.Base(filePath)
 
-	filehash, err := HashFile(filePath)
+	filehash, err := HashFile(fsys, filePath)
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting file hash")
 		return []NeuroscanFilepathData{}, err
 	}
 
-	timepoint, err := GetTimepoint(filePath)
+	timepoint, err := layout.Timepoint(filePath)
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting timepoint")
 		return []NeuroscanFilepathData{}, err
 	}
 
-	devStageUID, err := GetDevStage(filePath)
+	devStageUID, err := layout.DevStage(filePath)
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting developmental stage")
 		return []NeuroscanFilepathData{}, err
@@ -189,16 +139,24 @@ func FilePathParse(filePath string) ([]NeuroscanFilepathData, error) {
 
 	var parsedFiles []NeuroscanFilepathData
 	// attempt to open and decode the gltf file
-	doc, err := gltf.Open(filePath)
+	doc, err := gltf.OpenFS(fsys, filePath)
 	if err != nil {
 		log.Error().Err(err).Msg("Error opening gltf file")
 		return []NeuroscanFilepathData{}, err
 	}
 
-	colors := doc.Materials[0].PBRMetallicRoughness.BaseColorFactor
-	color := Color(*colors)
+	if err := validateDocument(doc, filePath); err != nil {
+		return []NeuroscanFilepathData{}, err
+	}
 
-	for _, node := range doc.Nodes {
+	// a spec-valid document may have no nodes, callers take the first one
+	if len(doc.Nodes) == 0 {
+		return []NeuroscanFilepathData{}, fmt.Errorf("%w: %s", ErrNoNodes, filePath)
+	}
+
+	color := documentColor(doc)
+
+	for i, node := range doc.Nodes {
 		// we need to make sure any spaces are replaced with underscores
 		uid := strings.ReplaceAll(node.Name, " ", "_")
 
@@ -209,6 +167,7 @@ func FilePathParse(filePath string) ([]NeuroscanFilepathData, error) {
 			Timepoint:          timepoint,
 			DevelopmentalStage: devStageUID,
 			Color:              color,
+			Mesh:               measureNode(doc, i),
 		}
 
 		parsedFiles = append(parsedFiles, parsedFile)
@@ -218,6 +177,45 @@ func FilePathParse(filePath string) ([]NeuroscanFilepathData, error) {
 	return parsedFiles, nil
 }
 
+// validateDocument logs the spec warnings of a document and returns its errors, documents
+// with errors can't be measured or split safely
+func validateDocument(doc *gltf.Document, filePath string) error {
+	diagnostics := gltf.Validate(doc)
+
+	for _, d := range diagnostics.Warnings() {
+		log.Debug().Str("file", filePath).Str("code", string(d.Code)).Str("path", d.Path).Msg(d.Message)
+	}
+
+	return diagnostics.Err()
+}
+
+// documentColor returns the base color of the document's first material, or DefaultColor
+// when it has no materials or the material has no base color
+func documentColor(doc *gltf.Document) Color {
+	if len(doc.Materials) == 0 || doc.Materials[0].PBRMetallicRoughness == nil {
+		return DefaultColor
+	}
+
+	return Color(doc.Materials[0].PBRMetallicRoughness.BaseColorFactorOrDefault())
+}
+
+// measureNode measures the node's mesh, nodes without geometry are not measured
+func measureNode(doc *gltf.Document, node int) *mesh.Measurements {
+	if doc.Nodes[node].Mesh == nil {
+		return nil
+	}
+
+	m, err := mesh.FromNode(doc, node)
+	if err != nil {
+		log.Warn().Err(err).Str("node", doc.Nodes[node].Name).Msg("Unable to measure mesh")
+		return nil
+	}
+
+	measurements := m.Measure()
+
+	return &measurements
+}
+
 func CreateDirectory(path string, permissions os.FileMode) error {
 	// create directory if it doesn't exist at path
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -324,6 +322,98 @@ func GzipFile(input string, output string) error {
 	return nil
 }
 
+// PrecompressEncodings are the content encodings PrecompressFile writes, in order of preference
+var PrecompressEncodings = []string{"br", "gzip"}
+
+// EncodingExtension returns the suffix of the precompressed variant of a file for a content encoding
+func EncodingExtension(encoding string) string {
+	switch encoding {
+	case "br":
+		return ".br"
+	case "gzip":
+		return ".gz"
+	}
+	return ""
+}
+
+// PrecompressFile writes a brotli and a gzip copy of filePath in fsys under outputDir, at
+// outputPath with a .br or .gz suffix. A copy that isn't smaller than the file, such as an
+// already compressed GLB, is removed. It returns the encodings that were kept.
+func PrecompressFile(fsys fs.FS, filePath string, outputDir string, outputPath string) ([]string, error) {
+	info, err := fs.Stat(fsys, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	output := filepath.Join(outputDir, filepath.FromSlash(outputPath))
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return nil, err
+	}
+
+	var encodings []string
+	for _, encoding := range PrecompressEncodings {
+		variant := output + EncodingExtension(encoding)
+
+		size, err := compressFile(fsys, filePath, variant, encoding)
+		if err != nil {
+			return nil, err
+		}
+
+		if size >= info.Size() {
+			if err := os.Remove(variant); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		encodings = append(encodings, encoding)
+	}
+
+	return encodings, nil
+}
+
+// compressFile writes filePath compressed with the content encoding to output and returns its size.
+// Variants are compressed once and served many times, so the levels favor size over speed.
+func compressFile(fsys fs.FS, filePath string, output string, encoding string) (int64, error) {
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	out, err := os.Create(output)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	var w io.WriteCloser
+	switch encoding {
+	case "br":
+		// brotli's top levels are too slow for large meshes, 9 gets most of the way there
+		w = brotli.NewWriterLevel(out, 9)
+	case "gzip":
+		w, _ = gzip.NewWriterLevel(out, gzip.BestCompression)
+	default:
+		return 0, errors.New("unsupported content encoding " + encoding)
+	}
+
+	if _, err := io.Copy(w, file); err != nil {
+		w.Close()
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
 func RemoveExtension(str string) string {
 	return strings.Split(str, ".")[0]
 }
@@ -345,29 +435,53 @@ func CreateULID(prefix string) string {
 	return prefix + "_" + ulid.MustNew(ms, entropy).String()
 }
 
-func ParseTimepointIntArray(timepoints string) []int {
-	// timepoints is a slice that comes through like {1,2,3,4,5} in the csv. lets' parse that
-	timepoints = timepoints[1 : len(timepoints)-1]
-	timepointArray := strings.Split(timepoints, ",")
+// ParseTimepointIntArray parses a postgres style array of timepoints such as {1,2,3} from a CSV.
+// An empty array {} gives nil, anything that isn't an array of integers is an error.
+func ParseTimepointIntArray(timepoints string) ([]int, error) {
+	timepoints = strings.TrimSpace(timepoints)
+	if len(timepoints) < 2 || timepoints[0] != '{' || timepoints[len(timepoints)-1] != '}' {
+		return nil, fmt.Errorf("timepoints %q must be written as {1,2,3}", timepoints)
+	}
 
-	var timepointIntArray []int
+	timepoints = strings.TrimSpace(timepoints[1 : len(timepoints)-1])
+	if timepoints == "" {
+		return nil, nil
+	}
 
-	for _, tp := range timepointArray {
-		tpInt, _ := strconv.Atoi(tp)
+	var timepointIntArray []int
+	for _, tp := range strings.Split(timepoints, ",") {
+		tpInt, err := strconv.Atoi(strings.TrimSpace(tp))
+		if err != nil {
+			return nil, fmt.Errorf("timepoint %q is not an integer", tp)
+		}
 		timepointIntArray = append(timepointIntArray, tpInt)
 	}
 
-	return timepointIntArray
+	return timepointIntArray, nil
+}
+
+func GetCSVRows(fsys fs.FS, filePath string) ([][]string, error) {
+	return getDelimitedRows(fsys, filePath, ',')
+}
+
+// GetTSVRows reads a tab separated file such as a WormBase dump
+func GetTSVRows(fsys fs.FS, filePath string) ([][]string, error) {
+	return getDelimitedRows(fsys, filePath, '\t')
 }
 
-func GetCSVRows(filePath string) ([][]string, error) {
-	file, err := os.Open(filePath)
+func getDelimitedRows(fsys fs.FS, filePath string, comma rune) ([][]string, error) {
+	file, err := fsys.Open(filePath)
 	if err != nil {
 		return [][]string{}, errors.New("error opening file: " + err.Error())
 	}
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	reader.Comma = comma
+	// descriptions in tab separated dumps contain stray quotes
+	reader.LazyQuotes = comma == '\t'
+	// rows with the wrong number of values are reported by the caller instead of failing the whole file
+	reader.FieldsPerRecord = -1
 
 	records, err := reader.ReadAll()
 	if err != nil {
@@ -0,0 +1,207 @@
+package toolshed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"neuroscan/pkg/gltf"
+
+	"github.com/andybalholm/brotli"
+)
+
+const testNeuronGLTF = `{
+	"asset": {"version": "2.0"},
+	"materials": [{"pbrMetallicRoughness": {"baseColorFactor": [0.1, 0.2, 0.3, 1]}}],
+	"nodes": [{"name": "ADAL"}]
+}`
+
+func TestFilePathParseFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"L1/23/neurons/ADAL.gltf": {Data: []byte(testNeuronGLTF)},
+	}
+
+	fileMetas, err := FilePathParse(fsys, DefaultLayout(), "L1/23/neurons/ADAL.gltf")
+	if err != nil {
+		t.Fatalf("Expected file to parse, got %v", err)
+	}
+
+	if len(fileMetas) != 1 {
+		t.Fatalf("Expected 1 file meta, got %d", len(fileMetas))
+	}
+
+	meta := fileMetas[0]
+
+	if meta.UID != "ADAL" {
+		t.Errorf("Expected uid to be ADAL, got %s", meta.UID)
+	}
+
+	if meta.Timepoint != 23 {
+		t.Errorf("Expected timepoint to be 23, got %d", meta.Timepoint)
+	}
+
+	if meta.DevelopmentalStage != "L1" {
+		t.Errorf("Expected developmental stage to be L1, got %s", meta.DevelopmentalStage)
+	}
+
+	if meta.Filename != "ADAL.gltf" {
+		t.Errorf("Expected filename to be ADAL.gltf, got %s", meta.Filename)
+	}
+
+	if meta.Color != (Color{0.1, 0.2, 0.3, 1}) {
+		t.Errorf("Expected color to be read from the material, got %v", meta.Color)
+	}
+
+	if meta.Filehash == "" {
+		t.Error("Expected file hash to be set")
+	}
+}
+
+func TestGetCSVRowsFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"promoters/promoters.csv": {Data: []byte("uid,wormbase\nunc-4,WBGene00006744\n")},
+	}
+
+	rows, err := GetCSVRows(fsys, "promoters/promoters.csv")
+	if err != nil {
+		t.Fatalf("Expected csv to be read, got %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+
+	if rows[1][0] != "unc-4" {
+		t.Errorf("Expected unc-4, got %s", rows[1][0])
+	}
+}
+
+func TestParseTimepointIntArray(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string][]int{
+		"{1,2,3}":     {1, 2, 3},
+		" { 23, 24 }": {23, 24},
+		"{}":          nil,
+	}
+	for input, expected := range tests {
+		timepoints, err := ParseTimepointIntArray(input)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", input, err)
+			continue
+		}
+		if !slices.Equal(timepoints, expected) || (expected == nil) != (timepoints == nil) {
+			t.Errorf("Expected %q to give %v, got %v", input, expected, timepoints)
+		}
+	}
+
+	for _, input := range []string{"", "{", "1,2", "{1,,2}", "{a}"} {
+		if _, err := ParseTimepointIntArray(input); err == nil {
+			t.Errorf("Expected %q to fail", input)
+		}
+	}
+}
+
+func TestFilePathParseWithoutMaterial(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"L1/23/neurons/ADAL.gltf":  {Data: []byte(`{"asset": {"version": "2.0"}, "nodes": [{"name": "ADAL"}]}`)},
+		"L1/23/neurons/ADAR.gltf":  {Data: []byte(`{"asset": {"version": "2.0"}, "materials": [{}], "nodes": [{"name": "ADAR"}]}`)},
+		"L1/23/neurons/BROKE.gltf": {Data: []byte(`{"asset": {"version": "2.0"}, "nodes": [{"name": "BROKE", "mesh": 2}]}`)},
+	}
+
+	for _, file := range []string{"L1/23/neurons/ADAL.gltf", "L1/23/neurons/ADAR.gltf"} {
+		fileMetas, err := FilePathParse(fsys, DefaultLayout(), file)
+		if err != nil {
+			t.Fatalf("Expected %s to parse, got %v", file, err)
+		}
+
+		if fileMetas[0].Color != DefaultColor {
+			t.Errorf("Expected %s to fall back to the default color, got %v", file, fileMetas[0].Color)
+		}
+	}
+
+	if _, err := FilePathParse(fsys, DefaultLayout(), "L1/23/neurons/BROKE.gltf"); !errors.Is(err, gltf.ErrInvalidDocument) {
+		t.Errorf("Expected an invalid document error, got %v", err)
+	}
+}
+
+func TestFilePathParseWithoutNodes(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"L1/23/neurons/EMPTY.gltf": {Data: []byte(`{"asset": {"version": "2.0"}}`)},
+	}
+
+	if _, err := FilePathParse(fsys, DefaultLayout(), "L1/23/neurons/EMPTY.gltf"); !errors.Is(err, ErrNoNodes) {
+		t.Errorf("Expected %v, got %v", ErrNoNodes, err)
+	}
+}
+
+func TestPrecompressFile(t *testing.T) {
+	t.Parallel()
+
+	noise := make([]byte, 4096)
+	rand.Read(noise)
+
+	gltfData := []byte(strings.Repeat(testNeuronGLTF, 20))
+	fsys := fstest.MapFS{
+		"L1/23/neurons/ADAL.gltf": {Data: gltfData},
+		"L1/23/neurons/ADAR.glb":  {Data: noise},
+	}
+	dir := t.TempDir()
+
+	// variants are written at the path the file is served from
+	encodings, err := PrecompressFile(fsys, "L1/23/neurons/ADAL.gltf", dir, "neuroscan/L1/23/neurons/ADAL.gltf")
+	if err != nil {
+		t.Fatalf("Expected file to be precompressed, got %v", err)
+	}
+	if !slices.Equal(encodings, []string{"br", "gzip"}) {
+		t.Fatalf("Expected br and gzip variants, got %v", encodings)
+	}
+
+	readers := map[string]func(io.Reader) (io.Reader, error){
+		".br": func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		".gz": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	}
+	for ext, newReader := range readers {
+		compressed, err := os.ReadFile(filepath.Join(dir, "neuroscan", "L1", "23", "neurons", "ADAL.gltf"+ext))
+		if err != nil {
+			t.Fatalf("Expected %s variant to be written, got %v", ext, err)
+		}
+
+		r, err := newReader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(data, gltfData) {
+			t.Errorf("Expected %s variant to decompress to the file, got %v", ext, err)
+		}
+	}
+
+	// random bytes don't compress, so no variant is kept
+	encodings, err = PrecompressFile(fsys, "L1/23/neurons/ADAR.glb", dir, "L1/23/neurons/ADAR.glb")
+	if err != nil {
+		t.Fatalf("Expected file to be precompressed, got %v", err)
+	}
+	if len(encodings) != 0 {
+		t.Errorf("Expected no variants, got %v", encodings)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "L1", "23", "neurons", "ADAR.glb.gz")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the larger gzip variant to be removed, got %v", err)
+	}
+}
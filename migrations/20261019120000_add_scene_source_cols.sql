@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table neurons
+add column scene_source jsonb;
+
+alter table contacts
+add column scene_source jsonb;
+
+alter table synapses
+add column scene_source jsonb;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table neurons
+drop column scene_source;
+
+alter table contacts
+drop column scene_source;
+
+alter table synapses
+drop column scene_source;
+
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table neurons
+add column mesh_volume double precision,
+add column mesh_surface_area double precision,
+add column centroid double precision[],
+add column bbox_min double precision[],
+add column bbox_max double precision[];
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table neurons
+drop column mesh_volume,
+drop column mesh_surface_area,
+drop column centroid,
+drop column bbox_min,
+drop column bbox_max;
+
+-- +goose StatementEnd
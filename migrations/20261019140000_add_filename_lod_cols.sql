@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table neurons add column filename_lod jsonb;
+alter table contacts add column filename_lod jsonb;
+alter table synapses add column filename_lod jsonb;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table neurons drop column filename_lod;
+alter table contacts drop column filename_lod;
+alter table synapses drop column filename_lod;
+
+-- +goose StatementEnd
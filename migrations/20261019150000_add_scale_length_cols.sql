@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table scales
+add column length double precision,
+add column micrometers double precision;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table scales
+drop column length,
+drop column micrometers;
+
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table contacts
+add column centroid double precision[],
+add column bbox_min double precision[],
+add column bbox_max double precision[];
+
+alter table synapses
+add column centroid double precision[],
+add column bbox_min double precision[],
+add column bbox_max double precision[];
+
+alter table nerve_rings
+add column centroid double precision[],
+add column bbox_min double precision[],
+add column bbox_max double precision[];
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table contacts
+drop column centroid,
+drop column bbox_min,
+drop column bbox_max;
+
+alter table synapses
+drop column centroid,
+drop column bbox_min,
+drop column bbox_max;
+
+alter table nerve_rings
+drop column centroid,
+drop column bbox_min,
+drop column bbox_max;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+create table assets (
+  path text primary key,
+  hash varchar(64) not null,
+  size bigint not null,
+  encodings text[] not null default '{}',
+  updated_at timestamptz not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table assets;
+-- +goose StatementEnd
@@ -0,0 +1,78 @@
+-- +goose Up
+-- +goose StatementBegin
+create table cphate_nodes (
+  id int generated always as identity primary key,
+  ulid varchar(255) unique not null,
+  cphate_id int not null references cphates(id) on delete cascade,
+  parent_id int references cphate_nodes(id) on delete set null,
+  timepoint int not null,
+  iteration int not null,
+  cluster int not null,
+  serial int not null default 0,
+  iteration_count int not null default 0,
+  cluster_count int not null default 0,
+  obj_file varchar(255) not null,
+  color jsonb not null,
+  unique (timepoint, iteration, cluster)
+);
+
+create index cphate_nodes_cphate_id_idx on cphate_nodes (cphate_id);
+
+create table cphate_node_neurons (
+  node_id int not null references cphate_nodes(id) on delete cascade,
+  uid varchar(255) not null,
+  position int not null,
+  primary key (node_id, uid)
+);
+
+create index cphate_node_neurons_uid_idx on cphate_node_neurons (uid);
+
+-- move the existing structures over, parents are linked again when the tree is read
+insert into cphate_nodes (ulid, cphate_id, timepoint, iteration, cluster, serial, iteration_count, cluster_count, obj_file, color)
+select distinct on (c.timepoint, (item->>'i')::int, (item->>'c')::int)
+  'cph_mi_' || c.timepoint || '_' || (item->>'i') || '_' || (item->>'c'),
+  c.id,
+  c.timepoint,
+  (item->>'i')::int,
+  (item->>'c')::int,
+  coalesce((item->>'s')::int, 0),
+  coalesce((item->>'iterationCount')::int, 0),
+  coalesce((item->>'clusterCount')::int, 0),
+  coalesce(item->>'objFile', ''),
+  coalesce(item->'color', '[1, 1, 1, 1]'::jsonb)
+from cphates c, jsonb_array_elements(c.structure) item;
+
+insert into cphate_node_neurons (node_id, uid, position)
+select n.id, neuron.uid, neuron.position - 1
+from cphates c
+cross join jsonb_array_elements(c.structure) item
+join cphate_nodes n on n.ulid = 'cph_mi_' || c.timepoint || '_' || (item->>'i') || '_' || (item->>'c')
+cross join jsonb_array_elements_text(item->'neurons') with ordinality as neuron(uid, position)
+on conflict do nothing;
+
+alter table cphates drop column structure;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table cphates add column structure jsonb not null default '[]';
+
+update cphates c set structure = coalesce((
+  select jsonb_agg(jsonb_build_object(
+    'i', n.iteration,
+    'c', n.cluster,
+    's', n.serial,
+    'iterationCount', n.iteration_count,
+    'clusterCount', n.cluster_count,
+    'neurons', (select coalesce(jsonb_agg(nn.uid order by nn.position), '[]') from cphate_node_neurons nn where nn.node_id = n.id),
+    'objFile', n.obj_file,
+    'color', n.color,
+    'ulid', n.ulid
+  ) order by n.iteration, n.cluster)
+  from cphate_nodes n
+  where n.cphate_id = c.id
+), '[]');
+
+drop table cphate_node_neurons;
+drop table cphate_nodes;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+create table promoter_cells (
+  promoter_id int not null references promoters(id) on delete cascade,
+  cell varchar(255) not null,
+  source varchar(32) not null,
+  primary key (promoter_id, cell, source)
+);
+
+create index promoter_cells_cell_idx on promoter_cells (upper(cell));
+create index promoters_wormbase_idx on promoters (wormbase);
+
+insert into promoter_cells (promoter_id, cell, source)
+select p.id, cells.cell, cells.source
+from promoters p
+cross join lateral (
+  select regexp_split_to_table(coalesce(p.cells_by_lineaging, ''), '[\s,;]+') as cell, 'lineaging' as source
+  union all
+  select regexp_split_to_table(coalesce(p.other_cells, ''), '[\s,;]+'), 'other'
+) cells
+where cells.cell <> ''
+on conflict do nothing;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index promoters_wormbase_idx;
+drop table promoter_cells;
+-- +goose StatementEnd
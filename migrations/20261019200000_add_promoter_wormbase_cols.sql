@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table promoters add column wormbase_name varchar(255) not null default '';
+alter table promoters add column wormbase_sequence_name varchar(255) not null default '';
+alter table promoters add column wormbase_description text not null default '';
+alter table promoters add column wormbase_status varchar(32) not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table promoters drop column wormbase_status;
+alter table promoters drop column wormbase_description;
+alter table promoters drop column wormbase_sequence_name;
+alter table promoters drop column wormbase_name;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+create table timepoints (
+  timepoint int primary key,
+  ulid varchar(255) unique not null,
+  stage varchar(255) not null default '',
+  hours_post_hatch double precision,
+  dataset_source varchar(255) not null default '',
+  specimen_id varchar(255) not null default ''
+);
+
+-- every timepoint already in use gets a row so the foreign keys hold
+insert into timepoints (timepoint, ulid)
+select timepoint, 'tmpnt_' || timepoint
+from (
+  select timepoint from neurons
+  union select timepoint from contacts
+  union select timepoint from synapses
+  union select timepoint from cphates
+  union select timepoint from cphate_nodes
+  union select timepoint from nerve_rings
+  union select timepoint from scales
+) used;
+
+update timepoints t set stage = ds.uid
+from developmental_stages ds
+where t.timepoint = any(ds.timepoints);
+
+alter table neurons add constraint neurons_timepoint_fkey foreign key (timepoint) references timepoints(timepoint) on update cascade;
+alter table contacts add constraint contacts_timepoint_fkey foreign key (timepoint) references timepoints(timepoint) on update cascade;
+alter table synapses add constraint synapses_timepoint_fkey foreign key (timepoint) references timepoints(timepoint) on update cascade;
+alter table cphates add constraint cphates_timepoint_fkey foreign key (timepoint) references timepoints(timepoint) on update cascade;
+alter table cphate_nodes add constraint cphate_nodes_timepoint_fkey foreign key (timepoint) references timepoints(timepoint) on update cascade;
+alter table nerve_rings add constraint nerve_rings_timepoint_fkey foreign key (timepoint) references timepoints(timepoint) on update cascade;
+alter table scales add constraint scales_timepoint_fkey foreign key (timepoint) references timepoints(timepoint) on update cascade;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table scales drop constraint scales_timepoint_fkey;
+alter table nerve_rings drop constraint nerve_rings_timepoint_fkey;
+alter table cphate_nodes drop constraint cphate_nodes_timepoint_fkey;
+alter table cphates drop constraint cphates_timepoint_fkey;
+alter table synapses drop constraint synapses_timepoint_fkey;
+alter table contacts drop constraint contacts_timepoint_fkey;
+alter table neurons drop constraint neurons_timepoint_fkey;
+drop table timepoints;
+-- +goose StatementEnd
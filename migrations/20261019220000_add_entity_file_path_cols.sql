@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the path of each file under the served glTF directory, entities ingested before have none
+-- and have to be ingested again to be composed into scenes or exported as meshes
+alter table neurons add column file_path varchar(1024) not null default '';
+alter table contacts add column file_path varchar(1024) not null default '';
+alter table synapses add column file_path varchar(1024) not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table synapses drop column file_path;
+alter table contacts drop column file_path;
+alter table neurons drop column file_path;
+-- +goose StatementEnd
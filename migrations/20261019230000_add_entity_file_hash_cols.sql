@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the SHA256 of each entity's file, clients version their /files URLs with it
+alter table neurons add column file_hash varchar(255) not null default '';
+alter table contacts add column file_hash varchar(255) not null default '';
+alter table synapses add column file_hash varchar(255) not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table synapses drop column file_hash;
+alter table contacts drop column file_hash;
+alter table neurons drop column file_hash;
+-- +goose StatementEnd
@@ -0,0 +1,219 @@
+// Package cluster builds a hierarchy of clusters over a weighted graph by iterative
+// agglomeration, the way CPHATE condenses neurons by their contacts.
+package cluster
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Linkage is how the similarity of two clusters is derived from the edges between them.
+type Linkage string
+
+const (
+	// AverageLinkage is the total weight between the clusters over the number of node pairs
+	AverageLinkage Linkage = "average"
+	// SingleLinkage is the heaviest edge between the clusters
+	SingleLinkage Linkage = "single"
+	// CompleteLinkage is the lightest weight between any two nodes, 0 unless every pair is connected
+	CompleteLinkage Linkage = "complete"
+)
+
+// Options tune the agglomeration.
+type Options struct {
+	// Linkage defaults to AverageLinkage
+	Linkage Linkage
+	// MinSimilarity keeps clusters with a weaker link apart, so the hierarchy may end with several roots
+	MinSimilarity float64
+	// MaxIterations stops the agglomeration early, 0 runs until nothing merges
+	MaxIterations int
+}
+
+// Graph is an undirected graph with weighted edges between labelled nodes.
+type Graph struct {
+	labels  []string
+	index   map[string]int
+	weights map[[2]int]float64
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		index:   map[string]int{},
+		weights: map[[2]int]float64{},
+	}
+}
+
+// AddNode adds a node without edges, nodes are also added by AddEdge.
+func (g *Graph) AddNode(label string) int {
+	if i, ok := g.index[label]; ok {
+		return i
+	}
+
+	g.index[label] = len(g.labels)
+	g.labels = append(g.labels, label)
+
+	return len(g.labels) - 1
+}
+
+// AddEdge adds weight to the edge between two nodes, edges to the node itself are ignored.
+func (g *Graph) AddEdge(a, b string, weight float64) {
+	i, j := g.AddNode(a), g.AddNode(b)
+	if i == j {
+		return
+	}
+
+	g.weights[edgeKey(i, j)] += weight
+}
+
+// Len returns the number of nodes.
+func (g *Graph) Len() int {
+	return len(g.labels)
+}
+
+func edgeKey(i, j int) [2]int {
+	if i > j {
+		i, j = j, i
+	}
+	return [2]int{i, j}
+}
+
+// Level is the clustering at one iteration, each cluster lists the labels of its nodes.
+type Level [][]string
+
+// Agglomerate returns the clustering at every iteration. The first level has every node on its
+// own. At each iteration clusters that are each other's most similar neighbour merge, so every
+// cluster is contained in one cluster of the next level. It stops once everything is merged,
+// nothing can merge any more or MaxIterations is reached.
+func Agglomerate(g *Graph, opts Options) ([]Level, error) {
+	if opts.Linkage == "" {
+		opts.Linkage = AverageLinkage
+	}
+	if !slices.Contains([]Linkage{AverageLinkage, SingleLinkage, CompleteLinkage}, opts.Linkage) {
+		return nil, fmt.Errorf("cluster: unknown linkage %q", opts.Linkage)
+	}
+
+	// assignment maps each node to its cluster
+	assignment := make([]int, g.Len())
+	for i := range assignment {
+		assignment[i] = i
+	}
+
+	levels := []Level{g.level(assignment)}
+	for opts.MaxIterations <= 0 || len(levels) < opts.MaxIterations {
+		merged := g.merge(assignment, opts)
+		if !merged {
+			break
+		}
+
+		levels = append(levels, g.level(assignment))
+		if len(levels[len(levels)-1]) == 1 {
+			break
+		}
+	}
+
+	return levels, nil
+}
+
+// merge joins the mutually most similar clusters, it returns false when no pair qualifies.
+func (g *Graph) merge(assignment []int, opts Options) bool {
+	sizes := map[int]int{}
+	for _, c := range assignment {
+		sizes[c]++
+	}
+
+	// sum, heaviest and lightest weight and the number of connected pairs between clusters
+	type link struct {
+		sum, heaviest, lightest float64
+		pairs                   int
+	}
+	links := map[[2]int]*link{}
+	for edge, weight := range g.weights {
+		a, b := assignment[edge[0]], assignment[edge[1]]
+		if a == b {
+			continue
+		}
+
+		key := edgeKey(a, b)
+		l, ok := links[key]
+		if !ok {
+			l = &link{heaviest: weight, lightest: weight}
+			links[key] = l
+		}
+		l.sum += weight
+		l.heaviest = max(l.heaviest, weight)
+		l.lightest = min(l.lightest, weight)
+		l.pairs++
+	}
+
+	similarity := func(key [2]int, l *link) float64 {
+		switch opts.Linkage {
+		case SingleLinkage:
+			return l.heaviest
+		case CompleteLinkage:
+			if l.pairs < sizes[key[0]]*sizes[key[1]] {
+				return 0
+			}
+			return l.lightest
+		}
+		return l.sum / float64(sizes[key[0]]*sizes[key[1]])
+	}
+
+	// the best neighbour of each cluster, ties go to the lower cluster so runs are repeatable
+	type neighbour struct {
+		cluster    int
+		similarity float64
+	}
+	best := map[int]neighbour{}
+	keys := slices.SortedFunc(maps.Keys(links), func(x, y [2]int) int {
+		return cmp.Or(cmp.Compare(x[0], y[0]), cmp.Compare(x[1], y[1]))
+	})
+	for _, key := range keys {
+		s := similarity(key, links[key])
+		if s <= 0 || s < opts.MinSimilarity {
+			continue
+		}
+
+		for _, side := range [][2]int{{key[0], key[1]}, {key[1], key[0]}} {
+			if current, ok := best[side[0]]; !ok || s > current.similarity {
+				best[side[0]] = neighbour{cluster: side[1], similarity: s}
+			}
+		}
+	}
+
+	renamed := map[int]int{}
+	for c, n := range best {
+		if c < n.cluster && best[n.cluster].cluster == c {
+			renamed[n.cluster] = c
+		}
+	}
+
+	for i, c := range assignment {
+		if to, ok := renamed[c]; ok {
+			assignment[i] = to
+		}
+	}
+
+	return len(renamed) > 0
+}
+
+// level lists the clusters of an assignment, the largest first and the nodes of each in label order.
+func (g *Graph) level(assignment []int) Level {
+	members := map[int][]string{}
+	for node, c := range assignment {
+		members[c] = append(members[c], g.labels[node])
+	}
+
+	level := make(Level, 0, len(members))
+	for _, labels := range members {
+		slices.Sort(labels)
+		level = append(level, labels)
+	}
+
+	slices.SortFunc(level, func(a, b []string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), cmp.Compare(a[0], b[0]))
+	})
+
+	return level
+}
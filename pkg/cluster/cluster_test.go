@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"slices"
+	"testing"
+)
+
+// twoGroups is two tight groups, ADAL ADAR AVAL and RIAL RIAR, joined by a weak contact
+func twoGroups() *Graph {
+	g := NewGraph()
+	g.AddEdge("ADAL", "ADAR", 10)
+	g.AddEdge("ADAL", "AVAL", 10)
+	g.AddEdge("ADAR", "AVAL", 10)
+	g.AddEdge("RIAL", "RIAR", 10)
+	g.AddEdge("AVAL", "RIAL", 1)
+	g.AddNode("SMDL")
+	return g
+}
+
+func TestAgglomerate(t *testing.T) {
+	t.Parallel()
+
+	levels, err := Agglomerate(twoGroups(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Level{
+		{{"ADAL"}, {"ADAR"}, {"AVAL"}, {"RIAL"}, {"RIAR"}, {"SMDL"}},
+		{{"ADAL", "ADAR"}, {"RIAL", "RIAR"}, {"AVAL"}, {"SMDL"}},
+		{{"ADAL", "ADAR", "AVAL"}, {"RIAL", "RIAR"}, {"SMDL"}},
+		{{"ADAL", "ADAR", "AVAL", "RIAL", "RIAR"}, {"SMDL"}},
+	}
+	if len(levels) != len(want) {
+		t.Fatalf("Expected %d levels, got %v", len(want), levels)
+	}
+
+	for i := range want {
+		if !slices.EqualFunc(levels[i], want[i], slices.Equal) {
+			t.Errorf("Expected level %d to be %v, got %v", i+1, want[i], levels[i])
+		}
+	}
+
+	// every cluster sits inside a cluster of the next level
+	for i := 1; i < len(levels); i++ {
+		for _, cluster := range levels[i-1] {
+			if !slices.ContainsFunc(levels[i], func(parent []string) bool {
+				return !slices.ContainsFunc(cluster, func(label string) bool { return !slices.Contains(parent, label) })
+			}) {
+				t.Errorf("Expected %v to be contained in a cluster of level %d", cluster, i+1)
+			}
+		}
+	}
+}
+
+func TestAgglomerateOptions(t *testing.T) {
+	t.Parallel()
+
+	// the two groups are linked by 1/6 on average, below the minimum
+	levels, _ := Agglomerate(twoGroups(), Options{MinSimilarity: 0.5})
+	if len(levels) != 3 {
+		t.Errorf("Expected the groups to stay apart after 3 levels, got %v", levels)
+	}
+
+	levels, _ = Agglomerate(twoGroups(), Options{MaxIterations: 2})
+	if len(levels) != 2 {
+		t.Errorf("Expected 2 levels, got %d", len(levels))
+	}
+
+	if _, err := Agglomerate(twoGroups(), Options{Linkage: "ward"}); err == nil {
+		t.Error("Expected an unknown linkage to fail")
+	}
+}
@@ -0,0 +1,283 @@
+// Package compose merges nodes from several glTF documents into a single document.
+package compose
+
+import (
+	"errors"
+	"fmt"
+
+	"neuroscan/pkg/gltf"
+	"neuroscan/pkg/mesh"
+)
+
+// Part is one entity of a composed scene.
+type Part struct {
+	// Name is given to the node that holds the part, it is usually the entity ULID.
+	Name string
+	// Color is the base color of the material shared by all of the part's primitives.
+	Color  [4]float64
+	Extras any
+	Doc    *gltf.Document
+	// Node selects a single node of Doc, along with its children. Its ancestors'
+	// transforms are baked into the part node. When nil the roots of the default scene are copied.
+	Node *int
+}
+
+// Scene builds a document with one root node per part. All of the parts' buffer
+// data is packed into a single buffer, ready to be encoded as GLB.
+func Scene(parts []Part) (*gltf.Document, error) {
+	c := &composer{doc: gltf.NewDocument()}
+	c.doc.Asset.Generator = "neuroscan"
+
+	for _, part := range parts {
+		if err := c.add(part); err != nil {
+			return nil, fmt.Errorf("compose %s: %w", part.Name, err)
+		}
+	}
+
+	if len(c.data) > 0 {
+		c.doc.Buffers = []*gltf.Buffer{{ByteLength: len(c.data), Data: c.data}}
+	}
+	return c.doc, nil
+}
+
+type composer struct {
+	doc  *gltf.Document
+	data []byte
+}
+
+// source keeps track of what was already copied from one part's document.
+type source struct {
+	doc         *gltf.Document
+	material    int
+	meshes      map[int]int
+	accessors   map[int]int
+	bufferViews map[int]int
+}
+
+func (c *composer) add(part Part) error {
+	if part.Doc == nil {
+		return errors.New("missing document")
+	}
+
+	src := &source{
+		doc:         part.Doc,
+		meshes:      map[int]int{},
+		accessors:   map[int]int{},
+		bufferViews: map[int]int{},
+	}
+
+	c.doc.Materials = append(c.doc.Materials, &gltf.Material{
+		Name:                 part.Name,
+		DoubleSided:          true,
+		PBRMetallicRoughness: &gltf.PBRMetallicRoughness{BaseColorFactor: &part.Color},
+	})
+	src.material = len(c.doc.Materials) - 1
+	if part.Color[3] < 1 {
+		c.doc.Materials[src.material].AlphaMode = gltf.AlphaBlend
+	}
+
+	root := &gltf.Node{Name: part.Name, Extras: part.Extras}
+	c.doc.Nodes = append(c.doc.Nodes, root)
+	rootIndex := len(c.doc.Nodes) - 1
+	c.doc.Scenes[0].Nodes = append(c.doc.Scenes[0].Nodes, rootIndex)
+
+	var roots []int
+	if part.Node != nil {
+		if *part.Node < 0 || *part.Node >= len(part.Doc.Nodes) {
+			return fmt.Errorf("node %d out of range", *part.Node)
+		}
+		roots = []int{*part.Node}
+		if parent, ok := parentOf(part.Doc, *part.Node); ok {
+			root.Matrix = mesh.WorldMatrix(part.Doc, parent)
+		}
+	} else {
+		roots = sceneRoots(part.Doc)
+	}
+
+	for _, n := range roots {
+		child, err := c.copyNode(src, n, 0)
+		if err != nil {
+			return err
+		}
+		root.Children = append(root.Children, child)
+	}
+	return nil
+}
+
+// copyNode copies a node and its children, depth guards against cyclic hierarchies.
+func (c *composer) copyNode(src *source, index int, depth int) (int, error) {
+	if depth > len(src.doc.Nodes) {
+		return 0, errors.New("node hierarchy contains a cycle")
+	}
+	n := src.doc.Nodes[index]
+	node := &gltf.Node{
+		Name:        n.Name,
+		Matrix:      n.Matrix,
+		Rotation:    n.Rotation,
+		Scale:       n.Scale,
+		Translation: n.Translation,
+	}
+	if n.Mesh != nil {
+		m, err := c.copyMesh(src, *n.Mesh)
+		if err != nil {
+			return 0, err
+		}
+		node.Mesh = gltf.Index(m)
+	}
+
+	for _, child := range n.Children {
+		if child < 0 || child >= len(src.doc.Nodes) {
+			return 0, fmt.Errorf("node %d out of range", child)
+		}
+		i, err := c.copyNode(src, child, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		node.Children = append(node.Children, i)
+	}
+
+	c.doc.Nodes = append(c.doc.Nodes, node)
+	return len(c.doc.Nodes) - 1, nil
+}
+
+func (c *composer) copyMesh(src *source, index int) (int, error) {
+	if i, ok := src.meshes[index]; ok {
+		return i, nil
+	}
+	if index < 0 || index >= len(src.doc.Meshes) {
+		return 0, fmt.Errorf("mesh %d out of range", index)
+	}
+
+	m := src.doc.Meshes[index]
+	out := &gltf.Mesh{Name: m.Name}
+	for _, p := range m.Primitives {
+		primitive := &gltf.Primitive{
+			Attributes: gltf.PrimitiveAttributes{},
+			Mode:       p.Mode,
+			Material:   gltf.Index(src.material),
+		}
+		for name, accessor := range p.Attributes {
+			a, err := c.copyAccessor(src, accessor)
+			if err != nil {
+				return 0, err
+			}
+			primitive.Attributes[name] = a
+		}
+		if p.Indices != nil {
+			a, err := c.copyAccessor(src, *p.Indices)
+			if err != nil {
+				return 0, err
+			}
+			primitive.Indices = gltf.Index(a)
+		}
+		out.Primitives = append(out.Primitives, primitive)
+	}
+
+	c.doc.Meshes = append(c.doc.Meshes, out)
+	src.meshes[index] = len(c.doc.Meshes) - 1
+	return src.meshes[index], nil
+}
+
+func (c *composer) copyAccessor(src *source, index int) (int, error) {
+	if i, ok := src.accessors[index]; ok {
+		return i, nil
+	}
+	if index < 0 || index >= len(src.doc.Accessors) {
+		return 0, fmt.Errorf("accessor %d out of range", index)
+	}
+
+	cp := *src.doc.Accessors[index]
+	cp.Extensions = nil
+	if cp.BufferView != nil {
+		bv, err := c.copyBufferView(src, *cp.BufferView)
+		if err != nil {
+			return 0, err
+		}
+		cp.BufferView = gltf.Index(bv)
+	}
+	if cp.Sparse != nil {
+		sparse := *cp.Sparse
+		indices, err := c.copyBufferView(src, sparse.Indices.BufferView)
+		if err != nil {
+			return 0, err
+		}
+		values, err := c.copyBufferView(src, sparse.Values.BufferView)
+		if err != nil {
+			return 0, err
+		}
+		sparse.Indices.BufferView = indices
+		sparse.Values.BufferView = values
+		cp.Sparse = &sparse
+	}
+
+	c.doc.Accessors = append(c.doc.Accessors, &cp)
+	src.accessors[index] = len(c.doc.Accessors) - 1
+	return src.accessors[index], nil
+}
+
+func (c *composer) copyBufferView(src *source, index int) (int, error) {
+	if i, ok := src.bufferViews[index]; ok {
+		return i, nil
+	}
+	if index < 0 || index >= len(src.doc.BufferViews) {
+		return 0, fmt.Errorf("buffer view %d out of range", index)
+	}
+
+	view := src.doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(src.doc.Buffers) {
+		return 0, fmt.Errorf("buffer %d out of range", view.Buffer)
+	}
+	data := src.doc.Buffers[view.Buffer].Data
+	end := view.ByteOffset + view.ByteLength
+	if view.ByteOffset < 0 || end > len(data) {
+		return 0, fmt.Errorf("buffer view %d exceeds its buffer", index)
+	}
+
+	// accessors need their data aligned to the component size, 4 covers all of them
+	for len(c.data)%4 != 0 {
+		c.data = append(c.data, 0)
+	}
+	offset := len(c.data)
+	c.data = append(c.data, data[view.ByteOffset:end]...)
+
+	c.doc.BufferViews = append(c.doc.BufferViews, &gltf.BufferView{
+		Buffer:     0,
+		ByteOffset: offset,
+		ByteLength: view.ByteLength,
+		ByteStride: view.ByteStride,
+		Target:     view.Target,
+	})
+	src.bufferViews[index] = len(c.doc.BufferViews) - 1
+	return src.bufferViews[index], nil
+}
+
+// sceneRoots returns the root nodes of the default scene, or every node without
+// a parent when the document has no scenes.
+func sceneRoots(doc *gltf.Document) []int {
+	if len(doc.Scenes) > 0 {
+		scene := 0
+		if doc.Scene != nil && *doc.Scene >= 0 && *doc.Scene < len(doc.Scenes) {
+			scene = *doc.Scene
+		}
+		return doc.Scenes[scene].Nodes
+	}
+
+	var roots []int
+	for i := range doc.Nodes {
+		if _, ok := parentOf(doc, i); !ok {
+			roots = append(roots, i)
+		}
+	}
+	return roots
+}
+
+func parentOf(doc *gltf.Document, node int) (int, bool) {
+	for i, n := range doc.Nodes {
+		for _, child := range n.Children {
+			if child == node {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,79 @@
+package compose
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"neuroscan/pkg/gltf"
+	"neuroscan/pkg/gltf/modeler"
+)
+
+func triangleDoc(offset float32) *gltf.Document {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, []float32{offset, 0, 0, offset + 1, 0, 0, offset, 1, 0})
+
+	doc := gltf.NewDocument()
+	doc.Buffers = []*gltf.Buffer{{ByteLength: data.Len(), Data: data.Bytes()}}
+	doc.BufferViews = []*gltf.BufferView{{Buffer: 0, ByteLength: data.Len()}}
+	doc.Accessors = []*gltf.Accessor{{BufferView: gltf.Index(0), ComponentType: gltf.ComponentFloat, Type: gltf.AccessorVec3, Count: 3}}
+	doc.Meshes = []*gltf.Mesh{{Primitives: []*gltf.Primitive{{Attributes: gltf.PrimitiveAttributes{gltf.POSITION: 0}}}}}
+	doc.Nodes = []*gltf.Node{
+		{Name: "group", Children: []int{1}, Translation: [3]float64{0, 0, 5}},
+		{Name: "cell", Mesh: gltf.Index(0)},
+	}
+	doc.Scenes[0].Nodes = []int{0}
+	return doc
+}
+
+func TestScene(t *testing.T) {
+	t.Parallel()
+
+	parts := []Part{
+		{Name: "neu_A", Color: [4]float64{1, 0, 0, 1}, Doc: triangleDoc(0)},
+		{Name: "cntct_B", Color: [4]float64{0, 1, 0, 0.5}, Doc: triangleDoc(10), Node: gltf.Index(1)},
+	}
+
+	doc, err := Scene(parts)
+	if err != nil {
+		t.Fatalf("Expected scene to be composed, got %v", err)
+	}
+
+	if len(doc.Scenes[0].Nodes) != 2 {
+		t.Fatalf("Expected 2 root nodes, got %d", len(doc.Scenes[0].Nodes))
+	}
+
+	for i, part := range parts {
+		root := doc.Nodes[doc.Scenes[0].Nodes[i]]
+		if root.Name != part.Name {
+			t.Errorf("Expected root node %d to be named %s, got %s", i, part.Name, root.Name)
+		}
+		color := doc.Materials[i].PBRMetallicRoughness.BaseColorFactorOrDefault()
+		if color != part.Color {
+			t.Errorf("Expected material %d color %v, got %v", i, part.Color, color)
+		}
+	}
+
+	// the split node keeps the transform of the group it was taken out of
+	second := doc.Nodes[doc.Scenes[0].Nodes[1]]
+	if second.Matrix[14] != 5 {
+		t.Errorf("Expected parent translation to be baked into the part node, got %v", second.Matrix)
+	}
+
+	var buf bytes.Buffer
+	if err := gltf.NewEncoder(&buf).Encode(doc); err != nil {
+		t.Fatalf("Expected scene to be encoded, got %v", err)
+	}
+	decoded := new(gltf.Document)
+	if err := gltf.NewDecoder(&buf).Decode(decoded); err != nil {
+		t.Fatalf("Expected scene to be decoded, got %v", err)
+	}
+
+	positions, err := modeler.ReadPosition(decoded, decoded.Accessors[1])
+	if err != nil {
+		t.Fatalf("Expected positions to be read, got %v", err)
+	}
+	if positions[0] != [3]float32{10, 0, 0} {
+		t.Errorf("Expected second part positions to be copied, got %v", positions)
+	}
+}
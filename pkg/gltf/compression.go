@@ -0,0 +1,408 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"neuroscan/pkg/gltf/meshopt"
+)
+
+const (
+	// ExtMeshoptCompression compresses buffer views with the meshoptimizer codecs.
+	ExtMeshoptCompression = "EXT_meshopt_compression"
+	// ExtDracoMeshCompression compresses primitives with Draco. It is recognized so documents that
+	// require it are rejected, decoding it is still to do.
+	ExtDracoMeshCompression = "KHR_draco_mesh_compression"
+)
+
+// supportedExtensions are the required extensions a document can be decoded with.
+var supportedExtensions = []string{ExtMeshoptCompression}
+
+// ErrUnsupportedExtension is returned when a document requires an extension that can't be decoded.
+var ErrUnsupportedExtension = errors.New("gltf: unsupported required extension")
+
+// MeshoptMode is the codec a compressed buffer view was encoded with.
+type MeshoptMode string
+
+const (
+	MeshoptAttributes MeshoptMode = "ATTRIBUTES" // vertex attributes, see meshopt.DecodeVertexBuffer
+	MeshoptTriangles  MeshoptMode = "TRIANGLES"  // triangle list indices, see meshopt.DecodeIndexBuffer
+	MeshoptIndices    MeshoptMode = "INDICES"    // indices of any other mode, see meshopt.DecodeIndexSequence
+)
+
+// MeshoptFilter is applied to vertex attributes after they are decoded.
+type MeshoptFilter string
+
+const (
+	MeshoptFilterNone        MeshoptFilter = "NONE"
+	MeshoptFilterOctahedral  MeshoptFilter = "OCTAHEDRAL"
+	MeshoptFilterQuaternion  MeshoptFilter = "QUATERNION"
+	MeshoptFilterExponential MeshoptFilter = "EXPONENTIAL"
+)
+
+// MeshoptCompression is the EXT_meshopt_compression extension of a buffer view. The view keeps
+// its place in an uncompressed fallback buffer, while the extension points at the compressed data.
+type MeshoptCompression struct {
+	Buffer     int           `json:"buffer"`
+	ByteOffset int           `json:"byteOffset,omitempty"`
+	ByteLength int           `json:"byteLength"`
+	ByteStride int           `json:"byteStride"`
+	Count      int           `json:"count"`
+	Mode       MeshoptMode   `json:"mode"`
+	Filter     MeshoptFilter `json:"filter,omitempty"`
+}
+
+// MeshoptFallback is the EXT_meshopt_compression extension of a buffer. A fallback buffer only
+// reserves the layout of the compressed views and usually has no URI or data.
+type MeshoptFallback struct {
+	Fallback bool `json:"fallback,omitempty"`
+}
+
+func init() {
+	// the same key is used on buffers and buffer views, only views have a mode
+	RegisterExtension(ExtMeshoptCompression, func(data []byte) (any, error) {
+		var probe struct {
+			Mode *MeshoptMode `json:"mode"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return nil, err
+		}
+		if probe.Mode == nil {
+			fallback := new(MeshoptFallback)
+			return fallback, json.Unmarshal(data, fallback)
+		}
+		compression := new(MeshoptCompression)
+		return compression, json.Unmarshal(data, compression)
+	})
+}
+
+// isMeshoptFallback reports whether the buffer only reserves space for decompressed views.
+func isMeshoptFallback(buffer *Buffer) bool {
+	fallback, ok := buffer.Extensions[ExtMeshoptCompression].(*MeshoptFallback)
+	return ok && fallback.Fallback
+}
+
+// checkRequiredExtensions fails on documents that can't be read without an unsupported extension,
+// such as Draco compressed meshes whose accessors have no data of their own.
+func checkRequiredExtensions(doc *Document) error {
+	for _, name := range doc.ExtensionsRequired {
+		if !slices.Contains(supportedExtensions, name) {
+			return fmt.Errorf("%w %s", ErrUnsupportedExtension, name)
+		}
+	}
+	return nil
+}
+
+// decompress decodes every EXT_meshopt_compression buffer view into its fallback buffer, after
+// which the document reads like an uncompressed one. The compressed buffers are left in place.
+func decompress(doc *Document) error {
+	decompressed := false
+	for i, view := range doc.BufferViews {
+		compression, ok := view.Extensions[ExtMeshoptCompression].(*MeshoptCompression)
+		if !ok {
+			continue
+		}
+		if view.Buffer < 0 || view.Buffer >= len(doc.Buffers) {
+			return fmt.Errorf("gltf: buffer view %d points at missing buffer %d", i, view.Buffer)
+		}
+
+		target := doc.Buffers[view.Buffer]
+		if !isMeshoptFallback(target) && len(target.Data) > 0 {
+			// the fallback holds real data, there is nothing to decompress
+			delete(view.Extensions, ExtMeshoptCompression)
+			continue
+		}
+		if target.Data == nil {
+			length, err := fallbackLength(doc, view.Buffer)
+			if err != nil {
+				return fmt.Errorf("gltf: buffer %d: %w", view.Buffer, err)
+			}
+			target.Data = make([]byte, length)
+		}
+
+		if err := decompressView(doc, view, compression, target.Data); err != nil {
+			return fmt.Errorf("gltf: buffer view %d: %w", i, err)
+		}
+		delete(view.Extensions, ExtMeshoptCompression)
+		decompressed = true
+	}
+
+	if !decompressed {
+		return nil
+	}
+
+	for _, buffer := range doc.Buffers {
+		if isMeshoptFallback(buffer) {
+			delete(buffer.Extensions, ExtMeshoptCompression)
+		}
+	}
+	doc.ExtensionsUsed = slices.DeleteFunc(doc.ExtensionsUsed, func(name string) bool { return name == ExtMeshoptCompression })
+	doc.ExtensionsRequired = slices.DeleteFunc(doc.ExtensionsRequired, func(name string) bool { return name == ExtMeshoptCompression })
+	return nil
+}
+
+// fallbackLength checks the byteLength of a fallback buffer before it is allocated. It can't be
+// negative, nor larger than the compressed views it holds with their padding.
+func fallbackLength(doc *Document, buffer int) (int, error) {
+	length := doc.Buffers[buffer].ByteLength
+	if length < 0 {
+		return 0, fmt.Errorf("negative byteLength %d", length)
+	}
+
+	held := 0
+	for _, view := range doc.BufferViews {
+		if _, ok := view.Extensions[ExtMeshoptCompression].(*MeshoptCompression); !ok || view.Buffer != buffer {
+			continue
+		}
+		if view.ByteLength < 0 || view.ByteLength > length {
+			return 0, fmt.Errorf("compressed buffer view of %d bytes doesn't fit the %d bytes of the buffer", view.ByteLength, length)
+		}
+		// subtracting keeps the sum from overflowing
+		if length-held-view.ByteLength <= 3 {
+			return length, nil
+		}
+		held += view.ByteLength + padding(view.ByteLength)
+	}
+
+	return 0, fmt.Errorf("byteLength %d is larger than the %d bytes of its compressed buffer views", length, held)
+}
+
+func decompressView(doc *Document, view *BufferView, compression *MeshoptCompression, target []byte) error {
+	if compression.Buffer < 0 || compression.Buffer >= len(doc.Buffers) {
+		return fmt.Errorf("compressed data points at missing buffer %d", compression.Buffer)
+	}
+
+	source := doc.Buffers[compression.Buffer].Data
+	if compression.ByteOffset < 0 || compression.ByteLength < 0 || compression.ByteOffset > len(source) || compression.ByteLength > len(source)-compression.ByteOffset {
+		return errors.New("compressed data is outside of its buffer")
+	}
+	src := source[compression.ByteOffset : compression.ByteOffset+compression.ByteLength]
+
+	if compression.ByteStride <= 0 || compression.Count < 0 {
+		return fmt.Errorf("invalid count %d or byteStride %d", compression.Count, compression.ByteStride)
+	}
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset > len(target) || view.ByteLength > len(target)-view.ByteOffset {
+		return errors.New("buffer view is outside of its fallback buffer")
+	}
+	// dividing keeps count * byteStride from overflowing
+	if compression.Count > view.ByteLength/compression.ByteStride {
+		return errors.New("decompressed data doesn't fit the buffer view")
+	}
+	dst := target[view.ByteOffset : view.ByteOffset+compression.Count*compression.ByteStride]
+
+	switch compression.Mode {
+	case MeshoptAttributes:
+		if err := meshopt.DecodeVertexBuffer(dst, compression.Count, compression.ByteStride, src); err != nil {
+			return err
+		}
+	case MeshoptTriangles:
+		return meshopt.DecodeIndexBuffer(dst, compression.Count, compression.ByteStride, src)
+	case MeshoptIndices:
+		return meshopt.DecodeIndexSequence(dst, compression.Count, compression.ByteStride, src)
+	default:
+		return fmt.Errorf("unknown meshopt mode %q", compression.Mode)
+	}
+
+	switch compression.Filter {
+	case "", MeshoptFilterNone:
+		return nil
+	case MeshoptFilterOctahedral:
+		return meshopt.DecodeFilterOct(dst, compression.Count, compression.ByteStride)
+	case MeshoptFilterQuaternion:
+		return meshopt.DecodeFilterQuat(dst, compression.Count, compression.ByteStride)
+	case MeshoptFilterExponential:
+		return meshopt.DecodeFilterExp(dst, compression.Count, compression.ByteStride)
+	}
+	return fmt.Errorf("unknown meshopt filter %q", compression.Filter)
+}
+
+// CompressMeshopt returns a copy of doc whose vertex and index buffer views are compressed with
+// EXT_meshopt_compression. The compressed data and the views that can't be compressed, such as
+// images, are packed into the first buffer. The second is a fallback buffer without data that
+// keeps the uncompressed layout. Compression is lossless, no filters are applied.
+func CompressMeshopt(doc *Document) (*Document, error) {
+	modes := meshoptModes(doc)
+
+	packed := &Buffer{}
+	fallback := &Buffer{Extensions: Extensions{ExtMeshoptCompression: &MeshoptFallback{Fallback: true}}}
+	views := make([]*BufferView, len(doc.BufferViews))
+	compressed := 0
+
+	for i, view := range doc.BufferViews {
+		if view.Buffer < 0 || view.Buffer >= len(doc.Buffers) {
+			return nil, fmt.Errorf("gltf: buffer view %d points at missing buffer %d", i, view.Buffer)
+		}
+		data := doc.Buffers[view.Buffer].Data
+		if view.ByteOffset < 0 || view.ByteOffset+view.ByteLength > len(data) {
+			return nil, fmt.Errorf("gltf: buffer view %d is outside of its loaded buffer", i)
+		}
+		data = data[view.ByteOffset : view.ByteOffset+view.ByteLength]
+
+		cp := *view
+		cp.Extensions = nil
+		views[i] = &cp
+
+		mode, ok := modes[i]
+		var stream []byte
+		var err error
+		if ok {
+			count := view.ByteLength / mode.stride
+			switch mode.mode {
+			case MeshoptAttributes:
+				stream, err = meshopt.EncodeVertexBuffer(data, count, mode.stride)
+			case MeshoptTriangles:
+				stream, err = meshopt.EncodeIndexBuffer(readIndices(data, mode.stride))
+			case MeshoptIndices:
+				stream = meshopt.EncodeIndexSequence(readIndices(data, mode.stride))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("gltf: buffer view %d: %w", i, err)
+			}
+		}
+
+		if stream == nil || len(stream) >= len(data) {
+			// not worth compressing, the view moves into the packed buffer as is
+			cp.Buffer, cp.ByteOffset = 0, appendAligned(packed, data)
+			continue
+		}
+
+		cp.Buffer, cp.ByteOffset = 1, fallback.ByteLength
+		fallback.ByteLength += view.ByteLength + padding(view.ByteLength)
+		cp.Extensions = Extensions{ExtMeshoptCompression: &MeshoptCompression{
+			Buffer:     0,
+			ByteOffset: appendAligned(packed, stream),
+			ByteLength: len(stream),
+			ByteStride: mode.stride,
+			Count:      view.ByteLength / mode.stride,
+			Mode:       mode.mode,
+		}}
+		compressed++
+	}
+
+	out := *doc
+	out.BufferViews = views
+	out.Buffers = []*Buffer{packed}
+	packed.ByteLength = len(packed.Data)
+	if compressed > 0 {
+		out.Buffers = append(out.Buffers, fallback)
+		out.ExtensionsUsed = appendMissing(doc.ExtensionsUsed, ExtMeshoptCompression)
+		out.ExtensionsRequired = appendMissing(doc.ExtensionsRequired, ExtMeshoptCompression)
+	}
+	if len(packed.Data) == 0 {
+		// a document without buffer views has nothing to pack
+		out.Buffers = nil
+	}
+	return &out, nil
+}
+
+type meshoptMode struct {
+	mode   MeshoptMode
+	stride int
+}
+
+// meshoptModes picks the codec of each buffer view from the accessors that read it. Views read
+// by accessors of different kinds, or with a layout the codecs can't hold, are left out.
+func meshoptModes(doc *Document) map[int]meshoptMode {
+	modes := map[int]meshoptMode{}
+	rejected := map[int]bool{}
+
+	use := func(accessor int, mode MeshoptMode) {
+		if accessor < 0 || accessor >= len(doc.Accessors) {
+			return
+		}
+		a := doc.Accessors[accessor]
+		if a.BufferView == nil || a.Sparse != nil {
+			return
+		}
+		i := *a.BufferView
+		if i < 0 || i >= len(doc.BufferViews) || rejected[i] {
+			return
+		}
+		view := doc.BufferViews[i]
+
+		candidate := meshoptMode{mode: mode, stride: view.ByteStride}
+		if mode == MeshoptAttributes {
+			if candidate.stride == 0 {
+				candidate.stride = SizeOfElement(a.ComponentType, a.Type)
+			}
+			if candidate.stride%4 != 0 || candidate.stride > 256 {
+				rejected[i] = true
+				return
+			}
+		} else {
+			candidate.stride = a.ComponentType.ByteSize()
+			if a.ByteOffset != 0 || (candidate.stride != 2 && candidate.stride != 4) || a.Count*candidate.stride != view.ByteLength {
+				rejected[i] = true
+				return
+			}
+			if mode == MeshoptTriangles && a.Count%3 != 0 {
+				candidate.mode = MeshoptIndices
+			}
+		}
+
+		if previous, ok := modes[i]; (ok && previous != candidate) || view.ByteLength%candidate.stride != 0 {
+			delete(modes, i)
+			rejected[i] = true
+			return
+		}
+		modes[i] = candidate
+	}
+
+	for _, mesh := range doc.Meshes {
+		for _, primitive := range mesh.Primitives {
+			for _, accessor := range primitive.Attributes {
+				use(accessor, MeshoptAttributes)
+			}
+			for _, target := range primitive.Targets {
+				for _, accessor := range target {
+					use(accessor, MeshoptAttributes)
+				}
+			}
+			if primitive.Indices != nil {
+				mode := MeshoptIndices
+				if primitive.Mode == PrimitiveTriangles {
+					mode = MeshoptTriangles
+				}
+				use(*primitive.Indices, mode)
+			}
+		}
+	}
+
+	// views read by anything else, like images or animations, are kept as they are
+	for _, image := range doc.Images {
+		if image.BufferView != nil {
+			delete(modes, *image.BufferView)
+		}
+	}
+	return modes
+}
+
+func readIndices(data []byte, size int) []uint32 {
+	indices := make([]uint32, len(data)/size)
+	for i := range indices {
+		if size == 2 {
+			indices[i] = uint32(binary.LittleEndian.Uint16(data[i*2:]))
+		} else {
+			indices[i] = binary.LittleEndian.Uint32(data[i*4:])
+		}
+	}
+	return indices
+}
+
+// appendAligned appends data to the buffer at a 4 byte boundary and returns its offset.
+func appendAligned(buffer *Buffer, data []byte) int {
+	buffer.Data = append(buffer.Data, make([]byte, padding(len(buffer.Data)))...)
+	offset := len(buffer.Data)
+	buffer.Data = append(buffer.Data, data...)
+	return offset
+}
+
+func appendMissing(names []string, name string) []string {
+	if slices.Contains(names, name) {
+		return names
+	}
+	return append(slices.Clone(names), name)
+}
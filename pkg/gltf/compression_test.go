@@ -0,0 +1,186 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// gridDocument is a 10x10 quad grid with float positions and ushort indices.
+func gridDocument() *Document {
+	var positions, indices []byte
+	for y := range 11 {
+		for x := range 11 {
+			for _, v := range []float32{float32(x), float32(y), float32(x*y) / 10} {
+				positions = binary.LittleEndian.AppendUint32(positions, math.Float32bits(v))
+			}
+		}
+	}
+	for y := range 10 {
+		for x := range 10 {
+			a := uint16(y*11 + x)
+			for _, i := range []uint16{a, a + 1, a + 11, a + 1, a + 12, a + 11} {
+				indices = binary.LittleEndian.AppendUint16(indices, i)
+			}
+		}
+	}
+
+	doc := NewDocument()
+	data := append(positions, indices...)
+	doc.Buffers = []*Buffer{{ByteLength: len(data), Data: data}}
+	doc.BufferViews = []*BufferView{
+		{Buffer: 0, ByteLength: len(positions), Target: TargetArrayBuffer},
+		{Buffer: 0, ByteOffset: len(positions), ByteLength: len(indices), Target: TargetElementArrayBuffer},
+	}
+	doc.Accessors = []*Accessor{
+		{BufferView: Index(0), ComponentType: ComponentFloat, Type: AccessorVec3, Count: 121, Min: []float64{0, 0, 0}, Max: []float64{10, 10, 10}},
+		{BufferView: Index(1), ComponentType: ComponentUshort, Type: AccessorScalar, Count: 600},
+	}
+	doc.Meshes = []*Mesh{{Primitives: []*Primitive{{Attributes: PrimitiveAttributes{POSITION: 0}, Indices: Index(1)}}}}
+	doc.Nodes = []*Node{{Name: "ADAL", Mesh: Index(0)}}
+	doc.Scenes[0].Nodes = []int{0}
+	return doc
+}
+
+func viewData(doc *Document, view int) []byte {
+	v := doc.BufferViews[view]
+	return doc.Buffers[v.Buffer].Data[v.ByteOffset : v.ByteOffset+v.ByteLength]
+}
+
+// triangles reads ushort indices with each triangle rotated to start at its lowest index.
+func triangles(data []byte) [][3]uint16 {
+	var tris [][3]uint16
+	for i := 0; i+6 <= len(data); i += 6 {
+		t := [3]uint16{
+			binary.LittleEndian.Uint16(data[i:]),
+			binary.LittleEndian.Uint16(data[i+2:]),
+			binary.LittleEndian.Uint16(data[i+4:]),
+		}
+		for t[0] != min(t[0], t[1], t[2]) {
+			t = [3]uint16{t[1], t[2], t[0]}
+		}
+		tris = append(tris, t)
+	}
+	return tris
+}
+
+func TestMeshoptRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, asBinary := range []bool{true, false} {
+		doc := gridDocument()
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.AsBinary = asBinary
+		enc.Meshopt = true
+		if err := enc.Encode(doc); err != nil {
+			t.Fatalf("Expected document to be encoded, got %v", err)
+		}
+
+		if !bytes.Contains(buf.Bytes(), []byte(`"extensionsRequired":["EXT_meshopt_compression"]`)) {
+			t.Errorf("Expected the encoded document to require %s", ExtMeshoptCompression)
+		}
+		if uncompressed := len(doc.Buffers[0].Data); buf.Len() >= uncompressed && asBinary {
+			t.Errorf("Expected the GLB to be smaller than the %d uncompressed bytes, got %d", uncompressed, buf.Len())
+		}
+
+		got := new(Document)
+		if err := NewDecoder(&buf).Decode(got); err != nil {
+			t.Fatalf("Expected document to be decoded, got %v", err)
+		}
+
+		if slices.Contains(got.ExtensionsUsed, ExtMeshoptCompression) || slices.Contains(got.ExtensionsRequired, ExtMeshoptCompression) {
+			t.Errorf("Expected %s to be removed once decoded, got %v", ExtMeshoptCompression, got.ExtensionsUsed)
+		}
+		if !bytes.Equal(viewData(got, 0), viewData(doc, 0)) {
+			t.Error("Expected positions to round trip")
+		}
+		if !slices.Equal(triangles(viewData(got, 1)), triangles(viewData(doc, 1))) {
+			t.Error("Expected triangles to round trip")
+		}
+		if len(Validate(got).Errors()) != 0 {
+			t.Errorf("Expected the decoded document to be valid, got %v", Validate(got))
+		}
+	}
+}
+
+func TestDecodeUnsupportedRequiredExtension(t *testing.T) {
+	t.Parallel()
+
+	r := strings.NewReader(`{"asset":{"version":"2.0"},"extensionsUsed":["KHR_draco_mesh_compression"],"extensionsRequired":["KHR_draco_mesh_compression"]}`)
+	err := NewDecoder(r).Decode(new(Document))
+	if !errors.Is(err, ErrUnsupportedExtension) {
+		t.Errorf("Expected %v, got %v", ErrUnsupportedExtension, err)
+	}
+}
+
+func TestDecodeMalformedMeshopt(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.AsBinary = false
+	enc.Meshopt = true
+	if err := enc.Encode(gridDocument()); err != nil {
+		t.Fatalf("Expected document to be encoded, got %v", err)
+	}
+
+	tests := map[string]func(doc map[string]any){
+		"huge fallback": func(doc map[string]any) {
+			doc["buffers"].([]any)[1].(map[string]any)["byteLength"] = json.Number("4611686018427387904")
+		},
+		"negative fallback": func(doc map[string]any) {
+			doc["buffers"].([]any)[1].(map[string]any)["byteLength"] = json.Number("-4")
+		},
+		"fallback larger than its views": func(doc map[string]any) {
+			doc["buffers"].([]any)[1].(map[string]any)["byteLength"] = json.Number("1000000")
+		},
+		"negative stride": func(doc map[string]any) {
+			meshoptExtension(doc, 0)["byteStride"] = json.Number("-4")
+		},
+		"negative count": func(doc map[string]any) {
+			meshoptExtension(doc, 0)["count"] = json.Number("-1")
+		},
+		"huge count": func(doc map[string]any) {
+			meshoptExtension(doc, 0)["count"] = json.Number("4611686018427387905")
+		},
+		"negative compressed length": func(doc map[string]any) {
+			meshoptExtension(doc, 1)["byteLength"] = json.Number("-4")
+		},
+		"negative view offset": func(doc map[string]any) {
+			doc["bufferViews"].([]any)[1].(map[string]any)["byteOffset"] = json.Number("-4")
+		},
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dec := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+			dec.UseNumber()
+			var doc map[string]any
+			if err := dec.Decode(&doc); err != nil {
+				t.Fatalf("Expected the encoded document to be JSON, got %v", err)
+			}
+			tamper(doc)
+
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatalf("Expected the tampered document to be encoded, got %v", err)
+			}
+			if err := NewDecoder(bytes.NewReader(data)).Decode(new(Document)); err == nil {
+				t.Error("Expected the malformed compressed data to be rejected")
+			}
+		})
+	}
+}
+
+func meshoptExtension(doc map[string]any, view int) map[string]any {
+	extensions := doc["bufferViews"].([]any)[view].(map[string]any)["extensions"].(map[string]any)
+	return extensions[ExtMeshoptCompression].(map[string]any)
+}
@@ -11,6 +11,7 @@ import (
 	"io/fs"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -30,6 +31,26 @@ func Open(name string) (*Document, error) {
 	return doc, err
 }
 
+// OpenFS will open a glTF or GLB file specified by name from fsys and return the Document.
+// External buffers are resolved relative to the directory of name inside fsys.
+func OpenFS(fsys fs.FS, name string) (*Document, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dir, err := fs.Sub(fsys, path.Dir(name))
+	if err != nil {
+		return nil, err
+	}
+	dec := NewDecoderFS(f, dir)
+	doc := new(Document)
+	if err = dec.Decode(doc); err != nil {
+		doc = nil
+	}
+	return doc, err
+}
+
 // A Decoder reads and decodes glTF and GLB values from an input stream.
 //
 // Only buffers with relative URIs will be read from Fsys.
@@ -77,8 +98,12 @@ func (d *Decoder) Decode(doc *Document) error {
 		}
 	}
 
+	if err := checkRequiredExtensions(doc); err != nil {
+		return err
+	}
+
 	var externalBufferIndex = 0
-	if isBinary && len(doc.Buffers) > 0 && doc.Buffers[0].URI == "" {
+	if isBinary && len(doc.Buffers) > 0 && doc.Buffers[0].URI == "" && !isMeshoptFallback(doc.Buffers[0]) {
 		externalBufferIndex = 1
 		if err := d.decodeBinaryBuffer(doc.Buffers[0]); err != nil {
 			return err
@@ -89,7 +114,7 @@ func (d *Decoder) Decode(doc *Document) error {
 			return err
 		}
 	}
-	return nil
+	return decompress(doc)
 }
 
 func (d *Decoder) decodeDocument(doc *Document) (bool, error) {
@@ -148,6 +173,10 @@ func (d *Decoder) decodeBuffer(buffer *Buffer) error {
 		return err
 	}
 	if buffer.URI == "" {
+		if isMeshoptFallback(buffer) {
+			// filled in when the compressed views are decoded
+			return nil
+		}
 		return errors.New("gltf: buffer without URI")
 	}
 	var err error
@@ -220,3 +249,21 @@ func sanitizeURI(uri string) (string, bool) {
 	}
 	return uri, true
 }
+
+// BinaryChunkOffset reads the GLB header from r and returns the offset at which
+// the data of the BIN chunk starts. ok is false when r is not a GLB stream.
+func BinaryChunkOffset(r io.Reader) (offset int64, ok bool, err error) {
+	var header glbHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	if header.Magic != glbHeaderMagic {
+		return 0, false, nil
+	}
+	// the BIN chunk header follows the JSON chunk, its data follows the header
+	offset = int64(binary.Size(header)) + int64(header.JSONHeader.Length) + int64(binary.Size(chunkHeader{}))
+	return offset, true, nil
+}
@@ -0,0 +1,213 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CreateFS is the interface implemented by a file system that can also create files.
+// The Encoder uses it to write external buffers.
+type CreateFS interface {
+	Create(name string) (io.WriteCloser, error)
+}
+
+// dirFS creates files relative to a directory on the local disk.
+type dirFS string
+
+func (dir dirFS) Create(name string) (io.WriteCloser, error) {
+	full := filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(full)
+}
+
+// Save will save a document as a glTF file specified by name.
+// Buffers with a relative URI are written next to it, buffers without one are embedded.
+func Save(doc *Document, name string) error {
+	return save(doc, name, false)
+}
+
+// SaveBinary will save a document as a GLB file specified by name.
+// The first buffer is stored in the BIN chunk when it has no URI.
+func SaveBinary(doc *Document, name string) error {
+	return save(doc, name, true)
+}
+
+func save(doc *Document, name string, asBinary bool) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	e := NewEncoderFS(f, dirFS(filepath.Dir(name)))
+	e.AsBinary = asBinary
+	if err := e.Encode(doc); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// An Encoder writes a glTF or GLB document to an output stream.
+//
+// Only buffers with relative URIs will be written to Fsys.
+// When Fsys is nil external buffers are left untouched.
+type Encoder struct {
+	AsBinary bool
+	// Meshopt compresses vertex and index data with EXT_meshopt_compression, see CompressMeshopt
+	Meshopt bool
+	Fsys    CreateFS
+	w       io.Writer
+	indent  string
+}
+
+// NewEncoder returns a new encoder that writes GLB to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		AsBinary: true,
+		w:        w,
+	}
+}
+
+// NewEncoderFS returns a new encoder that writes GLB to w and external buffers to fsys.
+func NewEncoderFS(w io.Writer, fsys CreateFS) *Encoder {
+	return &Encoder{
+		AsBinary: true,
+		Fsys:     fsys,
+		w:        w,
+	}
+}
+
+// SetIndent indents the JSON of glTF documents. It has no effect on GLB output.
+func (e *Encoder) SetIndent(indent string) {
+	e.indent = indent
+}
+
+// Encode writes the encoding of doc to the stream.
+// doc is not modified; buffers are copied before their URIs are rewritten.
+func (e *Encoder) Encode(doc *Document) error {
+	if e.Meshopt {
+		compressed, err := CompressMeshopt(doc)
+		if err != nil {
+			return err
+		}
+		doc = compressed
+	}
+
+	out := *doc
+	out.Buffers = make([]*Buffer, len(doc.Buffers))
+	for i, b := range doc.Buffers {
+		cp := *b
+		if len(cp.Data) > 0 {
+			cp.ByteLength = len(cp.Data)
+		}
+		out.Buffers[i] = &cp
+	}
+
+	var binChunk []byte
+	externalBufferIndex := 0
+	if e.AsBinary && len(out.Buffers) > 0 && (out.Buffers[0].URI == "" || out.Buffers[0].IsEmbeddedResource()) {
+		binChunk = out.Buffers[0].Data
+		out.Buffers[0].URI = ""
+		externalBufferIndex = 1
+	}
+	for _, b := range out.Buffers[externalBufferIndex:] {
+		if err := e.encodeBuffer(b); err != nil {
+			return err
+		}
+	}
+
+	if e.AsBinary {
+		return e.encodeBinary(&out, binChunk)
+	}
+	enc := json.NewEncoder(e.w)
+	enc.SetEscapeHTML(false)
+	if e.indent != "" {
+		enc.SetIndent("", e.indent)
+	}
+	return enc.Encode(&out)
+}
+
+func (e *Encoder) encodeBuffer(buffer *Buffer) error {
+	if buffer.URI == "" && len(buffer.Data) == 0 && isMeshoptFallback(buffer) {
+		return nil
+	}
+	if buffer.URI == "" || buffer.IsEmbeddedResource() {
+		if len(buffer.Data) == 0 {
+			return errors.New("gltf: buffer without URI or data")
+		}
+		buffer.EmbeddedResource()
+		return nil
+	}
+	if len(buffer.Data) == 0 || e.Fsys == nil {
+		return nil
+	}
+	if err := validateBufferURI(buffer.URI); err != nil {
+		return err
+	}
+	if strings.Contains(buffer.URI, "://") {
+		// absolute URIs point somewhere we can't write to
+		return nil
+	}
+	w, err := e.Fsys.Create(buffer.URI)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buffer.Data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (e *Encoder) encodeBinary(doc *Document, binChunk []byte) error {
+	var js bytes.Buffer
+	enc := json.NewEncoder(&js)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	jsonData := bytes.TrimRight(js.Bytes(), "\n")
+	jsonData = append(jsonData, bytes.Repeat([]byte{' '}, padding(len(jsonData)))...)
+
+	binPadding := padding(len(binChunk))
+	header := glbHeader{
+		Magic:      glbHeaderMagic,
+		Version:    2,
+		Length:     uint32(binary.Size(glbHeader{}) + len(jsonData)),
+		JSONHeader: chunkHeader{Length: uint32(len(jsonData)), Type: glbChunkJSON},
+	}
+	if len(binChunk) > 0 {
+		header.Length += uint32(binary.Size(chunkHeader{}) + len(binChunk) + binPadding)
+	}
+
+	if err := binary.Write(e.w, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(jsonData); err != nil {
+		return err
+	}
+	if len(binChunk) == 0 {
+		return nil
+	}
+	binHeader := chunkHeader{Length: uint32(len(binChunk) + binPadding), Type: glbChunkBIN}
+	if err := binary.Write(e.w, binary.LittleEndian, &binHeader); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(binChunk); err != nil {
+		return err
+	}
+	_, err := e.w.Write(make([]byte, binPadding))
+	return err
+}
+
+// padding returns the bytes needed to align n to 4 bytes, as GLB chunks require.
+func padding(n int) int {
+	return (4 - n%4) % 4
+}
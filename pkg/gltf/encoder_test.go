@@ -0,0 +1,114 @@
+package gltf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testDocument() *Document {
+	data := []byte{0, 0, 0, 0, 0, 0, 128, 63, 0, 0, 0, 0, 1}
+	doc := NewDocument()
+	doc.Buffers = []*Buffer{{ByteLength: len(data), Data: data}}
+	doc.BufferViews = []*BufferView{{Buffer: 0, ByteLength: 12, Target: TargetArrayBuffer}}
+	doc.Accessors = []*Accessor{{BufferView: Index(0), ComponentType: ComponentFloat, Type: AccessorVec3, Count: 1}}
+	doc.Meshes = []*Mesh{{Name: "cube", Primitives: []*Primitive{{Attributes: PrimitiveAttributes{POSITION: 0}}}}}
+	doc.Nodes = []*Node{{Name: "ADAL", Mesh: Index(0), Translation: [3]float64{1, 2, 3}}}
+	doc.Scenes[0].Nodes = []int{0}
+	return doc
+}
+
+func assertRoundTrip(t *testing.T, expected, got *Document) {
+	t.Helper()
+
+	if len(got.Buffers) != len(expected.Buffers) {
+		t.Fatalf("Expected %d buffers, got %d", len(expected.Buffers), len(got.Buffers))
+	}
+	for i := range expected.Buffers {
+		if !bytes.Equal(got.Buffers[i].Data, expected.Buffers[i].Data) {
+			t.Errorf("Expected buffer %d data %v, got %v", i, expected.Buffers[i].Data, got.Buffers[i].Data)
+		}
+	}
+	if len(got.Nodes) != 1 || got.Nodes[0].Name != "ADAL" {
+		t.Fatalf("Expected node ADAL, got %v", got.Nodes)
+	}
+	if got.Nodes[0].Translation != [3]float64{1, 2, 3} {
+		t.Errorf("Expected translation [1 2 3], got %v", got.Nodes[0].Translation)
+	}
+	if got.Nodes[0].MatrixOrDefault() != DefaultMatrix || got.Nodes[0].ScaleOrDefault() != DefaultScale {
+		t.Errorf("Expected default matrix and scale, got %v and %v", got.Nodes[0].Matrix, got.Nodes[0].Scale)
+	}
+	if got.Accessors[0].Type != AccessorVec3 || got.Accessors[0].ComponentType != ComponentFloat {
+		t.Errorf("Expected VEC3 float accessor, got %d %d", got.Accessors[0].Type, got.Accessors[0].ComponentType)
+	}
+}
+
+func TestEncodeBinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	doc := testDocument()
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(doc); err != nil {
+		t.Fatalf("Expected document to be encoded, got %v", err)
+	}
+
+	if buf.Len()%4 != 0 {
+		t.Errorf("Expected GLB length to be 4 byte aligned, got %d", buf.Len())
+	}
+
+	got := new(Document)
+	if err := NewDecoder(&buf).Decode(got); err != nil {
+		t.Fatalf("Expected document to be decoded, got %v", err)
+	}
+	assertRoundTrip(t, doc, got)
+
+	if doc.Buffers[0].URI != "" {
+		t.Errorf("Expected source document to be left untouched, got uri %q", doc.Buffers[0].URI)
+	}
+}
+
+func TestEncodeEmbeddedRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	doc := testDocument()
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.AsBinary = false
+	if err := enc.Encode(doc); err != nil {
+		t.Fatalf("Expected document to be encoded, got %v", err)
+	}
+
+	if strings.Contains(buf.String(), `"matrix"`) || strings.Contains(buf.String(), `"scale"`) {
+		t.Errorf("Expected default transforms to be omitted, got %s", buf.String())
+	}
+
+	got := new(Document)
+	if err := NewDecoder(&buf).Decode(got); err != nil {
+		t.Fatalf("Expected document to be decoded, got %v", err)
+	}
+	assertRoundTrip(t, doc, got)
+}
+
+func TestSaveExternalRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	doc := testDocument()
+	doc.Buffers[0].URI = "bin/ADAL.bin"
+	dir := t.TempDir()
+	name := filepath.Join(dir, "ADAL.gltf")
+	if err := Save(doc, name); err != nil {
+		t.Fatalf("Expected document to be saved, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "bin", "ADAL.bin")); err != nil {
+		t.Fatalf("Expected external buffer to be written, got %v", err)
+	}
+
+	got, err := Open(name)
+	if err != nil {
+		t.Fatalf("Expected document to be opened, got %v", err)
+	}
+	assertRoundTrip(t, doc, got)
+}
@@ -2,6 +2,7 @@ package gltf
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"strings"
 	"sync"
@@ -210,6 +211,34 @@ func (n *Node) TranslationOrDefault() [3]float64 {
 	return n.Translation
 }
 
+// MarshalJSON marshal the node omitting the transform properties that hold default values.
+// Writing a zero matrix or scale would otherwise collapse the node in other readers.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	type alias Node
+	tmp := &struct {
+		Matrix      *[16]float64 `json:"matrix,omitempty"`
+		Rotation    *[4]float64  `json:"rotation,omitempty"`
+		Scale       *[3]float64  `json:"scale,omitempty"`
+		Translation *[3]float64  `json:"translation,omitempty"`
+		*alias
+	}{
+		alias: (*alias)(n),
+	}
+	if n.Matrix != DefaultMatrix && n.Matrix != emptyMatrix {
+		tmp.Matrix = &n.Matrix
+	}
+	if n.Rotation != DefaultRotation && n.Rotation != emptyRotation {
+		tmp.Rotation = &n.Rotation
+	}
+	if n.Scale != DefaultScale && n.Scale != emptyScale {
+		tmp.Scale = &n.Scale
+	}
+	if n.Translation != [3]float64{} {
+		tmp.Translation = &n.Translation
+	}
+	return json.Marshal(tmp)
+}
+
 // Skin defines joints and matrices.
 type Skin struct {
 	Extensions          Extensions `json:"extensions,omitempty"`
@@ -464,6 +493,27 @@ type AnimationChannelTarget struct {
 // If a key does not match with any of the supported extensions the value will be a json.RawMessage so its decoding can be delayed.
 type Extensions map[string]any
 
+// UnmarshalJSON decodes the registered extensions into their types, others are kept as json.RawMessage.
+func (ext *Extensions) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if *ext == nil {
+		*ext = make(Extensions, len(raw))
+	}
+	for key, value := range raw {
+		(*ext)[key] = value
+		if factory, ok := queryExtension(key); ok {
+			if decoded, err := factory(value); err == nil {
+				(*ext)[key] = decoded
+			}
+		}
+	}
+	return nil
+}
+
 var (
 	extMu      sync.RWMutex
 	extensions = make(map[string]func([]byte) (any, error))
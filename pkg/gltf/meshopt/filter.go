@@ -0,0 +1,121 @@
+package meshopt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// DecodeFilterOct turns octahedral encoded normals or tangents back into unit vectors in place.
+// Each element is 4 signed 8 bit (stride 4) or 16 bit (stride 8) components, the fourth is kept.
+func DecodeFilterOct(data []byte, count, stride int) error {
+	switch stride {
+	case 4:
+		for i := range count {
+			e := data[i*4 : i*4+4]
+			x, y, z := octahedral(float32(int8(e[0])), float32(int8(e[1])), float32(int8(e[2])), 127)
+			e[0], e[1], e[2] = byte(int8(x)), byte(int8(y)), byte(int8(z))
+		}
+	case 8:
+		for i := range count {
+			e := data[i*8 : i*8+8]
+			x, y, z := octahedral(
+				float32(int16(binary.LittleEndian.Uint16(e[0:]))),
+				float32(int16(binary.LittleEndian.Uint16(e[2:]))),
+				float32(int16(binary.LittleEndian.Uint16(e[4:]))),
+				32767,
+			)
+			binary.LittleEndian.PutUint16(e[0:], uint16(int16(x)))
+			binary.LittleEndian.PutUint16(e[2:], uint16(int16(y)))
+			binary.LittleEndian.PutUint16(e[4:], uint16(int16(z)))
+		}
+	default:
+		return fmt.Errorf("meshopt: octahedral filter needs a stride of 4 or 8, got %d", stride)
+	}
+	return nil
+}
+
+// octahedral unfolds x and y, with z holding the encoded one, into a vector of length limit.
+func octahedral(x, y, one, limit float32) (int32, int32, int32) {
+	z := one - abs(x) - abs(y)
+
+	// fold back the lower hemisphere
+	t := min(z, 0)
+	x += copySign(t, x)
+	y += copySign(t, y)
+
+	s := limit / float32(math.Sqrt(float64(x*x+y*y+z*z)))
+	return round(x * s), round(y * s), round(z * s)
+}
+
+// DecodeFilterQuat turns quaternions stored as their three smallest components back into
+// four signed 16 bit components in place. The stride must be 8.
+func DecodeFilterQuat(data []byte, count, stride int) error {
+	if stride != 8 {
+		return fmt.Errorf("meshopt: quaternion filter needs a stride of 8, got %d", stride)
+	}
+
+	for i := range count {
+		e := data[i*8 : i*8+8]
+		var q [4]int16
+		for c := range 4 {
+			q[c] = int16(binary.LittleEndian.Uint16(e[c*2:]))
+		}
+
+		// the fourth component holds the scale in its high bits and which component was dropped in the low two
+		scale := float32(1/math.Sqrt2) / float32(q[3]|3)
+		x := float32(q[0]) * scale
+		y := float32(q[1]) * scale
+		z := float32(q[2]) * scale
+		w := float32(math.Sqrt(float64(max(1-x*x-y*y-z*z, 0))))
+
+		dropped := int(q[3] & 3)
+		out := [4]int32{}
+		out[(dropped+1)&3] = round(x * 32767)
+		out[(dropped+2)&3] = round(y * 32767)
+		out[(dropped+3)&3] = round(z * 32767)
+		out[dropped] = round(w * 32767)
+
+		for c := range 4 {
+			binary.LittleEndian.PutUint16(e[c*2:], uint16(int16(out[c])))
+		}
+	}
+	return nil
+}
+
+// DecodeFilterExp turns 24 bit mantissas with 8 bit exponents back into 32 bit floats in place.
+// The stride must be a multiple of 4.
+func DecodeFilterExp(data []byte, count, stride int) error {
+	if stride%4 != 0 {
+		return fmt.Errorf("meshopt: exponential filter needs a stride that is a multiple of 4, got %d", stride)
+	}
+
+	for i := range count * stride / 4 {
+		v := binary.LittleEndian.Uint32(data[i*4:])
+		m := int32(v<<8) >> 8
+		e := int32(v) >> 24
+
+		f := math.Float32frombits(uint32(e+127)<<23) * float32(m)
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(f))
+	}
+	return nil
+}
+
+func abs(v float32) float32 {
+	return float32(math.Abs(float64(v)))
+}
+
+func copySign(t, v float32) float32 {
+	if v >= 0 {
+		return t
+	}
+	return -t
+}
+
+// round rounds half away from zero like the reference decoder.
+func round(v float32) int32 {
+	if v >= 0 {
+		return int32(v + 0.5)
+	}
+	return int32(v - 0.5)
+}
@@ -0,0 +1,479 @@
+package meshopt
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	indexHeader    = 0xe0
+	sequenceHeader = 0xd0
+	// indexVersion is the triangle codec version written by EncodeIndexBuffer, version 1 adds
+	// the codes for the previous free index plus or minus one
+	indexVersion = 1
+)
+
+// codeAuxTable lists the common pairs of vertex fifo references, it is written at the end of
+// every encoded index buffer so decoders read it from the data instead of assuming it.
+var codeAuxTable = [16]byte{
+	0x00, 0x76, 0x87, 0x56, 0x67, 0x78, 0xa9, 0x86, 0x65, 0x89, 0x68, 0x98, 0x01, 0x69,
+	0x00, 0x00,
+}
+
+type edgeFifo [16][2]uint32
+
+type vertexFifo [16]uint32
+
+func newFifos() (*edgeFifo, *vertexFifo) {
+	edges := &edgeFifo{}
+	vertices := &vertexFifo{}
+	for i := range 16 {
+		edges[i] = [2]uint32{^uint32(0), ^uint32(0)}
+		vertices[i] = ^uint32(0)
+	}
+	return edges, vertices
+}
+
+// DecodeIndexBuffer decodes count triangle list indices from src into dst, writing each index
+// as indexSize (2 or 4) little endian bytes.
+func DecodeIndexBuffer(dst []byte, count, indexSize int, src []byte) error {
+	if count%3 != 0 {
+		return fmt.Errorf("meshopt: index count %d is not a multiple of 3", count)
+	}
+	if indexSize != 2 && indexSize != 4 {
+		return fmt.Errorf("meshopt: invalid index size %d", indexSize)
+	}
+	if len(dst) < count*indexSize {
+		return fmt.Errorf("meshopt: destination holds %d bytes, %d indices need %d", len(dst), count, count*indexSize)
+	}
+
+	// a header, a code per triangle and the trailing 16 byte code table
+	if len(src) < 1+count/3+16 {
+		return ErrMalformed
+	}
+	if src[0]&0xf0 != indexHeader {
+		return fmt.Errorf("%w: not an index buffer", ErrMalformed)
+	}
+	version := src[0] & 0x0f
+	if version > 1 {
+		return fmt.Errorf("meshopt: unsupported index codec version %d", version)
+	}
+
+	fecmax := uint32(15)
+	if version >= 1 {
+		fecmax = 13
+	}
+
+	edges, vertices := newFifos()
+	var edgeOffset, vertexOffset uint32
+	var next, last uint32
+
+	pushVertex := func(v uint32, cond bool) {
+		vertices[vertexOffset] = v
+		if cond {
+			vertexOffset = (vertexOffset + 1) & 15
+		}
+	}
+	pushEdge := func(a, b uint32) {
+		edges[edgeOffset] = [2]uint32{a, b}
+		edgeOffset = (edgeOffset + 1) & 15
+	}
+	write := func(i int, a, b, c uint32) {
+		if indexSize == 2 {
+			binary.LittleEndian.PutUint16(dst[i*2:], uint16(a))
+			binary.LittleEndian.PutUint16(dst[i*2+2:], uint16(b))
+			binary.LittleEndian.PutUint16(dst[i*2+4:], uint16(c))
+		} else {
+			binary.LittleEndian.PutUint32(dst[i*4:], a)
+			binary.LittleEndian.PutUint32(dst[i*4+4:], b)
+			binary.LittleEndian.PutUint32(dst[i*4+8:], c)
+		}
+	}
+
+	codes := src[1 : 1+count/3]
+	end := len(src) - 16
+	table := src[end:]
+	data := 1 + count/3
+
+	for t, code := range codes {
+		if data > end {
+			return ErrMalformed
+		}
+
+		if code < 0xf0 {
+			// the triangle shares an edge with a recent triangle
+			fe := uint32(code >> 4)
+			edge := edges[(edgeOffset-1-fe)&15]
+			a, b := edge[0], edge[1]
+
+			fec := uint32(code & 15)
+			var c uint32
+			if fec < fecmax {
+				if fec == 0 {
+					c = next
+					next++
+				} else {
+					c = vertices[(vertexOffset-1-fec)&15]
+				}
+				pushVertex(c, fec == 0)
+			} else {
+				if fec == 15 {
+					v, n, err := decodeIndex(src[data:end+16], last)
+					if err != nil {
+						return err
+					}
+					c, data = v, data+n
+				} else {
+					// 13 and 14 are the last free index minus and plus one
+					c = last + uint32(int32(fec)-int32(fec^3))
+				}
+				last = c
+				pushVertex(c, true)
+			}
+
+			write(t*3, a, b, c)
+			pushEdge(c, b)
+			pushEdge(a, c)
+			continue
+		}
+
+		var fea, feb, fec uint32
+		if code < 0xfe {
+			aux := table[code&15]
+			feb, fec = uint32(aux>>4), uint32(aux&15)
+		} else {
+			aux := src[data]
+			data++
+			if code == 0xff {
+				fea = 15
+			}
+			feb, fec = uint32(aux>>4), uint32(aux&15)
+			if aux == 0 {
+				next = 0
+			}
+		}
+
+		var a, b, c uint32
+		if fea == 0 {
+			a = next
+			next++
+		}
+		if feb == 0 {
+			b = next
+			next++
+		} else {
+			b = vertices[(vertexOffset-feb)&15]
+		}
+		if fec == 0 {
+			c = next
+			next++
+		} else {
+			c = vertices[(vertexOffset-fec)&15]
+		}
+
+		for _, free := range []struct {
+			fe uint32
+			v  *uint32
+		}{{fea, &a}, {feb, &b}, {fec, &c}} {
+			if free.fe != 15 {
+				continue
+			}
+			v, n, err := decodeIndex(src[data:end+16], last)
+			if err != nil {
+				return err
+			}
+			*free.v, data, last = v, data+n, v
+		}
+
+		write(t*3, a, b, c)
+		pushVertex(a, true)
+		pushVertex(b, feb == 0 || feb == 15)
+		pushVertex(c, fec == 0 || fec == 15)
+		pushEdge(b, a)
+		pushEdge(c, b)
+		pushEdge(a, c)
+	}
+
+	if data != end {
+		return fmt.Errorf("%w: index data ends at byte %d, expected %d", ErrMalformed, data, end)
+	}
+	return nil
+}
+
+// EncodeIndexBuffer encodes a triangle list, indices that reuse the edges and vertices of the
+// triangles just before them compress best.
+func EncodeIndexBuffer(indices []uint32) ([]byte, error) {
+	if len(indices)%3 != 0 {
+		return nil, fmt.Errorf("meshopt: index count %d is not a multiple of 3", len(indices))
+	}
+
+	codes := make([]byte, 0, len(indices)/3)
+	var data []byte
+
+	edges, vertices := newFifos()
+	var edgeOffset, vertexOffset uint32
+	var next, last uint32
+	const fecmax = 13
+
+	pushVertex := func(v uint32) {
+		vertices[vertexOffset] = v
+		vertexOffset = (vertexOffset + 1) & 15
+	}
+	pushEdge := func(a, b uint32) {
+		edges[edgeOffset] = [2]uint32{a, b}
+		edgeOffset = (edgeOffset + 1) & 15
+	}
+	findVertex := func(v uint32) int {
+		for i := range 16 {
+			if vertices[(vertexOffset-1-uint32(i))&15] == v {
+				return i
+			}
+		}
+		return -1
+	}
+	findEdge := func(a, b, c uint32) (int, [3]uint32) {
+		for i := range 16 {
+			e := edges[(edgeOffset-1-uint32(i))&15]
+			switch {
+			case e[0] == a && e[1] == b:
+				return i, [3]uint32{a, b, c}
+			case e[0] == b && e[1] == c:
+				return i, [3]uint32{b, c, a}
+			case e[0] == c && e[1] == a:
+				return i, [3]uint32{c, a, b}
+			}
+		}
+		return -1, [3]uint32{}
+	}
+
+	for t := 0; t < len(indices); t += 3 {
+		if fe, tri := findEdge(indices[t], indices[t+1], indices[t+2]); fe >= 0 && fe < 15 {
+			a, b, c := tri[0], tri[1], tri[2]
+
+			var fec uint32
+			switch fc := findVertex(c); {
+			case fc >= 1 && fc < fecmax:
+				fec = uint32(fc)
+			case c == next:
+				next++
+			case c+1 == last:
+				fec, last = 13, c
+			case c == last+1:
+				fec, last = 14, c
+			default:
+				fec = 15
+			}
+
+			codes = append(codes, byte(fe<<4)|byte(fec))
+			if fec == 15 {
+				data = encodeIndex(data, c, last)
+				last = c
+			}
+			if fec == 0 || fec >= fecmax {
+				pushVertex(c)
+			}
+			pushEdge(c, b)
+			pushEdge(a, c)
+			continue
+		}
+
+		// rotate so the next new vertex comes first, it is then coded implicitly
+		a, b, c := indices[t], indices[t+1], indices[t+2]
+		if b == next {
+			a, b, c = b, c, a
+		} else if c == next {
+			a, b, c = c, a, b
+		}
+
+		fb, fc := findVertex(b), findVertex(c)
+
+		fea := uint32(15)
+		if a == next {
+			fea = 0
+			next++
+		}
+		feb := uint32(15)
+		if fb >= 0 && fb < 14 {
+			feb = uint32(fb + 1)
+		} else if b == next {
+			feb = 0
+			next++
+		}
+		fec := uint32(15)
+		if fc >= 0 && fc < 14 {
+			fec = uint32(fc + 1)
+		} else if c == next {
+			fec = 0
+			next++
+		}
+
+		aux := byte(feb<<4 | fec)
+		tableIndex := -1
+		for i, v := range codeAuxTable[:14] {
+			if v == aux {
+				tableIndex = i
+				break
+			}
+		}
+
+		// a zero aux byte outside the table resets next, so that combination always uses the table
+		if fea == 0 && tableIndex >= 0 {
+			codes = append(codes, 0xf0|byte(tableIndex))
+		} else {
+			codes = append(codes, 0xfe|byte(fea&1))
+			data = append(data, aux)
+		}
+
+		for _, free := range []struct {
+			fe uint32
+			v  uint32
+		}{{fea, a}, {feb, b}, {fec, c}} {
+			if free.fe == 15 {
+				data = encodeIndex(data, free.v, last)
+				last = free.v
+			}
+		}
+
+		pushVertex(a)
+		if feb == 0 || feb == 15 {
+			pushVertex(b)
+		}
+		if fec == 0 || fec == 15 {
+			pushVertex(c)
+		}
+		pushEdge(b, a)
+		pushEdge(c, b)
+		pushEdge(a, c)
+	}
+
+	out := make([]byte, 0, 1+len(codes)+len(data)+16)
+	out = append(out, indexHeader|indexVersion)
+	out = append(out, codes...)
+	out = append(out, data...)
+	return append(out, codeAuxTable[:]...), nil
+}
+
+// DecodeIndexSequence decodes count indices of any primitive mode from src into dst, writing
+// each index as indexSize (2 or 4) little endian bytes.
+func DecodeIndexSequence(dst []byte, count, indexSize int, src []byte) error {
+	if indexSize != 2 && indexSize != 4 {
+		return fmt.Errorf("meshopt: invalid index size %d", indexSize)
+	}
+	if len(dst) < count*indexSize {
+		return fmt.Errorf("meshopt: destination holds %d bytes, %d indices need %d", len(dst), count, count*indexSize)
+	}
+
+	// a header, at least a byte per index and a 4 byte tail
+	if len(src) < 1+count+4 {
+		return ErrMalformed
+	}
+	if src[0]&0xf0 != sequenceHeader {
+		return fmt.Errorf("%w: not an index sequence", ErrMalformed)
+	}
+	if version := src[0] & 0x0f; version > 1 {
+		return fmt.Errorf("meshopt: unsupported index sequence version %d", version)
+	}
+
+	end := len(src) - 4
+	data := 1
+	var last [2]uint32
+	for i := range count {
+		if data >= end {
+			return ErrMalformed
+		}
+
+		v, n, err := decodeVByte(src[data:])
+		if err != nil {
+			return err
+		}
+		data += n
+
+		// the low bit picks which of the two previous indices the delta is from
+		baseline := v & 1
+		v >>= 1
+		index := last[baseline] + unzigzag32(v)
+		last[baseline] = index
+
+		if indexSize == 2 {
+			binary.LittleEndian.PutUint16(dst[i*2:], uint16(index))
+		} else {
+			binary.LittleEndian.PutUint32(dst[i*4:], index)
+		}
+	}
+
+	if data != end {
+		return fmt.Errorf("%w: index data ends at byte %d, expected %d", ErrMalformed, data, end)
+	}
+	return nil
+}
+
+// EncodeIndexSequence encodes indices of any primitive mode, such as lines or points.
+func EncodeIndexSequence(indices []uint32) []byte {
+	out := []byte{sequenceHeader | 1}
+
+	var last [2]uint32
+	current := uint32(0)
+	for _, index := range indices {
+		// deltas are taken from whichever of the last two baselines is closer
+		d := index - last[current]
+		if other := index - last[current^1]; abs32(other) < abs32(d) {
+			current ^= 1
+			d = other
+		}
+
+		out = encodeVByte(out, zigzag32(d)<<1|current)
+		last[current] = index
+	}
+
+	return append(out, 0, 0, 0, 0)
+}
+
+func decodeIndex(data []byte, last uint32) (uint32, int, error) {
+	v, n, err := decodeVByte(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	return last + unzigzag32(v), n, nil
+}
+
+func encodeIndex(out []byte, index, last uint32) []byte {
+	return encodeVByte(out, zigzag32(index-last))
+}
+
+// decodeVByte reads a little endian base 128 varint of at most 5 bytes.
+func decodeVByte(data []byte) (uint32, int, error) {
+	var v uint32
+	for i := range 5 {
+		if i >= len(data) {
+			return 0, 0, ErrMalformed
+		}
+		v |= uint32(data[i]&127) << (7 * i)
+		if data[i] < 128 {
+			return v, i + 1, nil
+		}
+	}
+	return v, 5, nil
+}
+
+func encodeVByte(out []byte, v uint32) []byte {
+	for v >= 128 {
+		out = append(out, byte(v&127|128))
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+func zigzag32(v uint32) uint32 {
+	return v<<1 ^ uint32(int32(v)>>31)
+}
+
+func unzigzag32(v uint32) uint32 {
+	return v>>1 ^ -(v & 1)
+}
+
+func abs32(v uint32) uint32 {
+	if int32(v) < 0 {
+		return -v
+	}
+	return v
+}
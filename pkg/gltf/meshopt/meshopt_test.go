@@ -0,0 +1,189 @@
+package meshopt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestDecodeVertexBuffer(t *testing.T) {
+	t.Parallel()
+
+	// two 4 byte vertices, the first comes from the tail and the second only changes its first byte
+	src := []byte{vertexHeader, 0x01, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	src = append(src, make([]byte, vertexTailMinSize-4)...)
+	src = append(src, 1, 2, 3, 4)
+
+	dst := make([]byte, 8)
+	if err := DecodeVertexBuffer(dst, 2, 4, src); err != nil {
+		t.Fatalf("Expected vertices to decode, got %v", err)
+	}
+
+	if want := []byte{1, 2, 3, 4, 2, 2, 3, 4}; !bytes.Equal(dst, want) {
+		t.Errorf("Expected %v, got %v", want, dst)
+	}
+
+	if err := DecodeVertexBuffer(dst, 2, 4, src[:len(src)-1]); err == nil {
+		t.Error("Expected truncated data to fail")
+	}
+}
+
+func TestVertexBufferRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []int{4, 12, 16, 64} {
+		for _, count := range []int{0, 1, 17, 1000} {
+			src := make([]byte, count*size)
+			for i := range count {
+				for k := range size {
+					// smooth values with the odd jump, like positions along a mesh
+					src[i*size+k] = byte(i*(k+1) + k*7)
+					if i%97 == 0 {
+						src[i*size+k] ^= 0xa5
+					}
+				}
+			}
+
+			encoded, err := EncodeVertexBuffer(src, count, size)
+			if err != nil {
+				t.Fatalf("Expected %d vertices of %d bytes to encode, got %v", count, size, err)
+			}
+
+			dst := make([]byte, len(src))
+			if err := DecodeVertexBuffer(dst, count, size, encoded); err != nil {
+				t.Fatalf("Expected %d vertices of %d bytes to decode, got %v", count, size, err)
+			}
+
+			if !bytes.Equal(dst, src) {
+				t.Errorf("Expected %d vertices of %d bytes to round trip", count, size)
+			}
+		}
+	}
+}
+
+func TestDecodeIndexBuffer(t *testing.T) {
+	t.Parallel()
+
+	// a new triangle from the code table, then one sharing its 2-1 edge with a new vertex
+	src := append([]byte{indexHeader | 1, 0xf0, 0x10}, codeAuxTable[:]...)
+
+	dst := make([]byte, 12)
+	if err := DecodeIndexBuffer(dst, 6, 2, src); err != nil {
+		t.Fatalf("Expected indices to decode, got %v", err)
+	}
+
+	want := []uint16{0, 1, 2, 2, 1, 3}
+	for i, index := range want {
+		if got := binary.LittleEndian.Uint16(dst[i*2:]); got != index {
+			t.Errorf("Expected index %d to be %d, got %d", i, index, got)
+		}
+	}
+}
+
+func TestIndexBufferRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// a grid strip followed by scattered triangles with large indices
+	var indices []uint32
+	for y := range uint32(20) {
+		for x := range uint32(20) {
+			a := y*21 + x
+			indices = append(indices, a, a+1, a+21, a+1, a+22, a+21)
+		}
+	}
+	for i := range uint32(50) {
+		indices = append(indices, i*7919%70000, i*104729%70000+1, i*1299709%70000+2)
+	}
+
+	encoded, err := EncodeIndexBuffer(indices)
+	if err != nil {
+		t.Fatalf("Expected indices to encode, got %v", err)
+	}
+
+	if len(encoded) >= len(indices)*4 {
+		t.Errorf("Expected the encoding to be smaller than %d bytes, got %d", len(indices)*4, len(encoded))
+	}
+
+	dst := make([]byte, len(indices)*4)
+	if err := DecodeIndexBuffer(dst, len(indices), 4, encoded); err != nil {
+		t.Fatalf("Expected indices to decode, got %v", err)
+	}
+
+	// triangles may come back rotated, the winding is kept
+	for i := 0; i < len(indices); i += 3 {
+		got := [3]uint32{
+			binary.LittleEndian.Uint32(dst[i*4:]),
+			binary.LittleEndian.Uint32(dst[i*4+4:]),
+			binary.LittleEndian.Uint32(dst[i*4+8:]),
+		}
+		want := [3]uint32{indices[i], indices[i+1], indices[i+2]}
+		if got != want && got != [3]uint32{want[1], want[2], want[0]} && got != [3]uint32{want[2], want[0], want[1]} {
+			t.Fatalf("Expected triangle %d to be %v, got %v", i/3, want, got)
+		}
+	}
+}
+
+func TestIndexSequenceRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	indices := []uint32{0, 1, 1, 2, 2, 3, 100, 101, 3, 4, 65535, 0}
+	encoded := EncodeIndexSequence(indices)
+
+	dst := make([]byte, len(indices)*2)
+	if err := DecodeIndexSequence(dst, len(indices), 2, encoded); err != nil {
+		t.Fatalf("Expected indices to decode, got %v", err)
+	}
+
+	got := make([]uint32, len(indices))
+	for i := range got {
+		got[i] = uint32(binary.LittleEndian.Uint16(dst[i*2:]))
+	}
+
+	if !slices.Equal(got, indices) {
+		t.Errorf("Expected %v, got %v", indices, got)
+	}
+}
+
+func TestDecodeFilters(t *testing.T) {
+	t.Parallel()
+
+	// +z folded into the octahedron is x=0, y=0 with the full length in z
+	oct := []byte{0, 0, 127, 9}
+	if err := DecodeFilterOct(oct, 1, 4); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0, 0, 127, 9}; !bytes.Equal(oct, want) {
+		t.Errorf("Expected %v, got %v", want, oct)
+	}
+
+	// -x sits on the edge of the octahedron, z = 127 - 127 = 0
+	oct = []byte{byte(0x81), 0, 127, 0}
+	DecodeFilterOct(oct, 1, 4)
+	if int8(oct[0]) != -127 || oct[1] != 0 || oct[2] != 0 {
+		t.Errorf("Expected -x, got %v", []int8{int8(oct[0]), int8(oct[1]), int8(oct[2])})
+	}
+
+	// the identity quaternion with w dropped, stored in the last component
+	quat := make([]byte, 8)
+	binary.LittleEndian.PutUint16(quat[6:], uint16(int16(32767&^3|3)))
+	if err := DecodeFilterQuat(quat, 1, 8); err != nil {
+		t.Fatal(err)
+	}
+	for c, want := range []int16{0, 0, 0, 32767} {
+		if got := int16(binary.LittleEndian.Uint16(quat[c*2:])); got != want {
+			t.Errorf("Expected quaternion component %d to be %d, got %d", c, want, got)
+		}
+	}
+
+	// mantissa 3 with exponent -1 is 1.5
+	exp := make([]byte, 4)
+	binary.LittleEndian.PutUint32(exp, uint32(0xff)<<24|3)
+	if err := DecodeFilterExp(exp, 1, 4); err != nil {
+		t.Fatal(err)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(exp)); got != 1.5 {
+		t.Errorf("Expected 1.5, got %v", got)
+	}
+}
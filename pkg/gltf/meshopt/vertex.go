@@ -0,0 +1,261 @@
+// Package meshopt implements the vertex and index codecs and the filters of meshoptimizer, as
+// used by the EXT_meshopt_compression glTF extension.
+package meshopt
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	vertexHeader      = 0xa0
+	vertexBlockBytes  = 8192
+	vertexBlockMax    = 256
+	byteGroupSize     = 16
+	vertexTailMinSize = 32
+)
+
+// ErrMalformed is returned when compressed data is truncated or doesn't match its header.
+var ErrMalformed = errors.New("meshopt: malformed data")
+
+// vertexBlockSize is how many vertices are delta coded together, a multiple of the byte group size.
+func vertexBlockSize(vertexSize int) int {
+	size := vertexBlockBytes / vertexSize
+	size &^= byteGroupSize - 1
+	return min(size, vertexBlockMax)
+}
+
+// DecodeVertexBuffer decodes count vertices of size bytes each from src into dst, which must
+// hold count*size bytes. size must be a multiple of 4 no larger than 256.
+func DecodeVertexBuffer(dst []byte, count, size int, src []byte) error {
+	if size <= 0 || size > 256 || size%4 != 0 {
+		return fmt.Errorf("meshopt: invalid vertex size %d", size)
+	}
+	if len(dst) < count*size {
+		return fmt.Errorf("meshopt: destination holds %d bytes, %d vertices need %d", len(dst), count, count*size)
+	}
+	if len(src) < 1+size {
+		return ErrMalformed
+	}
+	if src[0]&0xf0 != vertexHeader {
+		return fmt.Errorf("%w: not a vertex buffer", ErrMalformed)
+	}
+	if version := src[0] & 0x0f; version > 0 {
+		return fmt.Errorf("meshopt: unsupported vertex codec version %d", version)
+	}
+
+	// the tail holds the first vertex, every vertex is a delta of the one before
+	var last [256]byte
+	copy(last[:size], src[len(src)-size:])
+
+	data := src[1:]
+	blockSize := vertexBlockSize(size)
+	var buffer [vertexBlockMax]byte
+	for offset := 0; offset < count; offset += blockSize {
+		n := min(blockSize, count-offset)
+		aligned := (n + byteGroupSize - 1) &^ (byteGroupSize - 1)
+		block := dst[offset*size:]
+
+		for k := range size {
+			var err error
+			data, err = decodeBytes(data, buffer[:aligned])
+			if err != nil {
+				return err
+			}
+
+			p := last[k]
+			for i := range n {
+				p += unzigzag8(buffer[i])
+				block[i*size+k] = p
+			}
+			last[k] = p
+		}
+	}
+
+	if tail := max(size, vertexTailMinSize); len(data) != tail {
+		return fmt.Errorf("%w: %d bytes left after the vertices, expected %d", ErrMalformed, len(data), tail)
+	}
+	return nil
+}
+
+// decodeBytes fills buffer from byte groups, each group of 16 values stores 0, 2, 4 or 8 bits per value.
+func decodeBytes(data []byte, buffer []byte) ([]byte, error) {
+	groups := len(buffer) / byteGroupSize
+	headerSize := (groups + 3) / 4
+	if len(data) < headerSize {
+		return nil, ErrMalformed
+	}
+
+	header := data[:headerSize]
+	data = data[headerSize:]
+	for g := range groups {
+		bitslog2 := (header[g/4] >> ((g % 4) * 2)) & 3
+
+		var err error
+		data, err = decodeBytesGroup(data, buffer[g*byteGroupSize:(g+1)*byteGroupSize], bitslog2)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func decodeBytesGroup(data []byte, group []byte, bitslog2 byte) ([]byte, error) {
+	switch bitslog2 {
+	case 0:
+		clear(group)
+		return data, nil
+	case 3:
+		if len(data) < byteGroupSize {
+			return nil, ErrMalformed
+		}
+		copy(group, data[:byteGroupSize])
+		return data[byteGroupSize:], nil
+	}
+
+	// values equal to the all ones sentinel are stored as whole bytes after the packed ones
+	bits := 1 << bitslog2
+	packed := byteGroupSize * bits / 8
+	if len(data) < packed {
+		return nil, ErrMalformed
+	}
+
+	sentinel := byte(1<<bits - 1)
+	extra := data[packed:]
+	perByte := 8 / bits
+	for i := range byteGroupSize {
+		b := data[i/perByte]
+		v := (b >> (8 - bits*(i%perByte+1))) & sentinel
+		if v == sentinel {
+			if len(extra) == 0 {
+				return nil, ErrMalformed
+			}
+			v = extra[0]
+			extra = extra[1:]
+		}
+		group[i] = v
+	}
+	return extra, nil
+}
+
+// EncodeVertexBuffer encodes count vertices of size bytes each, size must be a multiple of 4 no
+// larger than 256. Vertices that are similar to the one before compress best.
+func EncodeVertexBuffer(src []byte, count, size int) ([]byte, error) {
+	if size <= 0 || size > 256 || size%4 != 0 {
+		return nil, fmt.Errorf("meshopt: invalid vertex size %d", size)
+	}
+	if len(src) < count*size {
+		return nil, fmt.Errorf("meshopt: source holds %d bytes, %d vertices need %d", len(src), count, count*size)
+	}
+
+	out := []byte{vertexHeader}
+
+	var first [256]byte
+	if count > 0 {
+		copy(first[:size], src[:size])
+	}
+	last := first
+
+	blockSize := vertexBlockSize(size)
+	var buffer [vertexBlockMax]byte
+	for offset := 0; offset < count; offset += blockSize {
+		n := min(blockSize, count-offset)
+		aligned := (n + byteGroupSize - 1) &^ (byteGroupSize - 1)
+		block := src[offset*size:]
+
+		for k := range size {
+			clear(buffer[:aligned])
+			p := last[k]
+			for i := range n {
+				v := block[i*size+k]
+				buffer[i] = zigzag8(v - p)
+				p = v
+			}
+			last[k] = p
+
+			out = encodeBytes(out, buffer[:aligned])
+		}
+	}
+
+	// the tail is padded so decoders can read a few bytes ahead, the first vertex ends it
+	tail := max(size, vertexTailMinSize)
+	out = append(out, make([]byte, tail-size)...)
+	return append(out, first[:size]...), nil
+}
+
+func encodeBytes(out []byte, buffer []byte) []byte {
+	groups := len(buffer) / byteGroupSize
+	headerStart := len(out)
+	out = append(out, make([]byte, (groups+3)/4)...)
+
+	for g := range groups {
+		group := buffer[g*byteGroupSize : (g+1)*byteGroupSize]
+
+		best, bestSize := byte(3), byteGroupSize
+		for bitslog2 := byte(0); bitslog2 < 3; bitslog2++ {
+			if size := measureBytesGroup(group, bitslog2); size >= 0 && size < bestSize {
+				best, bestSize = bitslog2, size
+			}
+		}
+
+		out[headerStart+g/4] |= best << ((g % 4) * 2)
+		out = encodeBytesGroup(out, group, best)
+	}
+	return out
+}
+
+// measureBytesGroup returns the encoded size of a group, -1 when it can't be encoded with the bits.
+func measureBytesGroup(group []byte, bitslog2 byte) int {
+	if bitslog2 == 0 {
+		for _, v := range group {
+			if v != 0 {
+				return -1
+			}
+		}
+		return 0
+	}
+
+	bits := 1 << bitslog2
+	size := byteGroupSize * bits / 8
+	sentinel := byte(1<<bits - 1)
+	for _, v := range group {
+		if v >= sentinel {
+			size++
+		}
+	}
+	return size
+}
+
+func encodeBytesGroup(out []byte, group []byte, bitslog2 byte) []byte {
+	switch bitslog2 {
+	case 0:
+		return out
+	case 3:
+		return append(out, group...)
+	}
+
+	bits := 1 << bitslog2
+	perByte := 8 / bits
+	sentinel := byte(1<<bits - 1)
+	for i := 0; i < byteGroupSize; i += perByte {
+		var b byte
+		for k := range perByte {
+			b = b<<bits | min(group[i+k], sentinel)
+		}
+		out = append(out, b)
+	}
+	for _, v := range group {
+		if v >= sentinel {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func zigzag8(v byte) byte {
+	return byte(int8(v)>>7) ^ v<<1
+}
+
+func unzigzag8(v byte) byte {
+	return -(v & 1) ^ v>>1
+}
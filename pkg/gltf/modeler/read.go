@@ -0,0 +1,394 @@
+// Package modeler reads and writes typed vertex and index data through the accessors of a glTF document.
+package modeler
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"neuroscan/pkg/gltf"
+)
+
+// ReadPosition returns the positions stored in the accessor.
+func ReadPosition(doc *gltf.Document, acr *gltf.Accessor) ([][3]float32, error) {
+	return readVec3(doc, acr, gltf.POSITION)
+}
+
+// ReadNormal returns the normals stored in the accessor.
+func ReadNormal(doc *gltf.Document, acr *gltf.Accessor) ([][3]float32, error) {
+	return readVec3(doc, acr, gltf.NORMAL)
+}
+
+// ReadIndices returns the indices stored in the accessor.
+func ReadIndices(doc *gltf.Document, acr *gltf.Accessor) ([]uint32, error) {
+	if acr.Type != gltf.AccessorScalar {
+		return nil, fmt.Errorf("gltf: indices accessor must be SCALAR, got type %d", acr.Type)
+	}
+
+	switch acr.ComponentType {
+	case gltf.ComponentUbyte, gltf.ComponentUshort, gltf.ComponentUint:
+	default:
+		return nil, fmt.Errorf("gltf: indices accessor must be unsigned, got component type %d", acr.ComponentType)
+	}
+
+	return ReadUint(doc, acr)
+}
+
+// ReadPrimitivePosition returns the positions of a primitive.
+func ReadPrimitivePosition(doc *gltf.Document, primitive *gltf.Primitive) ([][3]float32, error) {
+	index, ok := primitive.Attributes[gltf.POSITION]
+	if !ok {
+		return nil, errors.New("gltf: primitive has no POSITION attribute")
+	}
+
+	acr, err := accessor(doc, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadPosition(doc, acr)
+}
+
+// ReadPrimitiveIndices returns the indices of a primitive. Non-indexed primitives
+// draw their vertices in order, so the sequence 0..n-1 is returned for them.
+func ReadPrimitiveIndices(doc *gltf.Document, primitive *gltf.Primitive) ([]uint32, error) {
+	if primitive.Indices != nil {
+		acr, err := accessor(doc, *primitive.Indices)
+		if err != nil {
+			return nil, err
+		}
+
+		return ReadIndices(doc, acr)
+	}
+
+	index, ok := primitive.Attributes[gltf.POSITION]
+	if !ok {
+		return nil, errors.New("gltf: primitive has no POSITION attribute")
+	}
+
+	acr, err := accessor(doc, index)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAccessor(doc, acr); err != nil {
+		return nil, err
+	}
+
+	indices := make([]uint32, acr.Count)
+	for i := range indices {
+		indices[i] = uint32(i)
+	}
+
+	return indices, nil
+}
+
+// ReadFloat returns every component of the accessor as a float32, flattened in element order.
+// Integer components are normalized when the accessor is normalized, else converted as is.
+func ReadFloat(doc *gltf.Document, acr *gltf.Accessor) ([]float32, error) {
+	if err := checkAccessor(doc, acr); err != nil {
+		return nil, err
+	}
+
+	values := make([]float32, acr.Count*acr.Type.Components())
+
+	err := read(doc, acr, func(i int, data []byte) {
+		values[i] = decodeFloat(data, acr.ComponentType, acr.Normalized)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// ReadUint returns every component of an integer accessor as a uint32, flattened in element order.
+func ReadUint(doc *gltf.Document, acr *gltf.Accessor) ([]uint32, error) {
+	if acr.ComponentType == gltf.ComponentFloat {
+		return nil, errors.New("gltf: cannot read float accessor as integers")
+	}
+
+	if err := checkAccessor(doc, acr); err != nil {
+		return nil, err
+	}
+
+	values := make([]uint32, acr.Count*acr.Type.Components())
+
+	err := read(doc, acr, func(i int, data []byte) {
+		values[i] = decodeUint(data, acr.ComponentType)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func readVec3(doc *gltf.Document, acr *gltf.Accessor, attribute string) ([][3]float32, error) {
+	if acr.Type != gltf.AccessorVec3 {
+		return nil, fmt.Errorf("gltf: %s accessor must be VEC3, got type %d", attribute, acr.Type)
+	}
+
+	flat, err := ReadFloat(doc, acr)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][3]float32, acr.Count)
+	for i := range values {
+		values[i] = [3]float32{flat[i*3], flat[i*3+1], flat[i*3+2]}
+	}
+
+	return values, nil
+}
+
+func accessor(doc *gltf.Document, index int) (*gltf.Accessor, error) {
+	if index < 0 || index >= len(doc.Accessors) {
+		return nil, fmt.Errorf("gltf: accessor %d out of range", index)
+	}
+
+	return doc.Accessors[index], nil
+}
+
+// checkAccessor rejects accessors whose count or type can't size a slice, or whose elements don't
+// fit their buffer view, before anything is allocated. Accessors without a buffer view read as
+// zeros, their count is capped by gltf.MaxZeroAccessorCount instead.
+func checkAccessor(doc *gltf.Document, acr *gltf.Accessor) error {
+	if acr.Count < 0 || acr.ComponentType.ByteSize() == 0 || acr.Type.Components() == 0 {
+		return errors.New("gltf: invalid accessor")
+	}
+
+	if acr.BufferView == nil {
+		if acr.Count > gltf.MaxZeroAccessorCount {
+			return fmt.Errorf("gltf: accessor without a buffer view has %d elements, at most %d are read", acr.Count, gltf.MaxZeroAccessorCount)
+		}
+
+		return nil
+	}
+
+	data, stride, err := bufferViewData(doc, *acr.BufferView)
+	if err != nil {
+		return err
+	}
+
+	elementSize := gltf.SizeOfElement(acr.ComponentType, acr.Type)
+	if stride == 0 {
+		stride = elementSize
+	}
+
+	if acr.ByteOffset < 0 || stride < 0 {
+		return errors.New("gltf: invalid accessor offset or stride")
+	}
+
+	// dividing keeps a huge count from overflowing the end of the accessor
+	if room := len(data) - acr.ByteOffset - elementSize; acr.Count > 0 && (room < 0 || acr.Count-1 > room/stride) {
+		return fmt.Errorf("gltf: accessor of %d elements reads past the %d bytes of its buffer view", acr.Count, len(data))
+	}
+
+	return nil
+}
+
+// read calls fn with the bytes of every component of the accessor, including sparse substitutions.
+// fn receives the flattened component index, element*components + component.
+func read(doc *gltf.Document, acr *gltf.Accessor, fn func(i int, data []byte)) error {
+	components := acr.Type.Components()
+	componentSize := acr.ComponentType.ByteSize()
+	elementSize := gltf.SizeOfElement(acr.ComponentType, acr.Type)
+	offsets := componentOffsets(acr.ComponentType, acr.Type)
+
+	if err := checkAccessor(doc, acr); err != nil {
+		return err
+	}
+
+	// accessors without a buffer view are initialized with zeros
+	if acr.BufferView == nil {
+		zero := make([]byte, componentSize)
+		for i := 0; i < acr.Count*components; i++ {
+			fn(i, zero)
+		}
+	} else {
+		data, stride, err := bufferViewData(doc, *acr.BufferView)
+		if err != nil {
+			return err
+		}
+
+		if stride == 0 {
+			stride = elementSize
+		}
+
+		for e := 0; e < acr.Count; e++ {
+			base := acr.ByteOffset + e*stride
+			for c, offset := range offsets {
+				start := base + offset
+				fn(e*components+c, data[start:start+componentSize])
+			}
+		}
+	}
+
+	if acr.Sparse == nil {
+		return nil
+	}
+
+	return readSparse(doc, acr, offsets, elementSize, fn)
+}
+
+func readSparse(doc *gltf.Document, acr *gltf.Accessor, offsets []int, elementSize int, fn func(i int, data []byte)) error {
+	sparse := acr.Sparse
+	components := acr.Type.Components()
+	componentSize := acr.ComponentType.ByteSize()
+
+	indexData, _, err := bufferViewData(doc, sparse.Indices.BufferView)
+	if err != nil {
+		return err
+	}
+
+	valueData, _, err := bufferViewData(doc, sparse.Values.BufferView)
+	if err != nil {
+		return err
+	}
+
+	indexSize := sparse.Indices.ComponentType.ByteSize()
+	if indexSize == 0 || sparse.Indices.ComponentType == gltf.ComponentFloat {
+		return errors.New("gltf: invalid sparse indices component type")
+	}
+
+	if sparse.Count < 0 {
+		return errors.New("gltf: invalid sparse count")
+	}
+
+	// dividing keeps a huge count from overflowing the end of the indices and values
+	if room := len(indexData) - sparse.Indices.ByteOffset; sparse.Indices.ByteOffset < 0 || room < 0 || sparse.Count > room/indexSize {
+		return errors.New("gltf: sparse indices out of range")
+	}
+
+	if room := len(valueData) - sparse.Values.ByteOffset; sparse.Values.ByteOffset < 0 || room < 0 || sparse.Count > room/elementSize {
+		return errors.New("gltf: sparse values out of range")
+	}
+
+	for s := 0; s < sparse.Count; s++ {
+		start := sparse.Indices.ByteOffset + s*indexSize
+		element := int(decodeUint(indexData[start:start+indexSize], sparse.Indices.ComponentType))
+		if element >= acr.Count {
+			return fmt.Errorf("gltf: sparse index %d out of range", element)
+		}
+
+		base := sparse.Values.ByteOffset + s*elementSize
+		for c, offset := range offsets {
+			start := base + offset
+			fn(element*components+c, valueData[start:start+componentSize])
+		}
+	}
+
+	return nil
+}
+
+// bufferViewData returns the bytes of a buffer view along with its stride.
+func bufferViewData(doc *gltf.Document, index int) ([]byte, int, error) {
+	if index < 0 || index >= len(doc.BufferViews) {
+		return nil, 0, fmt.Errorf("gltf: buffer view %d out of range", index)
+	}
+
+	view := doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(doc.Buffers) {
+		return nil, 0, fmt.Errorf("gltf: buffer %d out of range", view.Buffer)
+	}
+
+	data := doc.Buffers[view.Buffer].Data
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset > len(data) || view.ByteLength > len(data)-view.ByteOffset {
+		return nil, 0, fmt.Errorf("gltf: buffer view %d is outside of its buffer", index)
+	}
+
+	return data[view.ByteOffset : view.ByteOffset+view.ByteLength], view.ByteStride, nil
+}
+
+// componentOffsets returns the byte offset of each component within an element.
+// Matrix columns are aligned to 4 bytes, so small component types leave padding between them.
+func componentOffsets(c gltf.ComponentType, t gltf.AccessorType) []int {
+	components := t.Components()
+	size := c.ByteSize()
+	offsets := make([]int, components)
+
+	rows := 0
+	switch t {
+	case gltf.AccessorMat2:
+		rows = 2
+	case gltf.AccessorMat3:
+		rows = 3
+	case gltf.AccessorMat4:
+		rows = 4
+	}
+
+	if rows == 0 {
+		for i := range offsets {
+			offsets[i] = i * size
+		}
+
+		return offsets
+	}
+
+	columnSize := (rows*size + 3) &^ 3
+	for i := range offsets {
+		offsets[i] = (i/rows)*columnSize + (i%rows)*size
+	}
+
+	return offsets
+}
+
+func decodeUint(data []byte, c gltf.ComponentType) uint32 {
+	switch c {
+	case gltf.ComponentByte:
+		return uint32(int8(data[0]))
+	case gltf.ComponentUbyte:
+		return uint32(data[0])
+	case gltf.ComponentShort:
+		return uint32(int16(binary.LittleEndian.Uint16(data)))
+	case gltf.ComponentUshort:
+		return uint32(binary.LittleEndian.Uint16(data))
+	case gltf.ComponentUint:
+		return binary.LittleEndian.Uint32(data)
+	case gltf.ComponentFloat:
+		return uint32(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	}
+
+	return 0
+}
+
+func decodeFloat(data []byte, c gltf.ComponentType, normalized bool) float32 {
+	switch c {
+	case gltf.ComponentFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	case gltf.ComponentByte:
+		v := float32(int8(data[0]))
+		if normalized {
+			return max(v/127, -1)
+		}
+		return v
+	case gltf.ComponentUbyte:
+		v := float32(data[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case gltf.ComponentShort:
+		v := float32(int16(binary.LittleEndian.Uint16(data)))
+		if normalized {
+			return max(v/32767, -1)
+		}
+		return v
+	case gltf.ComponentUshort:
+		v := float32(binary.LittleEndian.Uint16(data))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case gltf.ComponentUint:
+		v := float64(binary.LittleEndian.Uint32(data))
+		if normalized {
+			return float32(v / math.MaxUint32)
+		}
+		return float32(v)
+	}
+
+	return 0
+}
@@ -0,0 +1,224 @@
+package modeler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"neuroscan/pkg/gltf"
+)
+
+func le(values ...any) []byte {
+	var buf bytes.Buffer
+	for _, v := range values {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+func TestReadPositionAndIndices(t *testing.T) {
+	t.Parallel()
+
+	positions := le(float32(0), float32(0), float32(0), float32(1), float32(0), float32(0), float32(0), float32(1), float32(0))
+	indices := le(uint16(0), uint16(1), uint16(2), uint16(0))
+
+	doc := &gltf.Document{
+		Buffers: []*gltf.Buffer{{ByteLength: len(positions) + len(indices), Data: append(positions, indices...)}},
+		BufferViews: []*gltf.BufferView{
+			{Buffer: 0, ByteLength: len(positions)},
+			{Buffer: 0, ByteOffset: len(positions), ByteLength: len(indices)},
+		},
+		Accessors: []*gltf.Accessor{
+			{BufferView: gltf.Index(0), ComponentType: gltf.ComponentFloat, Type: gltf.AccessorVec3, Count: 3},
+			{BufferView: gltf.Index(1), ComponentType: gltf.ComponentUshort, Type: gltf.AccessorScalar, Count: 3},
+		},
+	}
+
+	primitive := &gltf.Primitive{Attributes: gltf.PrimitiveAttributes{gltf.POSITION: 0}, Indices: gltf.Index(1)}
+
+	got, err := ReadPrimitivePosition(doc, primitive)
+	if err != nil {
+		t.Fatalf("Expected positions to be read, got %v", err)
+	}
+
+	expected := [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected position %d to be %v, got %v", i, expected[i], got[i])
+		}
+	}
+
+	idx, err := ReadPrimitiveIndices(doc, primitive)
+	if err != nil {
+		t.Fatalf("Expected indices to be read, got %v", err)
+	}
+
+	if len(idx) != 3 || idx[0] != 0 || idx[1] != 1 || idx[2] != 2 {
+		t.Errorf("Expected indices [0 1 2], got %v", idx)
+	}
+
+	primitive.Indices = nil
+
+	idx, err = ReadPrimitiveIndices(doc, primitive)
+	if err != nil || len(idx) != 3 || idx[2] != 2 {
+		t.Errorf("Expected generated indices [0 1 2], got %v (%v)", idx, err)
+	}
+}
+
+func TestReadNormalizedStrided(t *testing.T) {
+	t.Parallel()
+
+	// interleaved: a normalized short normal padded to 8 bytes followed by 4 unrelated bytes
+	data := le(
+		int16(32767), int16(0), int16(-32768), int16(0), uint32(0xdeadbeef),
+		int16(0), int16(16384), int16(0), int16(0), uint32(0xdeadbeef),
+	)
+
+	doc := &gltf.Document{
+		Buffers:     []*gltf.Buffer{{ByteLength: len(data), Data: data}},
+		BufferViews: []*gltf.BufferView{{Buffer: 0, ByteLength: len(data), ByteStride: 12}},
+		Accessors: []*gltf.Accessor{
+			{BufferView: gltf.Index(0), ComponentType: gltf.ComponentShort, Normalized: true, Type: gltf.AccessorVec3, Count: 2},
+		},
+	}
+
+	normals, err := ReadNormal(doc, doc.Accessors[0])
+	if err != nil {
+		t.Fatalf("Expected normals to be read, got %v", err)
+	}
+
+	if normals[0] != [3]float32{1, 0, -1} {
+		t.Errorf("Expected first normal to be [1 0 -1], got %v", normals[0])
+	}
+
+	if normals[1][1] < 0.49 || normals[1][1] > 0.51 {
+		t.Errorf("Expected second normal y to be ~0.5, got %v", normals[1][1])
+	}
+}
+
+func TestReadSparse(t *testing.T) {
+	t.Parallel()
+
+	sparseIndices := le(uint8(2), uint8(0), uint8(0), uint8(0))
+	sparseValues := le(float32(5), float32(6), float32(7))
+	data := append(sparseIndices, sparseValues...)
+
+	doc := &gltf.Document{
+		Buffers: []*gltf.Buffer{{ByteLength: len(data), Data: data}},
+		BufferViews: []*gltf.BufferView{
+			{Buffer: 0, ByteLength: len(sparseIndices)},
+			{Buffer: 0, ByteOffset: len(sparseIndices), ByteLength: len(sparseValues)},
+		},
+		Accessors: []*gltf.Accessor{{
+			ComponentType: gltf.ComponentFloat,
+			Type:          gltf.AccessorVec3,
+			Count:         3,
+			Sparse: &gltf.Sparse{
+				Count:   1,
+				Indices: gltf.SparseIndices{BufferView: 0, ComponentType: gltf.ComponentUbyte},
+				Values:  gltf.SparseValues{BufferView: 1},
+			},
+		}},
+	}
+
+	positions, err := ReadPosition(doc, doc.Accessors[0])
+	if err != nil {
+		t.Fatalf("Expected sparse positions to be read, got %v", err)
+	}
+
+	if positions[0] != ([3]float32{}) || positions[1] != ([3]float32{}) {
+		t.Errorf("Expected untouched positions to be zero, got %v", positions)
+	}
+
+	if positions[2] != [3]float32{5, 6, 7} {
+		t.Errorf("Expected sparse position to be [5 6 7], got %v", positions[2])
+	}
+}
+
+func TestReadMatrixPadding(t *testing.T) {
+	t.Parallel()
+
+	// a MAT2 of unsigned bytes pads each 2 byte column to 4 bytes
+	data := []byte{1, 2, 0, 0, 3, 4, 0, 0}
+
+	doc := &gltf.Document{
+		Buffers:     []*gltf.Buffer{{ByteLength: len(data), Data: data}},
+		BufferViews: []*gltf.BufferView{{Buffer: 0, ByteLength: len(data)}},
+		Accessors:   []*gltf.Accessor{{BufferView: gltf.Index(0), ComponentType: gltf.ComponentUbyte, Type: gltf.AccessorMat2, Count: 1}},
+	}
+
+	values, err := ReadUint(doc, doc.Accessors[0])
+	if err != nil {
+		t.Fatalf("Expected matrix to be read, got %v", err)
+	}
+
+	if len(values) != 4 || values[0] != 1 || values[1] != 2 || values[2] != 3 || values[3] != 4 {
+		t.Errorf("Expected [1 2 3 4], got %v", values)
+	}
+}
+
+func TestReadOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	doc := &gltf.Document{
+		Buffers:     []*gltf.Buffer{{ByteLength: 4, Data: make([]byte, 4)}},
+		BufferViews: []*gltf.BufferView{{Buffer: 0, ByteLength: 4}},
+		Accessors:   []*gltf.Accessor{{BufferView: gltf.Index(0), ComponentType: gltf.ComponentFloat, Type: gltf.AccessorVec3, Count: 1}},
+	}
+
+	if _, err := ReadPosition(doc, doc.Accessors[0]); err == nil {
+		t.Error("Expected accessor past the end of its buffer view to fail")
+	}
+}
+
+func TestReadNegativeCount(t *testing.T) {
+	t.Parallel()
+
+	doc := &gltf.Document{
+		Buffers:     []*gltf.Buffer{{ByteLength: 12, Data: make([]byte, 12)}},
+		BufferViews: []*gltf.BufferView{{Buffer: 0, ByteLength: 12}},
+		Accessors: []*gltf.Accessor{
+			{BufferView: gltf.Index(0), ComponentType: gltf.ComponentFloat, Type: gltf.AccessorVec3, Count: -1},
+			{BufferView: gltf.Index(0), ComponentType: gltf.ComponentUint, Type: gltf.AccessorScalar, Count: -3},
+		},
+		Meshes: []*gltf.Mesh{{Primitives: []*gltf.Primitive{{Attributes: gltf.PrimitiveAttributes{gltf.POSITION: 0}}}}},
+	}
+
+	if _, err := ReadFloat(doc, doc.Accessors[0]); err == nil {
+		t.Error("Expected a negative float accessor count to fail")
+	}
+	if _, err := ReadUint(doc, doc.Accessors[1]); err == nil {
+		t.Error("Expected a negative integer accessor count to fail")
+	}
+	if _, err := ReadPrimitiveIndices(doc, doc.Meshes[0].Primitives[0]); err == nil {
+		t.Error("Expected unindexed positions with a negative count to fail")
+	}
+}
+
+func TestReadHugeCount(t *testing.T) {
+	t.Parallel()
+
+	doc := &gltf.Document{
+		Buffers:     []*gltf.Buffer{{ByteLength: 12, Data: make([]byte, 12)}},
+		BufferViews: []*gltf.BufferView{{Buffer: 0, ByteLength: 12}},
+		Accessors: []*gltf.Accessor{
+			{BufferView: gltf.Index(0), ComponentType: gltf.ComponentFloat, Type: gltf.AccessorVec3, Count: 1 << 62},
+			{BufferView: gltf.Index(0), ComponentType: gltf.ComponentUint, Type: gltf.AccessorScalar, Count: 1 << 62},
+			{ComponentType: gltf.ComponentFloat, Type: gltf.AccessorVec3, Count: 1 << 62},
+		},
+		Meshes: []*gltf.Mesh{{Primitives: []*gltf.Primitive{{Attributes: gltf.PrimitiveAttributes{gltf.POSITION: 2}}}}},
+	}
+
+	if _, err := ReadFloat(doc, doc.Accessors[0]); err == nil {
+		t.Error("Expected a float accessor count past its buffer view to fail")
+	}
+	if _, err := ReadUint(doc, doc.Accessors[1]); err == nil {
+		t.Error("Expected an integer accessor count past its buffer view to fail")
+	}
+	if _, err := ReadFloat(doc, doc.Accessors[2]); err == nil {
+		t.Error("Expected a huge accessor without a buffer view to fail")
+	}
+	if _, err := ReadPrimitiveIndices(doc, doc.Meshes[0].Primitives[0]); err == nil {
+		t.Error("Expected unindexed positions with a huge count to fail")
+	}
+}
@@ -0,0 +1,106 @@
+package modeler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"neuroscan/pkg/gltf"
+)
+
+// WritePosition appends the positions to the document's first buffer and returns the index of
+// their accessor. The accessor carries the min and max bounds the glTF spec requires for positions.
+func WritePosition(doc *gltf.Document, data [][3]float32) int {
+	index := writeVec3(doc, data, gltf.TargetArrayBuffer)
+	if len(data) > 0 {
+		lo := []float64{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64}
+		hi := []float64{-math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64}
+		for _, p := range data {
+			for i := range 3 {
+				lo[i] = math.Min(lo[i], float64(p[i]))
+				hi[i] = math.Max(hi[i], float64(p[i]))
+			}
+		}
+		doc.Accessors[index].Min = lo
+		doc.Accessors[index].Max = hi
+	}
+	return index
+}
+
+// WriteNormal appends the normals to the document's first buffer and returns the index of their accessor.
+func WriteNormal(doc *gltf.Document, data [][3]float32) int {
+	return writeVec3(doc, data, gltf.TargetArrayBuffer)
+}
+
+// WriteIndices appends the indices to the document's first buffer and returns the index of their
+// accessor, using the smallest component type that holds every index.
+func WriteIndices(doc *gltf.Document, data []uint32) int {
+	var largest uint32
+	for _, i := range data {
+		largest = max(largest, i)
+	}
+
+	var buf bytes.Buffer
+	componentType := gltf.ComponentUint
+	switch {
+	case largest <= math.MaxUint8:
+		componentType = gltf.ComponentUbyte
+		for _, i := range data {
+			buf.WriteByte(uint8(i))
+		}
+	case largest <= math.MaxUint16:
+		componentType = gltf.ComponentUshort
+		for _, i := range data {
+			binary.Write(&buf, binary.LittleEndian, uint16(i))
+		}
+	default:
+		binary.Write(&buf, binary.LittleEndian, data)
+	}
+
+	view := writeBufferView(doc, buf.Bytes(), 0, gltf.TargetElementArrayBuffer)
+	doc.Accessors = append(doc.Accessors, &gltf.Accessor{
+		BufferView:    gltf.Index(view),
+		ComponentType: componentType,
+		Type:          gltf.AccessorScalar,
+		Count:         len(data),
+	})
+	return len(doc.Accessors) - 1
+}
+
+func writeVec3(doc *gltf.Document, data [][3]float32, target gltf.Target) int {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, data)
+
+	view := writeBufferView(doc, buf.Bytes(), 0, target)
+	doc.Accessors = append(doc.Accessors, &gltf.Accessor{
+		BufferView:    gltf.Index(view),
+		ComponentType: gltf.ComponentFloat,
+		Type:          gltf.AccessorVec3,
+		Count:         len(data),
+	})
+	return len(doc.Accessors) - 1
+}
+
+// writeBufferView appends data to the first buffer, creating it when missing, aligned to 4 bytes.
+func writeBufferView(doc *gltf.Document, data []byte, stride int, target gltf.Target) int {
+	if len(doc.Buffers) == 0 {
+		doc.Buffers = []*gltf.Buffer{{}}
+	}
+	buffer := doc.Buffers[0]
+	for len(buffer.Data)%4 != 0 {
+		buffer.Data = append(buffer.Data, 0)
+	}
+
+	offset := len(buffer.Data)
+	buffer.Data = append(buffer.Data, data...)
+	buffer.ByteLength = len(buffer.Data)
+
+	doc.BufferViews = append(doc.BufferViews, &gltf.BufferView{
+		Buffer:     0,
+		ByteOffset: offset,
+		ByteLength: len(data),
+		ByteStride: stride,
+		Target:     target,
+	})
+	return len(doc.BufferViews) - 1
+}
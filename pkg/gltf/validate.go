@@ -0,0 +1,438 @@
+package gltf
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidDocument is wrapped by the error Diagnostics.Err returns.
+var ErrInvalidDocument = errors.New("gltf: invalid document")
+
+// MaxZeroAccessorCount caps the count of an accessor without a buffer view. It reads as zeros, so
+// no data bounds how much has to be allocated for it.
+const MaxZeroAccessorCount = 1 << 24
+
+// Severity tells whether a diagnostic makes the document unusable.
+type Severity uint8
+
+const (
+	SeverityError   Severity = iota // the document breaks the spec and can't be read safely
+	SeverityWarning                 // the document is readable but not what the spec recommends
+)
+
+// DiagnosticCode identifies the rule a diagnostic was raised by.
+type DiagnosticCode string
+
+const (
+	CodeRequired        DiagnosticCode = "REQUIRED"           // a required property is missing or empty
+	CodeIndexOutOfRange DiagnosticCode = "INDEX_OUT_OF_RANGE" // an index points past the end of the array it refers to
+	CodeAccessorRange   DiagnosticCode = "ACCESSOR_RANGE"     // an accessor reads past the end of its buffer view
+	CodeBufferViewRange DiagnosticCode = "BUFFER_VIEW_RANGE"  // a buffer view reads past the end of its buffer
+	CodeBufferLength    DiagnosticCode = "BUFFER_LENGTH"      // a buffer holds less data than its byteLength
+	CodeAlignment       DiagnosticCode = "ALIGNMENT"          // an offset or stride is not a multiple of the component size
+	CodeInvalidValue    DiagnosticCode = "INVALID_VALUE"      // a property has a value the spec doesn't allow
+	CodeAttributeCount  DiagnosticCode = "ATTRIBUTE_COUNT"    // a primitive's attributes have different counts
+	CodeNodeParent      DiagnosticCode = "NODE_PARENT"        // a node is the child of more than one node, or of itself
+	CodeMissingMinMax   DiagnosticCode = "MISSING_MIN_MAX"    // a POSITION accessor has no bounds
+	CodeMissingPosition DiagnosticCode = "MISSING_POSITION"   // a primitive has no POSITION attribute
+	CodeMatrixAndTRS    DiagnosticCode = "MATRIX_AND_TRS"     // a node has both a matrix and translation, rotation or scale
+)
+
+// A Diagnostic is a problem Validate found in a document. Path is a JSON pointer to the offending
+// property, e.g. /accessors/3/bufferView.
+type Diagnostic struct {
+	Severity Severity       `json:"severity"`
+	Code     DiagnosticCode `json:"code"`
+	Path     string         `json:"path"`
+	Message  string         `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	level := "error"
+	if d.Severity == SeverityWarning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%s %s at %s: %s", level, d.Code, d.Path, d.Message)
+}
+
+// Diagnostics are the problems found in a document, in the order of its properties.
+type Diagnostics []Diagnostic
+
+// Errors returns the diagnostics with SeverityError.
+func (ds Diagnostics) Errors() Diagnostics {
+	var errs Diagnostics
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+	return errs
+}
+
+// Warnings returns the diagnostics with SeverityWarning.
+func (ds Diagnostics) Warnings() Diagnostics {
+	var warnings Diagnostics
+	for _, d := range ds {
+		if d.Severity == SeverityWarning {
+			warnings = append(warnings, d)
+		}
+	}
+	return warnings
+}
+
+// Err returns an error listing the diagnostics with SeverityError, nil when there are none.
+func (ds Diagnostics) Err() error {
+	errs := ds.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(errs))
+	for i, d := range errs {
+		messages[i] = d.String()
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidDocument, strings.Join(messages, "; "))
+}
+
+// Validate checks the structural rules of the glTF 2.0 spec: that indices point at existing
+// objects, that accessors and buffer views stay within the data they read, and that required
+// properties are set. It doesn't decode images or check extensions.
+func Validate(doc *Document) Diagnostics {
+	v := &validator{doc: doc}
+
+	if doc.Asset.Version == "" {
+		v.errorf(CodeRequired, "/asset/version", "asset version is required")
+	}
+
+	v.index("/scene", doc.Scene, len(doc.Scenes))
+	for i, scene := range doc.Scenes {
+		for j, node := range scene.Nodes {
+			v.index(fmt.Sprintf("/scenes/%d/nodes/%d", i, j), &node, len(doc.Nodes))
+		}
+	}
+
+	for i, buffer := range doc.Buffers {
+		v.buffer(i, buffer)
+	}
+	for i, view := range doc.BufferViews {
+		v.bufferView(i, view)
+	}
+	for i, accessor := range doc.Accessors {
+		v.accessor(i, accessor)
+	}
+	for i, mesh := range doc.Meshes {
+		v.mesh(i, mesh)
+	}
+	v.nodes()
+	for i, material := range doc.Materials {
+		v.material(i, material)
+	}
+	for i, texture := range doc.Textures {
+		v.index(fmt.Sprintf("/textures/%d/sampler", i), texture.Sampler, len(doc.Samplers))
+		v.index(fmt.Sprintf("/textures/%d/source", i), texture.Source, len(doc.Images))
+	}
+	for i, image := range doc.Images {
+		path := fmt.Sprintf("/images/%d", i)
+		if image.URI == "" && image.BufferView == nil {
+			v.errorf(CodeRequired, path, "image needs a uri or a bufferView")
+		}
+		if image.BufferView != nil && image.MimeType == "" {
+			v.errorf(CodeRequired, path+"/mimeType", "mimeType is required with a bufferView")
+		}
+		v.index(path+"/bufferView", image.BufferView, len(doc.BufferViews))
+	}
+	for i, skin := range doc.Skins {
+		path := fmt.Sprintf("/skins/%d", i)
+		v.index(path+"/inverseBindMatrices", skin.InverseBindMatrices, len(doc.Accessors))
+		v.index(path+"/skeleton", skin.Skeleton, len(doc.Nodes))
+		if len(skin.Joints) == 0 {
+			v.errorf(CodeRequired, path+"/joints", "skin needs at least one joint")
+		}
+		for j, joint := range skin.Joints {
+			v.index(fmt.Sprintf("%s/joints/%d", path, j), &joint, len(doc.Nodes))
+		}
+	}
+	for i, animation := range doc.Animations {
+		v.animation(i, animation)
+	}
+
+	return v.diagnostics
+}
+
+type validator struct {
+	doc         *Document
+	diagnostics Diagnostics
+}
+
+func (v *validator) errorf(code DiagnosticCode, path, format string, args ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{Severity: SeverityError, Code: code, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(code DiagnosticCode, path, format string, args ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{Severity: SeverityWarning, Code: code, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// index reports an index that is set but outside [0, length), it returns whether the index is usable.
+func (v *validator) index(path string, index *int, length int) bool {
+	if index == nil {
+		return false
+	}
+	if *index < 0 || *index >= length {
+		v.errorf(CodeIndexOutOfRange, path, "index %d is out of range, there are %d", *index, length)
+		return false
+	}
+	return true
+}
+
+func (v *validator) buffer(i int, buffer *Buffer) {
+	path := fmt.Sprintf("/buffers/%d", i)
+	if buffer.ByteLength < 1 {
+		v.errorf(CodeRequired, path+"/byteLength", "byteLength must be at least 1")
+	}
+	// external buffers may be left unloaded, only data that was read is checked
+	if len(buffer.Data) > 0 && len(buffer.Data) < buffer.ByteLength {
+		v.errorf(CodeBufferLength, path, "buffer holds %d bytes, byteLength is %d", len(buffer.Data), buffer.ByteLength)
+	}
+}
+
+func (v *validator) bufferView(i int, view *BufferView) {
+	path := fmt.Sprintf("/bufferViews/%d", i)
+	if view.ByteLength < 1 {
+		v.errorf(CodeRequired, path+"/byteLength", "byteLength must be at least 1")
+	}
+	if view.ByteOffset < 0 {
+		v.errorf(CodeInvalidValue, path+"/byteOffset", "byteOffset must not be negative")
+	}
+	if view.ByteStride != 0 && (view.ByteStride < 4 || view.ByteStride > 252 || view.ByteStride%4 != 0) {
+		v.errorf(CodeInvalidValue, path+"/byteStride", "byteStride %d must be a multiple of 4 between 4 and 252", view.ByteStride)
+	}
+	if v.index(path+"/buffer", &view.Buffer, len(v.doc.Buffers)) {
+		// subtracting keeps a huge byteLength from overflowing the end of the view
+		if length := v.doc.Buffers[view.Buffer].ByteLength; view.ByteLength > length-view.ByteOffset {
+			v.errorf(CodeBufferViewRange, path, "view of %d bytes from byte %d doesn't fit the %d bytes of buffer %d", view.ByteLength, view.ByteOffset, length, view.Buffer)
+		}
+	}
+}
+
+func (v *validator) accessor(i int, accessor *Accessor) {
+	path := fmt.Sprintf("/accessors/%d", i)
+	if accessor.Count < 1 {
+		v.errorf(CodeRequired, path+"/count", "count must be at least 1")
+	}
+
+	components := accessor.Type.Components()
+	if accessor.Min != nil && len(accessor.Min) != components {
+		v.errorf(CodeInvalidValue, path+"/min", "min has %d components, type needs %d", len(accessor.Min), components)
+	}
+	if accessor.Max != nil && len(accessor.Max) != components {
+		v.errorf(CodeInvalidValue, path+"/max", "max has %d components, type needs %d", len(accessor.Max), components)
+	}
+
+	if accessor.BufferView == nil && accessor.Count > MaxZeroAccessorCount {
+		v.errorf(CodeInvalidValue, path+"/count", "count %d of an accessor without a buffer view is more than %d", accessor.Count, MaxZeroAccessorCount)
+	}
+
+	componentSize := accessor.ComponentType.ByteSize()
+	if accessor.ByteOffset < 0 {
+		v.errorf(CodeInvalidValue, path+"/byteOffset", "byteOffset must not be negative")
+	} else if accessor.ByteOffset%componentSize != 0 {
+		v.errorf(CodeAlignment, path+"/byteOffset", "byteOffset %d is not a multiple of the component size %d", accessor.ByteOffset, componentSize)
+	}
+
+	if v.index(path+"/bufferView", accessor.BufferView, len(v.doc.BufferViews)) && accessor.Count > 0 {
+		view := v.doc.BufferViews[*accessor.BufferView]
+		elementSize := SizeOfElement(accessor.ComponentType, accessor.Type)
+		stride := elementSize
+		if view.ByteStride != 0 {
+			stride = view.ByteStride
+			if stride%componentSize != 0 {
+				v.errorf(CodeAlignment, fmt.Sprintf("/bufferViews/%d/byteStride", *accessor.BufferView), "byteStride %d is not a multiple of the component size %d", stride, componentSize)
+			}
+		}
+		// dividing keeps a huge count from overflowing the end of the accessor
+		if room := view.ByteLength - accessor.ByteOffset - elementSize; room < 0 || accessor.Count-1 > room/stride {
+			v.errorf(CodeAccessorRange, path, "%d elements %d bytes apart from byte %d don't fit the %d bytes of buffer view %d", accessor.Count, stride, accessor.ByteOffset, view.ByteLength, *accessor.BufferView)
+		}
+	}
+
+	if sparse := accessor.Sparse; sparse != nil {
+		if sparse.Count < 1 || sparse.Count > accessor.Count {
+			v.errorf(CodeInvalidValue, path+"/sparse/count", "sparse count %d must be between 1 and the accessor count %d", sparse.Count, accessor.Count)
+		}
+		if v.index(path+"/sparse/indices/bufferView", &sparse.Indices.BufferView, len(v.doc.BufferViews)) {
+			length := v.doc.BufferViews[sparse.Indices.BufferView].ByteLength
+			if room := length - sparse.Indices.ByteOffset; sparse.Indices.ByteOffset < 0 || room < 0 || sparse.Count > room/sparse.Indices.ComponentType.ByteSize() {
+				v.errorf(CodeAccessorRange, path+"/sparse/indices", "%d sparse indices from byte %d don't fit the %d bytes of buffer view %d", sparse.Count, sparse.Indices.ByteOffset, length, sparse.Indices.BufferView)
+			}
+		}
+		if v.index(path+"/sparse/values/bufferView", &sparse.Values.BufferView, len(v.doc.BufferViews)) {
+			length := v.doc.BufferViews[sparse.Values.BufferView].ByteLength
+			if room := length - sparse.Values.ByteOffset; sparse.Values.ByteOffset < 0 || room < 0 || sparse.Count > room/SizeOfElement(accessor.ComponentType, accessor.Type) {
+				v.errorf(CodeAccessorRange, path+"/sparse/values", "%d sparse values from byte %d don't fit the %d bytes of buffer view %d", sparse.Count, sparse.Values.ByteOffset, length, sparse.Values.BufferView)
+			}
+		}
+	}
+}
+
+func (v *validator) mesh(i int, mesh *Mesh) {
+	path := fmt.Sprintf("/meshes/%d", i)
+	if len(mesh.Primitives) == 0 {
+		v.errorf(CodeRequired, path+"/primitives", "mesh needs at least one primitive")
+	}
+
+	for j, primitive := range mesh.Primitives {
+		primitivePath := fmt.Sprintf("%s/primitives/%d", path, j)
+		v.index(primitivePath+"/material", primitive.Material, len(v.doc.Materials))
+
+		if len(primitive.Attributes) == 0 {
+			v.errorf(CodeRequired, primitivePath+"/attributes", "primitive needs at least one attribute")
+		}
+		if _, ok := primitive.Attributes[POSITION]; !ok && len(primitive.Attributes) > 0 {
+			v.warnf(CodeMissingPosition, primitivePath+"/attributes", "primitive has no POSITION and won't be rendered")
+		}
+
+		count := -1
+		for _, name := range sortedAttributes(primitive.Attributes) {
+			accessor := primitive.Attributes[name]
+			attributePath := primitivePath + "/attributes/" + name
+			if !v.index(attributePath, &accessor, len(v.doc.Accessors)) {
+				continue
+			}
+
+			a := v.doc.Accessors[accessor]
+			if count == -1 {
+				count = a.Count
+			} else if a.Count != count {
+				v.errorf(CodeAttributeCount, attributePath, "attribute has %d elements, the others have %d", a.Count, count)
+			}
+
+			if name == POSITION {
+				if a.Type != AccessorVec3 {
+					v.errorf(CodeInvalidValue, attributePath, "POSITION must be a VEC3 accessor")
+				}
+				if a.Min == nil || a.Max == nil {
+					v.warnf(CodeMissingMinMax, fmt.Sprintf("/accessors/%d", accessor), "POSITION accessor should have min and max")
+				}
+			}
+		}
+
+		if v.index(primitivePath+"/indices", primitive.Indices, len(v.doc.Accessors)) {
+			a := v.doc.Accessors[*primitive.Indices]
+			if a.Type != AccessorScalar {
+				v.errorf(CodeInvalidValue, primitivePath+"/indices", "indices must be a SCALAR accessor")
+			}
+			if a.ComponentType != ComponentUbyte && a.ComponentType != ComponentUshort && a.ComponentType != ComponentUint {
+				v.errorf(CodeInvalidValue, primitivePath+"/indices", "indices must be unsigned integers")
+			}
+		}
+
+		for k, target := range primitive.Targets {
+			for _, name := range sortedAttributes(target) {
+				accessor := target[name]
+				v.index(fmt.Sprintf("%s/targets/%d/%s", primitivePath, k, name), &accessor, len(v.doc.Accessors))
+			}
+		}
+	}
+}
+
+func (v *validator) nodes() {
+	parents := make([]int, len(v.doc.Nodes))
+	for i := range parents {
+		parents[i] = -1
+	}
+
+	for i, node := range v.doc.Nodes {
+		path := fmt.Sprintf("/nodes/%d", i)
+		v.index(path+"/mesh", node.Mesh, len(v.doc.Meshes))
+		v.index(path+"/camera", node.Camera, len(v.doc.Cameras))
+		v.index(path+"/skin", node.Skin, len(v.doc.Skins))
+
+		if node.Matrix != emptyMatrix && node.Matrix != DefaultMatrix &&
+			((node.Translation != DefaultTranslation) || (node.Rotation != emptyRotation && node.Rotation != DefaultRotation) || (node.Scale != emptyScale && node.Scale != DefaultScale)) {
+			v.warnf(CodeMatrixAndTRS, path, "node has both a matrix and translation, rotation or scale, the matrix wins")
+		}
+
+		for j, child := range node.Children {
+			childPath := fmt.Sprintf("%s/children/%d", path, j)
+			if !v.index(childPath, &child, len(v.doc.Nodes)) {
+				continue
+			}
+			switch {
+			case child == i:
+				v.errorf(CodeNodeParent, childPath, "node %d is its own child", i)
+			case parents[child] != -1:
+				v.errorf(CodeNodeParent, childPath, "node %d is already a child of node %d", child, parents[child])
+			default:
+				parents[child] = i
+			}
+		}
+	}
+
+	// a chain of parents that comes back to where it started is a cycle
+	for i := range parents {
+		seen := map[int]bool{i: true}
+		for parent := parents[i]; parent != -1; parent = parents[parent] {
+			if seen[parent] {
+				v.errorf(CodeNodeParent, fmt.Sprintf("/nodes/%d", i), "node %d is part of a cycle", i)
+				break
+			}
+			seen[parent] = true
+		}
+	}
+}
+
+func (v *validator) material(i int, material *Material) {
+	path := fmt.Sprintf("/materials/%d", i)
+	textures := len(v.doc.Textures)
+	if pbr := material.PBRMetallicRoughness; pbr != nil {
+		if pbr.BaseColorTexture != nil {
+			v.index(path+"/pbrMetallicRoughness/baseColorTexture/index", &pbr.BaseColorTexture.Index, textures)
+		}
+		if pbr.MetallicRoughnessTexture != nil {
+			v.index(path+"/pbrMetallicRoughness/metallicRoughnessTexture/index", &pbr.MetallicRoughnessTexture.Index, textures)
+		}
+		if pbr.BaseColorFactor != nil {
+			for _, c := range pbr.BaseColorFactor {
+				if c < 0 || c > 1 {
+					v.errorf(CodeInvalidValue, path+"/pbrMetallicRoughness/baseColorFactor", "color components must be between 0 and 1")
+					break
+				}
+			}
+		}
+	}
+	if material.NormalTexture != nil {
+		v.index(path+"/normalTexture/index", material.NormalTexture.Index, textures)
+	}
+	if material.OcclusionTexture != nil {
+		v.index(path+"/occlusionTexture/index", material.OcclusionTexture.Index, textures)
+	}
+	if material.EmissiveTexture != nil {
+		v.index(path+"/emissiveTexture/index", &material.EmissiveTexture.Index, textures)
+	}
+}
+
+func (v *validator) animation(i int, animation *Animation) {
+	path := fmt.Sprintf("/animations/%d", i)
+	if len(animation.Channels) == 0 {
+		v.errorf(CodeRequired, path+"/channels", "animation needs at least one channel")
+	}
+	if len(animation.Samplers) == 0 {
+		v.errorf(CodeRequired, path+"/samplers", "animation needs at least one sampler")
+	}
+	for j, sampler := range animation.Samplers {
+		v.index(fmt.Sprintf("%s/samplers/%d/input", path, j), &sampler.Input, len(v.doc.Accessors))
+		v.index(fmt.Sprintf("%s/samplers/%d/output", path, j), &sampler.Output, len(v.doc.Accessors))
+	}
+	for j, channel := range animation.Channels {
+		v.index(fmt.Sprintf("%s/channels/%d/sampler", path, j), &channel.Sampler, len(animation.Samplers))
+		v.index(fmt.Sprintf("%s/channels/%d/target/node", path, j), channel.Target.Node, len(v.doc.Nodes))
+	}
+}
+
+// sortedAttributes returns the attribute names in order so diagnostics are stable.
+func sortedAttributes(attributes PrimitiveAttributes) []string {
+	return slices.Sorted(maps.Keys(attributes))
+}
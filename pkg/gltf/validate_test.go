@@ -0,0 +1,87 @@
+package gltf
+
+import (
+	"errors"
+	"testing"
+)
+
+func validTriangle() *Document {
+	doc := NewDocument()
+	doc.Buffers = []*Buffer{{ByteLength: 42, Data: make([]byte, 42)}}
+	doc.BufferViews = []*BufferView{
+		{Buffer: 0, ByteLength: 36, Target: TargetArrayBuffer},
+		{Buffer: 0, ByteOffset: 36, ByteLength: 6, Target: TargetElementArrayBuffer},
+	}
+	doc.Accessors = []*Accessor{
+		{BufferView: Index(0), ComponentType: ComponentFloat, Type: AccessorVec3, Count: 3, Min: []float64{0, 0, 0}, Max: []float64{1, 1, 0}},
+		{BufferView: Index(1), ComponentType: ComponentUshort, Type: AccessorScalar, Count: 3},
+	}
+	doc.Meshes = []*Mesh{{Primitives: []*Primitive{{Attributes: PrimitiveAttributes{POSITION: 0}, Indices: Index(1)}}}}
+	doc.Nodes = []*Node{{Name: "ADAL", Mesh: Index(0)}}
+	doc.Scenes[0].Nodes = []int{0}
+	return doc
+}
+
+func TestValidateValidDocument(t *testing.T) {
+	t.Parallel()
+
+	if diagnostics := Validate(validTriangle()); len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestValidateDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mutate   func(doc *Document)
+		code     DiagnosticCode
+		path     string
+		severity Severity
+	}{
+		{"missing version", func(doc *Document) { doc.Asset.Version = "" }, CodeRequired, "/asset/version", SeverityError},
+		{"node mesh out of range", func(doc *Document) { doc.Nodes[0].Mesh = Index(3) }, CodeIndexOutOfRange, "/nodes/0/mesh", SeverityError},
+		{"material out of range", func(doc *Document) { doc.Meshes[0].Primitives[0].Material = Index(0) }, CodeIndexOutOfRange, "/meshes/0/primitives/0/material", SeverityError},
+		{"accessor past view", func(doc *Document) { doc.Accessors[0].Count = 4 }, CodeAccessorRange, "/accessors/0", SeverityError},
+		{"accessor count overflowing its end", func(doc *Document) { doc.Accessors[0].Count = 4611686018427387905 }, CodeAccessorRange, "/accessors/0", SeverityError},
+		{"sparse indices before their view", func(doc *Document) {
+			doc.Accessors[0].Sparse = &Sparse{Count: 3, Indices: SparseIndices{BufferView: 1, ComponentType: ComponentUshort}, Values: SparseValues{BufferView: 0}}
+			doc.Accessors[0].Sparse.Indices.ByteOffset = -9223372036854775807
+		}, CodeAccessorRange, "/accessors/0/sparse/indices", SeverityError},
+		{"huge accessor without a buffer view", func(doc *Document) {
+			doc.Accessors = append(doc.Accessors, &Accessor{ComponentType: ComponentFloat, Type: AccessorVec3, Count: MaxZeroAccessorCount + 1})
+			doc.Meshes[0].Primitives[0].Targets = []PrimitiveAttributes{{POSITION: 2}}
+		}, CodeInvalidValue, "/accessors/2/count", SeverityError},
+		{"view past buffer", func(doc *Document) { doc.BufferViews[1].ByteLength = 8 }, CodeBufferViewRange, "/bufferViews/1", SeverityError},
+		{"short buffer data", func(doc *Document) { doc.Buffers[0].Data = make([]byte, 10) }, CodeBufferLength, "/buffers/0", SeverityError},
+		{"signed indices", func(doc *Document) { doc.Accessors[1].ComponentType = ComponentShort }, CodeInvalidValue, "/meshes/0/primitives/0/indices", SeverityError},
+		{"node cycle", func(doc *Document) { doc.Nodes[0].Children = []int{0} }, CodeNodeParent, "/nodes/0/children/0", SeverityError},
+		{"missing position bounds", func(doc *Document) { doc.Accessors[0].Min = nil }, CodeMissingMinMax, "/accessors/0", SeverityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := validTriangle()
+			tt.mutate(doc)
+
+			diagnostics := Validate(doc)
+			if len(diagnostics) != 1 {
+				t.Fatalf("Expected 1 diagnostic, got %v", diagnostics)
+			}
+
+			d := diagnostics[0]
+			if d.Code != tt.code || d.Path != tt.path || d.Severity != tt.severity {
+				t.Errorf("Expected %s at %s, got %v", tt.code, tt.path, d)
+			}
+
+			if err := diagnostics.Err(); (err != nil) != (tt.severity == SeverityError) {
+				t.Errorf("Expected Err to report only errors, got %v", err)
+			} else if err != nil && !errors.Is(err, ErrInvalidDocument) {
+				t.Errorf("Expected Err to wrap ErrInvalidDocument, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package mesh
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Formats the mesh can be exported to.
+const (
+	FormatOBJ = "obj"
+	FormatSTL = "stl"
+	FormatPLY = "ply"
+)
+
+// Formats lists every export format, in the order they are documented.
+var Formats = []string{FormatOBJ, FormatSTL, FormatPLY}
+
+// Write encodes the mesh in format. The comment, such as the units the positions are in,
+// is written to the header of the formats that have one.
+func (m *Mesh) Write(w io.Writer, format string, name string, comment string) error {
+	switch format {
+	case FormatOBJ:
+		return m.WriteOBJ(w, name, comment)
+	case FormatSTL:
+		return m.WriteSTL(w, name)
+	case FormatPLY:
+		return m.WritePLY(w, comment)
+	}
+	return fmt.Errorf("mesh: unknown format %q", format)
+}
+
+// WriteOBJ writes the mesh as a Wavefront OBJ object with smooth vertex normals.
+func (m *Mesh) WriteOBJ(w io.Writer, name string, comment string) error {
+	bw := bufio.NewWriter(w)
+
+	if comment != "" {
+		fmt.Fprintf(bw, "# %s\n", comment)
+	}
+	fmt.Fprintf(bw, "o %s\n", name)
+
+	for _, p := range m.Positions {
+		fmt.Fprintf(bw, "v %g %g %g\n", p[0], p[1], p[2])
+	}
+	for _, n := range m.Normals() {
+		fmt.Fprintf(bw, "vn %g %g %g\n", n[0], n[1], n[2])
+	}
+
+	// OBJ indices start at 1
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Indices[i]+1, m.Indices[i+1]+1, m.Indices[i+2]+1
+		fmt.Fprintf(bw, "f %d//%d %d//%d %d//%d\n", a, a, b, b, c, c)
+	}
+
+	return bw.Flush()
+}
+
+// WriteSTL writes the mesh as binary STL, the format 3D printing slicers expect.
+// STL has no shared vertices or units, every triangle carries its own corners and facet normal.
+func (m *Mesh) WriteSTL(w io.Writer, name string) error {
+	bw := bufio.NewWriter(w)
+
+	var header [80]byte
+	copy(header[:], name)
+	bw.Write(header[:])
+
+	triangles := len(m.Indices) / 3
+	if err := binary.Write(bw, binary.LittleEndian, uint32(triangles)); err != nil {
+		return err
+	}
+
+	var facet struct {
+		Normal    [3]float32
+		Vertices  [3][3]float32
+		Attribute uint16
+	}
+	for i := 0; i < triangles; i++ {
+		a, b, c := m.Positions[m.Indices[i*3]], m.Positions[m.Indices[i*3+1]], m.Positions[m.Indices[i*3+2]]
+
+		n := cross(sub(b, a), sub(c, a))
+		if l := length(n); l > 0 {
+			n = [3]float64{n[0] / l, n[1] / l, n[2] / l}
+		}
+		facet.Normal = toFloat32(n)
+		facet.Vertices = [3][3]float32{toFloat32(a), toFloat32(b), toFloat32(c)}
+
+		if err := binary.Write(bw, binary.LittleEndian, &facet); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WritePLY writes the mesh as binary little endian PLY with vertex normals, as read by MeshLab.
+func (m *Mesh) WritePLY(w io.Writer, comment string) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "ply")
+	fmt.Fprintln(bw, "format binary_little_endian 1.0")
+	if comment != "" {
+		fmt.Fprintf(bw, "comment %s\n", comment)
+	}
+	fmt.Fprintf(bw, "element vertex %d\n", len(m.Positions))
+	fmt.Fprintln(bw, "property float x\nproperty float y\nproperty float z")
+	fmt.Fprintln(bw, "property float nx\nproperty float ny\nproperty float nz")
+	fmt.Fprintf(bw, "element face %d\n", len(m.Indices)/3)
+	fmt.Fprintln(bw, "property list uchar uint vertex_indices")
+	fmt.Fprintln(bw, "end_header")
+
+	normals := m.Normals()
+	for i, p := range m.Positions {
+		vertex := [6]float32{float32(p[0]), float32(p[1]), float32(p[2]), float32(normals[i][0]), float32(normals[i][1]), float32(normals[i][2])}
+		if err := binary.Write(bw, binary.LittleEndian, &vertex); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		bw.WriteByte(3)
+		if err := binary.Write(bw, binary.LittleEndian, m.Indices[i:i+3]); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func toFloat32(p [3]float64) [3]float32 {
+	return [3]float32{float32(p[0]), float32(p[1]), float32(p[2])}
+}
@@ -0,0 +1,93 @@
+package mesh
+
+import (
+	"errors"
+	"fmt"
+
+	"neuroscan/pkg/gltf"
+	"neuroscan/pkg/gltf/modeler"
+)
+
+// LOD builds a simplified copy of the document, keeping ratio of each mesh node's triangles.
+// Node transforms are baked into the positions so the hierarchy is flattened, every mesh node
+// becomes a root node with its name, extras and the base color of its first material.
+func LOD(doc *gltf.Document, ratio float64) (*gltf.Document, error) {
+	if ratio <= 0 || ratio > 1 {
+		return nil, fmt.Errorf("mesh: lod ratio must be in (0, 1], got %v", ratio)
+	}
+
+	out := gltf.NewDocument()
+	out.Asset.Generator = "neuroscan"
+
+	for i, node := range doc.Nodes {
+		if node.Mesh == nil {
+			continue
+		}
+
+		m, err := FromNode(doc, i)
+		if err != nil {
+			return nil, err
+		}
+
+		simplified := m.Simplify(int(float64(len(m.Indices)/3) * ratio))
+
+		positions := make([][3]float32, len(simplified.Positions))
+		for j, p := range simplified.Positions {
+			positions[j] = [3]float32{float32(p[0]), float32(p[1]), float32(p[2])}
+		}
+		normals := make([][3]float32, len(simplified.Positions))
+		for j, n := range simplified.Normals() {
+			normals[j] = [3]float32{float32(n[0]), float32(n[1]), float32(n[2])}
+		}
+
+		primitive := &gltf.Primitive{
+			Attributes: gltf.PrimitiveAttributes{
+				gltf.POSITION: modeler.WritePosition(out, positions),
+				gltf.NORMAL:   modeler.WriteNormal(out, normals),
+			},
+			Indices: gltf.Index(modeler.WriteIndices(out, simplified.Indices)),
+		}
+		if material := lodMaterial(doc, *node.Mesh); material != nil {
+			out.Materials = append(out.Materials, material)
+			primitive.Material = gltf.Index(len(out.Materials) - 1)
+		}
+
+		out.Meshes = append(out.Meshes, &gltf.Mesh{Name: doc.Meshes[*node.Mesh].Name, Primitives: []*gltf.Primitive{primitive}})
+		out.Nodes = append(out.Nodes, &gltf.Node{Name: node.Name, Extras: node.Extras, Mesh: gltf.Index(len(out.Meshes) - 1)})
+		out.Scenes[0].Nodes = append(out.Scenes[0].Nodes, len(out.Nodes)-1)
+	}
+
+	if len(out.Nodes) == 0 {
+		return nil, errors.New("mesh: document has no mesh nodes")
+	}
+
+	return out, nil
+}
+
+// lodMaterial copies the color of the mesh's first material, textures are dropped
+// along with the texture coordinates.
+func lodMaterial(doc *gltf.Document, meshIndex int) *gltf.Material {
+	for _, primitive := range doc.Meshes[meshIndex].Primitives {
+		if primitive.Material == nil || *primitive.Material < 0 || *primitive.Material >= len(doc.Materials) {
+			continue
+		}
+
+		source := doc.Materials[*primitive.Material]
+		material := &gltf.Material{
+			Name:        source.Name,
+			AlphaMode:   source.AlphaMode,
+			AlphaCutoff: source.AlphaCutoff,
+			DoubleSided: source.DoubleSided,
+		}
+		if source.PBRMetallicRoughness != nil {
+			color := source.PBRMetallicRoughness.BaseColorFactorOrDefault()
+			material.PBRMetallicRoughness = &gltf.PBRMetallicRoughness{
+				BaseColorFactor: &color,
+				MetallicFactor:  source.PBRMetallicRoughness.MetallicFactor,
+				RoughnessFactor: source.PBRMetallicRoughness.RoughnessFactor,
+			}
+		}
+		return material
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+// Package mesh builds triangle meshes from glTF nodes and measures them.
+package mesh
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"neuroscan/pkg/gltf"
+	"neuroscan/pkg/gltf/modeler"
+)
+
+// Mesh is an indexed triangle mesh, every three indices make a triangle.
+type Mesh struct {
+	Positions [][3]float64
+	Indices   []uint32
+}
+
+// FromNode builds the mesh of a node, with the node's world transform applied
+// so that measurements are in scene units. Primitives that are not made of
+// triangles (points and lines) are skipped.
+func FromNode(doc *gltf.Document, node int) (*Mesh, error) {
+	if node < 0 || node >= len(doc.Nodes) {
+		return nil, fmt.Errorf("mesh: node %d out of range", node)
+	}
+
+	if doc.Nodes[node].Mesh == nil {
+		return nil, fmt.Errorf("mesh: node %d has no mesh", node)
+	}
+
+	meshIndex := *doc.Nodes[node].Mesh
+	if meshIndex < 0 || meshIndex >= len(doc.Meshes) {
+		return nil, fmt.Errorf("mesh: mesh %d out of range", meshIndex)
+	}
+
+	transform := WorldMatrix(doc, node)
+	m := &Mesh{}
+
+	for _, primitive := range doc.Meshes[meshIndex].Primitives {
+		if err := m.addPrimitive(doc, primitive, transform); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(m.Indices) == 0 {
+		return nil, errors.New("mesh: node has no triangles")
+	}
+
+	return m, nil
+}
+
+// FromDocument builds a single mesh out of every mesh node of the document, in world space.
+func FromDocument(doc *gltf.Document) (*Mesh, error) {
+	m := &Mesh{}
+
+	for i, node := range doc.Nodes {
+		if node.Mesh == nil {
+			continue
+		}
+
+		if *node.Mesh < 0 || *node.Mesh >= len(doc.Meshes) {
+			return nil, fmt.Errorf("mesh: mesh %d out of range", *node.Mesh)
+		}
+
+		transform := WorldMatrix(doc, i)
+		for _, primitive := range doc.Meshes[*node.Mesh].Primitives {
+			if err := m.addPrimitive(doc, primitive, transform); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(m.Indices) == 0 {
+		return nil, errors.New("mesh: document has no triangles")
+	}
+
+	return m, nil
+}
+
+// Scaled returns a copy of the mesh with every position multiplied by factor.
+func (m *Mesh) Scaled(factor float64) *Mesh {
+	out := &Mesh{
+		Positions: make([][3]float64, len(m.Positions)),
+		Indices:   m.Indices,
+	}
+	for i, p := range m.Positions {
+		out.Positions[i] = [3]float64{p[0] * factor, p[1] * factor, p[2] * factor}
+	}
+	return out
+}
+
+func (m *Mesh) addPrimitive(doc *gltf.Document, primitive *gltf.Primitive, transform [16]float64) error {
+	switch primitive.Mode {
+	case gltf.PrimitiveTriangles, gltf.PrimitiveTriangleStrip, gltf.PrimitiveTriangleFan:
+	default:
+		return nil
+	}
+
+	positions, err := modeler.ReadPrimitivePosition(doc, primitive)
+	if err != nil {
+		return err
+	}
+
+	indices, err := modeler.ReadPrimitiveIndices(doc, primitive)
+	if err != nil {
+		return err
+	}
+
+	base := uint32(len(m.Positions))
+	for _, p := range positions {
+		m.Positions = append(m.Positions, transformPoint(transform, p))
+	}
+
+	for _, index := range indices {
+		if int(index) >= len(positions) {
+			return fmt.Errorf("mesh: index %d out of range", index)
+		}
+	}
+
+	add := func(a, b, c uint32) {
+		m.Indices = append(m.Indices, base+a, base+b, base+c)
+	}
+
+	switch primitive.Mode {
+	case gltf.PrimitiveTriangles:
+		for i := 0; i+2 < len(indices); i += 3 {
+			add(indices[i], indices[i+1], indices[i+2])
+		}
+	case gltf.PrimitiveTriangleStrip:
+		// every other triangle of a strip is flipped to keep a consistent winding
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				add(indices[i], indices[i+1], indices[i+2])
+			} else {
+				add(indices[i+1], indices[i], indices[i+2])
+			}
+		}
+	case gltf.PrimitiveTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			add(indices[0], indices[i], indices[i+1])
+		}
+	}
+
+	return nil
+}
+
+// WorldMatrix returns the column-major transform of the node, combined with all of its ancestors.
+func WorldMatrix(doc *gltf.Document, node int) [16]float64 {
+	parents := map[int]int{}
+	for i, n := range doc.Nodes {
+		for _, child := range n.Children {
+			parents[child] = i
+		}
+	}
+
+	matrix := LocalMatrix(doc.Nodes[node])
+
+	// guard against cycles in malformed files
+	for depth := 0; depth < len(doc.Nodes); depth++ {
+		parent, ok := parents[node]
+		if !ok {
+			break
+		}
+
+		matrix = multiply(LocalMatrix(doc.Nodes[parent]), matrix)
+		node = parent
+	}
+
+	return matrix
+}
+
+// LocalMatrix returns the column-major transform of the node, from its matrix or its TRS properties.
+func LocalMatrix(n *gltf.Node) [16]float64 {
+	if n.Matrix != emptyMatrix && n.Matrix != gltf.DefaultMatrix {
+		return n.Matrix
+	}
+
+	t := n.TranslationOrDefault()
+	r := n.RotationOrDefault()
+	s := n.ScaleOrDefault()
+
+	x, y, z, w := r[0], r[1], r[2], r[3]
+
+	return [16]float64{
+		(1 - 2*(y*y+z*z)) * s[0], (2 * (x*y + z*w)) * s[0], (2 * (x*z - y*w)) * s[0], 0,
+		(2 * (x*y - z*w)) * s[1], (1 - 2*(x*x+z*z)) * s[1], (2 * (y*z + x*w)) * s[1], 0,
+		(2 * (x*z + y*w)) * s[2], (2 * (y*z - x*w)) * s[2], (1 - 2*(x*x+y*y)) * s[2], 0,
+		t[0], t[1], t[2], 1,
+	}
+}
+
+// emptyMatrix is the value of a node matrix that was left out of the document.
+var emptyMatrix = [16]float64{}
+
+func multiply(a, b [16]float64) [16]float64 {
+	var out [16]float64
+
+	for col := 0; col < 4; col++ {
+		for row := 0; row < 4; row++ {
+			var sum float64
+			for k := 0; k < 4; k++ {
+				sum += a[k*4+row] * b[col*4+k]
+			}
+			out[col*4+row] = sum
+		}
+	}
+
+	return out
+}
+
+func transformPoint(m [16]float64, p [3]float32) [3]float64 {
+	x, y, z := float64(p[0]), float64(p[1]), float64(p[2])
+
+	return [3]float64{
+		m[0]*x + m[4]*y + m[8]*z + m[12],
+		m[1]*x + m[5]*y + m[9]*z + m[13],
+		m[2]*x + m[6]*y + m[10]*z + m[14],
+	}
+}
+
+// Measurements are the geometric properties of a mesh, in the units of the scene.
+type Measurements struct {
+	SurfaceArea float64    `json:"surface_area"`
+	Volume      float64    `json:"volume"`
+	Centroid    [3]float64 `json:"centroid"`
+	Min         [3]float64 `json:"min"`
+	Max         [3]float64 `json:"max"`
+}
+
+// Extent returns the longest side of the bounding box.
+func (m Measurements) Extent() float64 {
+	return math.Max(m.Max[0]-m.Min[0], math.Max(m.Max[1]-m.Min[1], m.Max[2]-m.Min[2]))
+}
+
+// Measure computes the surface area, enclosed volume, centroid and axis-aligned bounding box.
+// The volume is the sum of the signed tetrahedra formed by each triangle and the origin, so it
+// is only meaningful for closed meshes, its sign is dropped so inverted windings still measure.
+// The centroid is the centroid of the enclosed volume, or of the surface when the mesh encloses none.
+func (m *Mesh) Measure() Measurements {
+	var (
+		out             Measurements
+		volumeCentroid  [3]float64
+		surfaceCentroid [3]float64
+		signedVolume    float64
+	)
+
+	if len(m.Positions) == 0 {
+		return out
+	}
+
+	out.Min = m.Positions[0]
+	out.Max = m.Positions[0]
+
+	for _, p := range m.Positions {
+		for axis := 0; axis < 3; axis++ {
+			out.Min[axis] = math.Min(out.Min[axis], p[axis])
+			out.Max[axis] = math.Max(out.Max[axis], p[axis])
+		}
+	}
+
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Positions[m.Indices[i]], m.Positions[m.Indices[i+1]], m.Positions[m.Indices[i+2]]
+
+		area := length(cross(sub(b, a), sub(c, a))) / 2
+		out.SurfaceArea += area
+
+		// signed volume of the tetrahedron (origin, a, b, c)
+		volume := dot(a, cross(b, c)) / 6
+		signedVolume += volume
+
+		for axis := 0; axis < 3; axis++ {
+			// the tetrahedron centroid is (a+b+c)/4 as its fourth vertex is the origin
+			volumeCentroid[axis] += volume * (a[axis] + b[axis] + c[axis]) / 4
+			surfaceCentroid[axis] += area * (a[axis] + b[axis] + c[axis]) / 3
+		}
+	}
+
+	out.Volume = math.Abs(signedVolume)
+
+	switch {
+	case out.Volume > 1e-12:
+		for axis := 0; axis < 3; axis++ {
+			out.Centroid[axis] = volumeCentroid[axis] / signedVolume
+		}
+	case out.SurfaceArea > 0:
+		for axis := 0; axis < 3; axis++ {
+			out.Centroid[axis] = surfaceCentroid[axis] / out.SurfaceArea
+		}
+	default:
+		for axis := 0; axis < 3; axis++ {
+			out.Centroid[axis] = (out.Min[axis] + out.Max[axis]) / 2
+		}
+	}
+
+	return out
+}
+
+func sub(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func length(a [3]float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
@@ -0,0 +1,168 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"neuroscan/pkg/gltf"
+)
+
+// cubeDocument returns a unit cube spanning 0..1 with outward facing triangles.
+func cubeDocument(t *testing.T) *gltf.Document {
+	t.Helper()
+
+	positions := []float32{
+		0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0,
+		0, 0, 1, 1, 0, 1, 1, 1, 1, 0, 1, 1,
+	}
+	indices := []uint16{
+		0, 2, 1, 0, 3, 2, // bottom
+		4, 5, 6, 4, 6, 7, // top
+		0, 1, 5, 0, 5, 4, // front
+		2, 3, 7, 2, 7, 6, // back
+		0, 4, 7, 0, 7, 3, // left
+		1, 2, 6, 1, 6, 5, // right
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, positions)
+	binary.Write(&buf, binary.LittleEndian, indices)
+
+	return &gltf.Document{
+		Buffers: []*gltf.Buffer{{ByteLength: buf.Len(), Data: buf.Bytes()}},
+		BufferViews: []*gltf.BufferView{
+			{Buffer: 0, ByteLength: len(positions) * 4},
+			{Buffer: 0, ByteOffset: len(positions) * 4, ByteLength: len(indices) * 2},
+		},
+		Accessors: []*gltf.Accessor{
+			{BufferView: gltf.Index(0), ComponentType: gltf.ComponentFloat, Type: gltf.AccessorVec3, Count: 8},
+			{BufferView: gltf.Index(1), ComponentType: gltf.ComponentUshort, Type: gltf.AccessorScalar, Count: len(indices)},
+		},
+		Meshes: []*gltf.Mesh{{Primitives: []*gltf.Primitive{{
+			Attributes: gltf.PrimitiveAttributes{gltf.POSITION: 0},
+			Indices:    gltf.Index(1),
+		}}}},
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestMeasureCube(t *testing.T) {
+	t.Parallel()
+
+	doc := cubeDocument(t)
+	doc.Nodes = []*gltf.Node{{Mesh: gltf.Index(0)}}
+
+	m, err := FromNode(doc, 0)
+	if err != nil {
+		t.Fatalf("Expected mesh to build, got %v", err)
+	}
+
+	measurements := m.Measure()
+
+	if !near(measurements.SurfaceArea, 6) {
+		t.Errorf("Expected surface area to be 6, got %v", measurements.SurfaceArea)
+	}
+
+	if !near(measurements.Volume, 1) {
+		t.Errorf("Expected volume to be 1, got %v", measurements.Volume)
+	}
+
+	for axis := 0; axis < 3; axis++ {
+		if !near(measurements.Centroid[axis], 0.5) {
+			t.Errorf("Expected centroid to be 0.5 on axis %d, got %v", axis, measurements.Centroid[axis])
+		}
+	}
+
+	if measurements.Min != [3]float64{0, 0, 0} || measurements.Max != [3]float64{1, 1, 1} {
+		t.Errorf("Expected bounding box 0..1, got %v %v", measurements.Min, measurements.Max)
+	}
+}
+
+func TestMeasureTransformedCube(t *testing.T) {
+	t.Parallel()
+
+	doc := cubeDocument(t)
+	doc.Nodes = []*gltf.Node{
+		{Children: []int{1}, Translation: [3]float64{10, 0, 0}},
+		{Mesh: gltf.Index(0), Scale: [3]float64{2, 2, 2}},
+	}
+
+	m, err := FromNode(doc, 1)
+	if err != nil {
+		t.Fatalf("Expected mesh to build, got %v", err)
+	}
+
+	measurements := m.Measure()
+
+	if !near(measurements.Volume, 8) {
+		t.Errorf("Expected volume to be 8, got %v", measurements.Volume)
+	}
+
+	if !near(measurements.SurfaceArea, 24) {
+		t.Errorf("Expected surface area to be 24, got %v", measurements.SurfaceArea)
+	}
+
+	if !near(measurements.Centroid[0], 11) || !near(measurements.Centroid[1], 1) {
+		t.Errorf("Expected centroid to be (11, 1, 1), got %v", measurements.Centroid)
+	}
+
+	if measurements.Min != [3]float64{10, 0, 0} || measurements.Max != [3]float64{12, 2, 2} {
+		t.Errorf("Expected bounding box (10,0,0)..(12,2,2), got %v %v", measurements.Min, measurements.Max)
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	t.Parallel()
+
+	doc := cubeDocument(t)
+	doc.Nodes = []*gltf.Node{{Mesh: gltf.Index(0), Scale: [3]float64{2, 2, 2}}}
+
+	m, err := FromDocument(doc)
+	if err != nil {
+		t.Fatalf("Expected mesh to build, got %v", err)
+	}
+
+	var obj bytes.Buffer
+	if err := m.Scaled(0.5).Write(&obj, FormatOBJ, "cube", "units: um"); err != nil {
+		t.Fatalf("Expected OBJ to be written, got %v", err)
+	}
+	if got := strings.Count(obj.String(), "\nv "); got != 8 {
+		t.Errorf("Expected 8 OBJ vertices, got %d", got)
+	}
+	if got := strings.Count(obj.String(), "\nf "); got != 12 {
+		t.Errorf("Expected 12 OBJ faces, got %d", got)
+	}
+	if !strings.Contains(obj.String(), "\nv 1 1 1\n") {
+		t.Errorf("Expected the scaled corner to be at 1 1 1, got %s", obj.String())
+	}
+
+	var stl bytes.Buffer
+	if err := m.Write(&stl, FormatSTL, "cube", ""); err != nil {
+		t.Fatalf("Expected STL to be written, got %v", err)
+	}
+	if stl.Len() != 84+12*50 {
+		t.Errorf("Expected binary STL of %d bytes, got %d", 84+12*50, stl.Len())
+	}
+
+	var ply bytes.Buffer
+	if err := m.Write(&ply, FormatPLY, "cube", "units: um"); err != nil {
+		t.Fatalf("Expected PLY to be written, got %v", err)
+	}
+	header, body, ok := strings.Cut(ply.String(), "end_header\n")
+	if !ok || !strings.Contains(header, "element vertex 8\n") || !strings.Contains(header, "element face 12\n") {
+		t.Errorf("Expected PLY header with 8 vertices and 12 faces, got %s", header)
+	}
+	if len(body) != 8*24+12*13 {
+		t.Errorf("Expected PLY body of %d bytes, got %d", 8*24+12*13, len(body))
+	}
+
+	if err := m.Write(&obj, "fbx", "cube", ""); err == nil {
+		t.Errorf("Expected unknown format to fail")
+	}
+}
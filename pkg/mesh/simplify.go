@@ -0,0 +1,362 @@
+package mesh
+
+import (
+	"container/heap"
+	"math"
+)
+
+// boundaryWeight scales the planes that keep open borders in place, contact
+// patches would otherwise shrink towards their centre as they are simplified.
+const boundaryWeight = 1000
+
+// Simplify returns a copy of the mesh reduced to at most targetTriangles triangles
+// by quadric edge collapse. Vertices sharing a position are welded first so that
+// seams do not tear open. Collapses that would flip a triangle are skipped, so the
+// result can hold more triangles than asked for when the mesh can't be reduced further.
+func (m *Mesh) Simplify(targetTriangles int) *Mesh {
+	s := newSimplifier(m)
+	s.run(max(targetTriangles, 1))
+	return s.mesh()
+}
+
+// quadric is a symmetric 4x4 matrix stored as its upper triangle:
+// a² ab ac ad b² bc bd c² cd d²
+type quadric [10]float64
+
+func planeQuadric(n [3]float64, d float64, weight float64) quadric {
+	a, b, c := n[0], n[1], n[2]
+	return quadric{
+		a * a * weight, a * b * weight, a * c * weight, a * d * weight,
+		b * b * weight, b * c * weight, b * d * weight,
+		c * c * weight, c * d * weight,
+		d * d * weight,
+	}
+}
+
+func (q quadric) add(o quadric) quadric {
+	for i := range q {
+		q[i] += o[i]
+	}
+	return q
+}
+
+func (q quadric) error(p [3]float64) float64 {
+	x, y, z := p[0], p[1], p[2]
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+}
+
+// optimal solves for the position minimizing the error, ok is false when the system is singular.
+func (q quadric) optimal() ([3]float64, bool) {
+	a := [3][3]float64{
+		{q[0], q[1], q[2]},
+		{q[1], q[4], q[5]},
+		{q[2], q[5], q[7]},
+	}
+	b := [3]float64{-q[3], -q[6], -q[8]}
+
+	det := determinant(a)
+	if math.Abs(det) < 1e-12 {
+		return [3]float64{}, false
+	}
+
+	var p [3]float64
+	for i := range 3 {
+		m := a
+		for row := range 3 {
+			m[row][i] = b[row]
+		}
+		p[i] = determinant(m) / det
+	}
+	return p, true
+}
+
+func determinant(m [3][3]float64) float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// collapse is a candidate edge collapse, stale once either vertex changed since it was queued.
+type collapse struct {
+	cost     float64
+	u, v     int
+	position [3]float64
+	versions [2]int
+}
+
+type collapseHeap []collapse
+
+func (h collapseHeap) Len() int           { return len(h) }
+func (h collapseHeap) Less(i, j int) bool { return h[i].cost < h[j].cost }
+func (h collapseHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *collapseHeap) Push(x any)        { *h = append(*h, x.(collapse)) }
+func (h *collapseHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+type simplifier struct {
+	positions [][3]float64
+	quadrics  []quadric
+	versions  []int
+	removed   []bool
+	faces     [][3]int
+	alive     []bool
+	// vertexFaces lists the faces touching each vertex, it may still hold dead faces
+	vertexFaces [][]int
+	triangles   int
+	queue       collapseHeap
+}
+
+func newSimplifier(m *Mesh) *simplifier {
+	s := &simplifier{}
+
+	welded := make(map[[3]float64]int, len(m.Positions))
+	remap := make([]int, len(m.Positions))
+	for i, p := range m.Positions {
+		index, ok := welded[p]
+		if !ok {
+			index = len(s.positions)
+			welded[p] = index
+			s.positions = append(s.positions, p)
+		}
+		remap[i] = index
+	}
+
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := int(m.Indices[i]), int(m.Indices[i+1]), int(m.Indices[i+2])
+		if a >= len(remap) || b >= len(remap) || c >= len(remap) {
+			continue
+		}
+		face := [3]int{remap[a], remap[b], remap[c]}
+		if face[0] == face[1] || face[1] == face[2] || face[0] == face[2] {
+			continue
+		}
+		s.faces = append(s.faces, face)
+	}
+
+	s.quadrics = make([]quadric, len(s.positions))
+	s.versions = make([]int, len(s.positions))
+	s.removed = make([]bool, len(s.positions))
+	s.vertexFaces = make([][]int, len(s.positions))
+	s.alive = make([]bool, len(s.faces))
+	s.triangles = len(s.faces)
+
+	edgeFaces := map[[2]int]int{}
+	for f, face := range s.faces {
+		s.alive[f] = true
+		normal, area := s.faceNormal(face)
+		q := planeQuadric(normal, -dot(normal, s.positions[face[0]]), area)
+		for k, v := range face {
+			s.quadrics[v] = s.quadrics[v].add(q)
+			s.vertexFaces[v] = append(s.vertexFaces[v], f)
+			edgeFaces[edgeKey(v, face[(k+1)%3])]++
+		}
+	}
+
+	// constrain border edges with a plane perpendicular to their face
+	for _, face := range s.faces {
+		normal, _ := s.faceNormal(face)
+		for k := range face {
+			u, v := face[k], face[(k+1)%3]
+			if edgeFaces[edgeKey(u, v)] != 1 {
+				continue
+			}
+			edge := sub(s.positions[v], s.positions[u])
+			planeNormal := cross(edge, normal)
+			l := length(planeNormal)
+			if l == 0 {
+				continue
+			}
+			planeNormal = [3]float64{planeNormal[0] / l, planeNormal[1] / l, planeNormal[2] / l}
+			q := planeQuadric(planeNormal, -dot(planeNormal, s.positions[u]), boundaryWeight*dot(edge, edge))
+			s.quadrics[u] = s.quadrics[u].add(q)
+			s.quadrics[v] = s.quadrics[v].add(q)
+		}
+	}
+
+	for edge := range edgeFaces {
+		s.queue = append(s.queue, s.candidate(edge[0], edge[1]))
+	}
+	heap.Init(&s.queue)
+
+	return s
+}
+
+func edgeKey(u, v int) [2]int {
+	if u > v {
+		u, v = v, u
+	}
+	return [2]int{u, v}
+}
+
+// faceNormal returns the unit normal of a face and its area.
+func (s *simplifier) faceNormal(face [3]int) ([3]float64, float64) {
+	n := cross(sub(s.positions[face[1]], s.positions[face[0]]), sub(s.positions[face[2]], s.positions[face[0]]))
+	l := length(n)
+	if l == 0 {
+		return [3]float64{}, 0
+	}
+	return [3]float64{n[0] / l, n[1] / l, n[2] / l}, l / 2
+}
+
+// candidate prices collapsing the edge between u and v into a single vertex.
+func (s *simplifier) candidate(u, v int) collapse {
+	q := s.quadrics[u].add(s.quadrics[v])
+
+	position, ok := q.optimal()
+	if !ok {
+		// fall back to whichever of the ends or the midpoint costs the least
+		mid := [3]float64{
+			(s.positions[u][0] + s.positions[v][0]) / 2,
+			(s.positions[u][1] + s.positions[v][1]) / 2,
+			(s.positions[u][2] + s.positions[v][2]) / 2,
+		}
+		position = mid
+		for _, p := range [][3]float64{s.positions[u], s.positions[v]} {
+			if q.error(p) < q.error(position) {
+				position = p
+			}
+		}
+	}
+
+	return collapse{
+		cost:     math.Max(q.error(position), 0),
+		u:        u,
+		v:        v,
+		position: position,
+		versions: [2]int{s.versions[u], s.versions[v]},
+	}
+}
+
+func (s *simplifier) run(target int) {
+	for s.triangles > target && s.queue.Len() > 0 {
+		c := heap.Pop(&s.queue).(collapse)
+		if s.removed[c.u] || s.removed[c.v] || c.versions != [2]int{s.versions[c.u], s.versions[c.v]} {
+			continue
+		}
+		if s.flips(c.u, c.v, c.position) || s.flips(c.v, c.u, c.position) {
+			continue
+		}
+		s.collapse(c.u, c.v, c.position)
+	}
+}
+
+// flips reports whether moving u to position turns any face of u, not shared with v, upside down.
+func (s *simplifier) flips(u, v int, position [3]float64) bool {
+	for _, f := range s.vertexFaces[u] {
+		if !s.alive[f] {
+			continue
+		}
+		face := s.faces[f]
+		if face[0] == v || face[1] == v || face[2] == v {
+			continue
+		}
+
+		before, _ := s.faceNormal(face)
+		moved := s.positions[u]
+		s.positions[u] = position
+		after, area := s.faceNormal(face)
+		s.positions[u] = moved
+
+		if area == 0 || dot(before, after) < 0.2 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *simplifier) collapse(u, v int, position [3]float64) {
+	s.positions[u] = position
+	s.quadrics[u] = s.quadrics[u].add(s.quadrics[v])
+	s.removed[v] = true
+	s.versions[u]++
+
+	for _, f := range s.vertexFaces[v] {
+		if !s.alive[f] {
+			continue
+		}
+		face := &s.faces[f]
+		for k := range face {
+			if face[k] == v {
+				face[k] = u
+			}
+		}
+		if face[0] == face[1] || face[1] == face[2] || face[0] == face[2] {
+			s.alive[f] = false
+			s.triangles--
+			continue
+		}
+		s.vertexFaces[u] = append(s.vertexFaces[u], f)
+	}
+	s.vertexFaces[v] = nil
+
+	// drop dead faces and requeue the edges around u with its new quadric
+	faces := s.vertexFaces[u][:0]
+	neighbours := map[int]bool{}
+	for _, f := range s.vertexFaces[u] {
+		if !s.alive[f] {
+			continue
+		}
+		faces = append(faces, f)
+		for _, w := range s.faces[f] {
+			if w != u {
+				neighbours[w] = true
+			}
+		}
+	}
+	s.vertexFaces[u] = faces
+
+	for w := range neighbours {
+		heap.Push(&s.queue, s.candidate(u, w))
+	}
+}
+
+func (s *simplifier) mesh() *Mesh {
+	out := &Mesh{}
+	remap := make([]int, len(s.positions))
+	for i := range remap {
+		remap[i] = -1
+	}
+
+	for f, face := range s.faces {
+		if !s.alive[f] {
+			continue
+		}
+		for _, v := range face {
+			if remap[v] < 0 {
+				remap[v] = len(out.Positions)
+				out.Positions = append(out.Positions, s.positions[v])
+			}
+			out.Indices = append(out.Indices, uint32(remap[v]))
+		}
+	}
+
+	return out
+}
+
+// Normals returns the area weighted normal of each vertex.
+func (m *Mesh) Normals() [][3]float64 {
+	normals := make([][3]float64, len(m.Positions))
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Indices[i], m.Indices[i+1], m.Indices[i+2]
+		n := cross(sub(m.Positions[b], m.Positions[a]), sub(m.Positions[c], m.Positions[a]))
+		for _, v := range []uint32{a, b, c} {
+			normals[v] = [3]float64{normals[v][0] + n[0], normals[v][1] + n[1], normals[v][2] + n[2]}
+		}
+	}
+
+	for i, n := range normals {
+		if l := length(n); l > 0 {
+			normals[i] = [3]float64{n[0] / l, n[1] / l, n[2] / l}
+		} else {
+			normals[i] = [3]float64{0, 0, 1}
+		}
+	}
+	return normals
+}
@@ -0,0 +1,74 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+// sphere returns a closed UV sphere of radius 1 with duplicated seam vertices, like exporters write them.
+func sphere(rings, segments int) *Mesh {
+	m := &Mesh{}
+	for r := 0; r <= rings; r++ {
+		theta := math.Pi * float64(r) / float64(rings)
+		for s := 0; s <= segments; s++ {
+			phi := 2 * math.Pi * float64(s) / float64(segments)
+			m.Positions = append(m.Positions, [3]float64{
+				math.Sin(theta) * math.Cos(phi),
+				math.Cos(theta),
+				math.Sin(theta) * math.Sin(phi),
+			})
+		}
+	}
+
+	row := segments + 1
+	for r := 0; r < rings; r++ {
+		for s := 0; s < segments; s++ {
+			a := uint32(r*row + s)
+			b := a + uint32(row)
+			m.Indices = append(m.Indices, a, a+1, b, a+1, b+1, b)
+		}
+	}
+
+	// snap the poles and the seam so welding can find them
+	for i, p := range m.Positions {
+		for axis := range p {
+			m.Positions[i][axis] = math.Round(p[axis]*1e9) / 1e9
+		}
+	}
+	return m
+}
+
+func TestSimplifySphere(t *testing.T) {
+	t.Parallel()
+
+	m := sphere(32, 64)
+	original := m.Measure()
+	triangles := len(m.Indices) / 3
+	target := triangles / 10
+
+	simplified := m.Simplify(target)
+	got := len(simplified.Indices) / 3
+
+	if got > target {
+		t.Errorf("Expected at most %d triangles, got %d", target, got)
+	}
+
+	if got < target/2 {
+		t.Errorf("Expected close to %d triangles, got %d", target, got)
+	}
+
+	measured := simplified.Measure()
+	if math.Abs(measured.Volume-original.Volume)/original.Volume > 0.1 {
+		t.Errorf("Expected volume to stay within 10%% of %v, got %v", original.Volume, measured.Volume)
+	}
+
+	for axis := range 3 {
+		if math.Abs(measured.Min[axis]+1) > 0.1 || math.Abs(measured.Max[axis]-1) > 0.1 {
+			t.Errorf("Expected bounds to stay near the unit sphere on axis %d, got %v %v", axis, measured.Min, measured.Max)
+		}
+	}
+
+	if len(simplified.Normals()) != len(simplified.Positions) {
+		t.Errorf("Expected a normal per vertex, got %d for %d", len(simplified.Normals()), len(simplified.Positions))
+	}
+}
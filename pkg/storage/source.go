@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Source is a read-only file tree that can be walked with io/fs.
+// It is closed once the caller is done reading from it so any
+// temporary resources (spooled archives, network clients) are released.
+type Source interface {
+	fs.FS
+	io.Closer
+}
+
+// OpenSource opens the location as a Source. The location can be
+// a local directory, an S3 URL in the form s3://bucket/prefix, or a
+// tar archive (.tar, .tar.gz or .tgz). Gzip archives are decompressed into
+// tempDir, see OpenTarSource.
+func OpenSource(ctx context.Context, location string, tempDir string) (Source, error) {
+	if location == "" {
+		return nil, errors.New("source location is required")
+	}
+
+	if strings.HasPrefix(location, "s3://") {
+		bucket, prefix := parseS3URL(location)
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid s3 location %q", location)
+		}
+
+		return NewS3Source(ctx, bucket, prefix)
+	}
+
+	if isTarArchive(location) {
+		return OpenTarSource(location, tempDir)
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open source: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("source %q is not a directory or supported archive", location)
+	}
+
+	return dirSource{FS: os.DirFS(location)}, nil
+}
+
+// dirSource is a local directory, nothing needs to be released on close.
+type dirSource struct {
+	fs.FS
+}
+
+func (dirSource) Close() error {
+	return nil
+}
+
+// parseS3URL splits s3://bucket/prefix into its bucket and prefix.
+func parseS3URL(location string) (string, string) {
+	trimmed := strings.TrimPrefix(location, "s3://")
+	bucket, prefix, _ := strings.Cut(trimmed, "/")
+
+	return bucket, strings.Trim(prefix, "/")
+}
+
+func isTarArchive(location string) bool {
+	lower := strings.ToLower(location)
+
+	return strings.HasSuffix(lower, ".tar") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// s3API is the subset of the S3 client used by S3Source.
+type s3API interface {
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+}
+
+// S3Source exposes the objects under a bucket prefix as a read-only fs.FS.
+// Directories are derived from "/" separated keys and objects are streamed
+// from the bucket when opened, nothing is downloaded ahead of time.
+type S3Source struct {
+	ctx    context.Context
+	client s3API
+	bucket string
+	prefix string
+}
+
+// NewS3Source creates a source for s3://bucket/prefix using the S3_REGION and S3_PROFILE environment variables.
+func NewS3Source(ctx context.Context, bucket string, prefix string) (*S3Source, error) {
+	client := CreateS3Client(ctx, S3ClientConfig{
+		Bucket:  bucket,
+		Region:  os.Getenv("S3_REGION"),
+		Profile: os.Getenv("S3_PROFILE"),
+	})
+
+	return &S3Source{
+		ctx:    ctx,
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+	}, nil
+}
+
+// Open implements fs.FS.
+func (s *S3Source) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name != "." {
+		out, err := s.client.GetObject(s.ctx, &s3.GetObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(s.key(name)),
+		})
+		if err == nil {
+			return &s3File{
+				ReadCloser: out.Body,
+				info: s3Info{
+					name:    path.Base(name),
+					size:    aws.ToInt64(out.ContentLength),
+					modTime: aws.ToTime(out.LastModified),
+				},
+			}, nil
+		}
+
+		if !isS3NotFound(err) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+
+	entries, err := s.list(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &s3Dir{
+		info:    s3Info{name: path.Base(name), dir: true},
+		entries: entries,
+	}, nil
+}
+
+// Stat implements fs.StatFS.
+func (s *S3Source) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return s3Info{name: ".", dir: true}, nil
+	}
+
+	out, err := s.client.HeadObject(s.ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err == nil {
+		return s3Info{
+			name:    path.Base(name),
+			size:    aws.ToInt64(out.ContentLength),
+			modTime: aws.ToTime(out.LastModified),
+		}, nil
+	}
+
+	if !isS3NotFound(err) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	entries, err := s.list(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	if len(entries) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return s3Info{name: path.Base(name), dir: true}, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (s *S3Source) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := s.list(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	return entries, nil
+}
+
+// Close implements Source, the S3 client holds nothing that needs releasing.
+func (s *S3Source) Close() error {
+	return nil
+}
+
+func (s *S3Source) key(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+
+	if name == "." {
+		return s.prefix
+	}
+
+	return s.prefix + "/" + name
+}
+
+// list returns the direct children of the directory name using "/" as the delimiter.
+func (s *S3Source) list(name string) ([]fs.DirEntry, error) {
+	prefix := s.key(name)
+	if prefix != "" && prefix != "." {
+		prefix += "/"
+	} else {
+		prefix = ""
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Delimiter: aws.String("/"),
+	}
+
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	var entries []fs.DirEntry
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(s.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, common := range page.CommonPrefixes {
+			dirName := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(common.Prefix), prefix), "/")
+			if dirName == "" {
+				continue
+			}
+
+			entries = append(entries, fs.FileInfoToDirEntry(s3Info{name: dirName, dir: true}))
+		}
+
+		for _, object := range page.Contents {
+			fileName := strings.TrimPrefix(aws.ToString(object.Key), prefix)
+			// skip "folder" marker objects created by the console
+			if fileName == "" || strings.Contains(fileName, "/") {
+				continue
+			}
+
+			entries = append(entries, fs.FileInfoToDirEntry(s3Info{
+				name:    fileName,
+				size:    aws.ToInt64(object.Size),
+				modTime: aws.ToTime(object.LastModified),
+			}))
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries, nil
+}
+
+func isS3NotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+
+	return errors.As(err, &noSuchKey) || errors.As(err, &notFound)
+}
+
+type s3Info struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i s3Info) Name() string       { return i.name }
+func (i s3Info) Size() int64        { return i.size }
+func (i s3Info) ModTime() time.Time { return i.modTime }
+func (i s3Info) IsDir() bool        { return i.dir }
+func (i s3Info) Sys() any           { return nil }
+
+func (i s3Info) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+
+	return 0o444
+}
+
+type s3File struct {
+	io.ReadCloser
+	info s3Info
+}
+
+func (f *s3File) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+type s3Dir struct {
+	info    s3Info
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *s3Dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *s3Dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *s3Dir) Close() error {
+	return nil
+}
+
+func (d *s3Dir) ReadDir(count int) ([]fs.DirEntry, error) {
+	entries := d.entries[d.offset:]
+
+	if count <= 0 {
+		d.offset += len(entries)
+		return entries, nil
+	}
+
+	if len(entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if count < len(entries) {
+		entries = entries[:count]
+	}
+
+	d.offset += len(entries)
+
+	return entries, nil
+}
@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TarSource exposes the contents of a tar archive as a read-only fs.FS.
+// The archive is indexed once when opened and file contents are read
+// directly from the archive on demand, so entries are never held in memory.
+// Gzip compressed archives are first streamed to a temporary file as
+// compressed streams cannot be read at random offsets, and ingest walks the
+// tree before reading files concurrently and more than once. The temporary
+// directory therefore needs as much free space as the decompressed archive.
+type TarSource struct {
+	file    *os.File
+	temp    string
+	entries map[string]*tarEntry
+}
+
+type tarEntry struct {
+	name     string
+	offset   int64
+	size     int64
+	mode     fs.FileMode
+	modTime  time.Time
+	children []string
+}
+
+// OpenTarSource opens and indexes a .tar, .tar.gz or .tgz archive. A gzip
+// archive is decompressed into tempDir, or the default temporary directory
+// when it is empty.
+func OpenTarSource(name string, tempDir string) (*TarSource, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open archive: %w", err)
+	}
+
+	source := &TarSource{
+		file: file,
+		entries: map[string]*tarEntry{
+			".": {name: ".", mode: fs.ModeDir | 0o555},
+		},
+	}
+
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		if err := source.spool(tempDir); err != nil {
+			source.Close()
+			return nil, err
+		}
+	}
+
+	if err := source.index(); err != nil {
+		source.Close()
+		return nil, err
+	}
+
+	return source, nil
+}
+
+// spool decompresses the gzip stream into a temporary file that can be read at random offsets.
+func (s *TarSource) spool(tempDir string) error {
+	gz, err := gzip.NewReader(s.file)
+	if err != nil {
+		return fmt.Errorf("unable to read gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	temp, err := os.CreateTemp(tempDir, "neuroscan-source-*.tar")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary archive: %w", err)
+	}
+
+	_, err = io.Copy(temp, gz)
+
+	// swap in the spooled archive so Close cleans up the temp file, even on failure
+	s.file.Close()
+	s.file = temp
+	s.temp = temp.Name()
+
+	if err != nil {
+		return fmt.Errorf("unable to decompress archive into %s, it needs as much free space as the decompressed archive: %w", filepath.Dir(s.temp), err)
+	}
+
+	if _, err := temp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// index walks the tar headers once and records where each file's contents start.
+func (s *TarSource) index() error {
+	tr := tar.NewReader(s.file)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read archive: %w", err)
+		}
+
+		name := cleanArchiveName(header.Name)
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			s.addDir(name, header.ModTime)
+		case tar.TypeReg:
+			// the reader sits at the start of the entry's data right after Next
+			offset, err := s.file.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+
+			s.addDir(path.Dir(name), time.Time{})
+			s.entries[name] = &tarEntry{
+				name:    name,
+				offset:  offset,
+				size:    header.Size,
+				mode:    fs.FileMode(header.Mode).Perm(),
+				modTime: header.ModTime,
+			}
+			s.addChild(name)
+		}
+	}
+
+	for _, entry := range s.entries {
+		slices.Sort(entry.children)
+	}
+
+	return nil
+}
+
+// addDir registers the directory and all of its parents.
+func (s *TarSource) addDir(name string, modTime time.Time) {
+	if existing, ok := s.entries[name]; ok {
+		if existing.modTime.IsZero() {
+			existing.modTime = modTime
+		}
+		return
+	}
+
+	s.entries[name] = &tarEntry{
+		name:    name,
+		mode:    fs.ModeDir | 0o555,
+		modTime: modTime,
+	}
+
+	if name == "." {
+		return
+	}
+
+	s.addDir(path.Dir(name), time.Time{})
+	s.addChild(name)
+}
+
+func (s *TarSource) addChild(name string) {
+	parent := s.entries[path.Dir(name)]
+	base := path.Base(name)
+
+	if !slices.Contains(parent.children, base) {
+		parent.children = append(parent.children, base)
+	}
+}
+
+// Open implements fs.FS.
+func (s *TarSource) Open(name string) (fs.File, error) {
+	entry, err := s.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.mode.IsDir() {
+		return &tarDir{source: s, entry: entry}, nil
+	}
+
+	return &tarFile{
+		entry:         entry,
+		SectionReader: io.NewSectionReader(s.file, entry.offset, entry.size),
+	}, nil
+}
+
+// Stat implements fs.StatFS.
+func (s *TarSource) Stat(name string) (fs.FileInfo, error) {
+	entry, err := s.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return tarInfo{entry}, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (s *TarSource) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := s.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entry.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	return s.dirEntries(entry), nil
+}
+
+// Close releases the archive and removes any temporary file created for it.
+func (s *TarSource) Close() error {
+	err := s.file.Close()
+
+	if s.temp != "" {
+		if rmErr := os.Remove(s.temp); rmErr != nil && err == nil {
+			err = rmErr
+		}
+	}
+
+	return err
+}
+
+func (s *TarSource) lookup(op string, name string) (*tarEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	entry, ok := s.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return entry, nil
+}
+
+func (s *TarSource) dirEntries(dir *tarEntry) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(dir.children))
+
+	for _, child := range dir.children {
+		childName := child
+		if dir.name != "." {
+			childName = dir.name + "/" + child
+		}
+
+		entries = append(entries, fs.FileInfoToDirEntry(tarInfo{s.entries[childName]}))
+	}
+
+	return entries
+}
+
+func cleanArchiveName(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	name = path.Clean(name)
+
+	return strings.TrimPrefix(name, "./")
+}
+
+type tarInfo struct {
+	entry *tarEntry
+}
+
+func (i tarInfo) Name() string       { return path.Base(i.entry.name) }
+func (i tarInfo) Size() int64        { return i.entry.size }
+func (i tarInfo) Mode() fs.FileMode  { return i.entry.mode }
+func (i tarInfo) ModTime() time.Time { return i.entry.modTime }
+func (i tarInfo) IsDir() bool        { return i.entry.mode.IsDir() }
+func (i tarInfo) Sys() any           { return nil }
+
+type tarFile struct {
+	*io.SectionReader
+	entry *tarEntry
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) {
+	return tarInfo{f.entry}, nil
+}
+
+func (f *tarFile) Close() error {
+	return nil
+}
+
+type tarDir struct {
+	source *TarSource
+	entry  *tarEntry
+	offset int
+}
+
+func (d *tarDir) Stat() (fs.FileInfo, error) {
+	return tarInfo{d.entry}, nil
+}
+
+func (d *tarDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: errors.New("is a directory")}
+}
+
+func (d *tarDir) Close() error {
+	return nil
+}
+
+func (d *tarDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	entries := d.source.dirEntries(d.entry)[d.offset:]
+
+	if count <= 0 {
+		d.offset += len(entries)
+		return entries, nil
+	}
+
+	if len(entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if count < len(entries) {
+		entries = entries[:count]
+	}
+
+	d.offset += len(entries)
+
+	return entries, nil
+}
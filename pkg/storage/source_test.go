@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+var sourceFiles = map[string]string{
+	"L1/0/neurons/ADAL.gltf":       `{"asset":{"version":"2.0"}}`,
+	"L1/0/contacts/ADALbyAIY.gltf": `{"asset":{"version":"2.0"}}`,
+	"promoters/promoters.csv":      "uid,wormbase\n",
+}
+
+func writeTestArchive(t *testing.T, name string, compress bool) string {
+	t.Helper()
+
+	archivePath := filepath.Join(t.TempDir(), name)
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Expected archive to be created, got %v", err)
+	}
+	defer file.Close()
+
+	var w io.Writer = file
+	if compress {
+		gz := gzip.NewWriter(file)
+		defer gz.Close()
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	for name, content := range sourceFiles {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     "./" + name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		if err != nil {
+			t.Fatalf("Expected header to be written, got %v", err)
+		}
+
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Expected content to be written, got %v", err)
+		}
+	}
+
+	return archivePath
+}
+
+func expectedSourcePaths() []string {
+	paths := make([]string, 0, len(sourceFiles))
+	for name := range sourceFiles {
+		paths = append(paths, name)
+	}
+
+	return paths
+}
+
+func TestTarSource(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		compress bool
+	}{
+		{name: "release.tar", compress: false},
+		{name: "release.tar.gz", compress: true},
+		{name: "release.tgz", compress: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			archivePath := writeTestArchive(t, tc.name, tc.compress)
+
+			source, err := OpenSource(context.Background(), archivePath, "")
+			if err != nil {
+				t.Fatalf("Expected archive to open, got %v", err)
+			}
+			defer source.Close()
+
+			if err := fstest.TestFS(source, expectedSourcePaths()...); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := fs.ReadFile(source, "L1/0/neurons/ADAL.gltf")
+			if err != nil {
+				t.Fatalf("Expected file to be read, got %v", err)
+			}
+
+			if string(content) != sourceFiles["L1/0/neurons/ADAL.gltf"] {
+				t.Errorf("Expected %q, got %q", sourceFiles["L1/0/neurons/ADAL.gltf"], content)
+			}
+		})
+	}
+}
+
+func TestTarSourceRemovesSpooledArchive(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	source, err := OpenTarSource(writeTestArchive(t, "release.tar.gz", true), tempDir)
+	if err != nil {
+		t.Fatalf("Expected archive to open, got %v", err)
+	}
+
+	if source.temp == "" || filepath.Dir(source.temp) != tempDir {
+		t.Fatalf("Expected gzip archive to be spooled to a temporary file in %s, got %q", tempDir, source.temp)
+	}
+
+	if err := source.Close(); err != nil {
+		t.Fatalf("Expected archive to close, got %v", err)
+	}
+
+	if _, err := os.Stat(source.temp); !os.IsNotExist(err) {
+		t.Errorf("Expected temporary archive to be removed, got %v", err)
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range sourceFiles {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source, err := OpenSource(context.Background(), dir, "")
+	if err != nil {
+		t.Fatalf("Expected directory to open, got %v", err)
+	}
+	defer source.Close()
+
+	if err := fstest.TestFS(source, expectedSourcePaths()...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseS3URL(t *testing.T) {
+	t.Parallel()
+
+	bucket, prefix := parseS3URL("s3://neuroscan/releases/2025/")
+
+	if bucket != "neuroscan" {
+		t.Errorf("Expected bucket to be neuroscan, got %s", bucket)
+	}
+
+	if prefix != "releases/2025" {
+		t.Errorf("Expected prefix to be releases/2025, got %s", prefix)
+	}
+}